@@ -2,53 +2,340 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	_ "time/tzdata" // display.timezone works without system zoneinfo
 
+	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/yaml.v3"
+
+	"github.com/jamesruggles/reconsuite/internal/tools"
 )
 
 type ServerConfig struct {
-	Host string `yaml:"host"`
-	Port int    `yaml:"port"`
+	Host          string `yaml:"host"`
+	Port          int    `yaml:"port"`
+	MaxUploadSize int64  `yaml:"max_upload_size"` // bytes
+	MaxBodySize   int64  `yaml:"max_body_size"`   // bytes, for JSON API requests
+	// UploadTypes are the detected MIME types the metadata upload accepts;
+	// "image/*" matches a whole family and "*" anything. Empty accepts the
+	// types the extractor supports.
+	UploadTypes []string `yaml:"upload_types"`
+	APIKey      string   `yaml:"api_key"` // required by cross-project endpoints and WebSocket streams; empty disables them
+	// AllowedOrigins are extra WebSocket origin patterns (e.g. "recon.example.com");
+	// same-origin connections are always accepted.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+	// OutputBatchMS coalesces live output sent over the WebSocket into one
+	// array message per window: 0 batches only scans producing more than
+	// 200 lines a second (at 50ms), a positive value batches every scan at
+	// that window, and -1 disables batching.
+	OutputBatchMS int `yaml:"output_batch_ms"`
+	// LogStream copies the server log to GET /api/logs/stream (API key or
+	// login required). Off, logging has no extra handler.
+	LogStream bool `yaml:"log_stream"`
 }
 
 type DatabaseConfig struct {
-	Path string `yaml:"path"`
+	Path          string `yaml:"path"`
+	BusyTimeoutMS int    `yaml:"busy_timeout_ms"` // wait this long on a locked database before erroring
+	MaxOpenConns  int    `yaml:"max_open_conns"`
+	CacheSizeKB   int    `yaml:"cache_size_kb"` // per connection; 0 keeps SQLite's default
+	// BackupDir receives the snapshots POST /api/backup takes; BackupKeep
+	// is how many of them are kept, oldest removed first (0 keeps all).
+	BackupDir  string `yaml:"backup_dir"`
+	BackupKeep int    `yaml:"backup_keep"`
 }
 
 type ReportsConfig struct {
 	Directory string `yaml:"directory"`
 }
 
+// ScansConfig limits how many scans run at once and how many are kept. Zero
+// disables a limit.
+type ScansConfig struct {
+	MaxConcurrent           int `yaml:"max_concurrent"`
+	MaxConcurrentPerProject int `yaml:"max_concurrent_per_project"`
+	// HistoryLimit is how many finished runs of a tool against a target are
+	// kept per project; older ones are pruned when a new run completes.
+	HistoryLimit int `yaml:"history_limit"`
+	// RawOutputFileThreshold is the size in bytes above which a scan's raw
+	// output is written to a file under RawOutputDir, with only its path kept
+	// in the database. Zero keeps every output inline.
+	RawOutputFileThreshold int64  `yaml:"raw_output_file_threshold"`
+	RawOutputDir           string `yaml:"raw_output_dir"`
+}
+
+// SecurityConfig restricts what clients of the instance may do.
+type SecurityConfig struct {
+	// ReadOnly rejects every mutating /api/ request, for sharing results
+	// with people who shouldn't launch scans.
+	ReadOnly bool `yaml:"read_only"`
+	// Login puts every page and API route behind a username and password.
+	Login LoginConfig `yaml:"login"`
+	// Disclaimer customizes the terms shown on the welcome page.
+	Disclaimer DisclaimerConfig `yaml:"disclaimer"`
+	// AllowedOrigins are the origins ("https://dash.example.com", or "*" for
+	// any) whose pages may call the /api/ routes cross-origin. Empty sends no
+	// CORS headers, so only same-origin pages can use the API.
+	AllowedOrigins []string `yaml:"allowed_origins"`
+}
+
+// DisclaimerConfig replaces the built-in welcome page terms. File is an HTML
+// fragment, or Markdown if it ends in .md, resolved against the config file's
+// directory. Secret signs the acceptance cookie; when empty a random key is
+// made at startup, so a restart asks for acceptance again.
+type DisclaimerConfig struct {
+	File   string `yaml:"file"`
+	Secret string `yaml:"secret"`
+}
+
+// LoginConfig enables a single admin login when both Username and
+// PasswordHash (bcrypt) are set.
+type LoginConfig struct {
+	Username     string `yaml:"username"`
+	PasswordHash string `yaml:"password_hash"`
+	SessionHours int    `yaml:"session_hours"` // how long a login lasts
+}
+
+// Enabled reports whether login is required.
+func (l LoginConfig) Enabled() bool {
+	return l.Username != "" && l.PasswordHash != ""
+}
+
+// NetworkConfig controls how built-in scanners reach the network.
+type NetworkConfig struct {
+	DNSResolver string `yaml:"dns_resolver"` // host:port; empty uses the system resolver
+	SourceIP    string `yaml:"source_ip"`    // local address outbound builtin connections bind to
+}
+
+// NmapConfig tunes how nmap scans are built.
+type NmapConfig struct {
+	// Privileged says whether nmap may use raw sockets (root or
+	// CAP_NET_RAW). Unset means "only when running as root".
+	Privileged *bool `yaml:"privileged"`
+}
+
+// WebConfig tunes the built-in web recon tools.
+type WebConfig struct {
+	// InterestingHeaders are the response headers metadata_extract records.
+	InterestingHeaders []string `yaml:"interesting_headers"`
+	// CaptureEvidence keeps a bounded snippet of the HTTP request and
+	// response behind web findings, for the report's evidence appendix.
+	CaptureEvidence bool `yaml:"capture_evidence"`
+}
+
+// DisplayConfig controls how times are shown.
+type DisplayConfig struct {
+	// Timezone is the IANA zone ("Europe/Berlin", "UTC") reports and the
+	// web UI show times in. Empty uses the server's zone for reports and the
+	// browser's for the UI. Times are stored and returned by the API in UTC
+	// either way.
+	Timezone string `yaml:"timezone"`
+}
+
+// DefaultInterestingHeaders is used when web.interesting_headers is unset.
+var DefaultInterestingHeaders = []string{
+	"Server", "X-Powered-By", "Content-Type",
+	"X-Frame-Options", "X-Content-Type-Options",
+	"Strict-Transport-Security", "Content-Security-Policy",
+	"X-XSS-Protection", "Access-Control-Allow-Origin",
+	"Via", "X-Cache", "X-AspNet-Version", "X-Generator",
+}
+
+// DefaultBuiltinConcurrency is the worker count for builtins that probe many
+// hosts at once, kept low so scans don't trip rate limits or IDS alerts.
+const DefaultBuiltinConcurrency = 4
+
+type ToolsConfig struct {
+	Nmap NmapConfig `yaml:"nmap"`
+	// BuiltinConcurrency is the worker-pool size for concurrent builtins;
+	// BuiltinConcurrencyPerTool overrides it by tool name.
+	BuiltinConcurrency        int            `yaml:"builtin_concurrency"`
+	BuiltinConcurrencyPerTool map[string]int `yaml:"builtin_concurrency_per_tool"`
+	// DetectTimeoutMS bounds each version command the tools status check
+	// runs, and DetectConcurrency how many run at once; 0 uses 3000 and 4.
+	DetectTimeoutMS   int `yaml:"detect_timeout_ms"`
+	DetectConcurrency int `yaml:"detect_concurrency"`
+	// Env sets environment variables for external tools, keyed by tool name
+	// ("theharvester", "nmap", ...); "*" applies to every tool, and a tool's
+	// own entry wins over it.
+	Env map[string]map[string]string `yaml:"env"`
+}
+
 type Config struct {
+	// DataDir is the root that a relative database.path and
+	// reports.directory are resolved against. A relative DataDir is itself
+	// resolved against the directory holding the config file, so the binary
+	// finds the same data whatever directory it is started from.
+	// Without a config file, the working directory is used.
+	DataDir  string         `yaml:"data_dir"`
 	Server   ServerConfig   `yaml:"server"`
 	Database DatabaseConfig `yaml:"database"`
 	Reports  ReportsConfig  `yaml:"reports"`
+	Scans    ScansConfig    `yaml:"scans"`
+	Network  NetworkConfig  `yaml:"network"`
+	Tools    ToolsConfig    `yaml:"tools"`
+	Web      WebConfig      `yaml:"web"`
+	Security SecurityConfig `yaml:"security"`
+	Display  DisplayConfig  `yaml:"display"`
 }
 
 func defaults() *Config {
 	return &Config{
+		DataDir: ".",
 		Server: ServerConfig{
-			Host: "127.0.0.1",
-			Port: 8080,
+			Host:          "127.0.0.1",
+			Port:          8080,
+			MaxUploadSize: 50 << 20, // 50MB
+			MaxBodySize:   1 << 20,  // 1MB
 		},
 		Database: DatabaseConfig{
-			Path: "reconsuite.db",
+			Path:          "reconsuite.db",
+			BusyTimeoutMS: 5000,
+			MaxOpenConns:  1,
+			BackupDir:     "./backups",
+			BackupKeep:    10,
 		},
 		Reports: ReportsConfig{
 			Directory: "./reports",
 		},
+		Scans: ScansConfig{
+			MaxConcurrent:          3,
+			RawOutputFileThreshold: 1 << 20, // 1MB
+			RawOutputDir:           "./raw_output",
+		},
+		Web: WebConfig{
+			InterestingHeaders: DefaultInterestingHeaders,
+			CaptureEvidence:    true,
+		},
+		Security: SecurityConfig{
+			Login: LoginConfig{SessionHours: 12},
+		},
+	}
+}
+
+// BuiltinConcurrency returns the configured worker count for a builtin tool.
+func (c *Config) BuiltinConcurrency(tool string) int {
+	if n := c.Tools.BuiltinConcurrencyPerTool[tool]; n > 0 {
+		return n
+	}
+	if c.Tools.BuiltinConcurrency > 0 {
+		return c.Tools.BuiltinConcurrency
+	}
+	return DefaultBuiltinConcurrency
+}
+
+// ToolEnv returns the environment variables configured for an external tool:
+// the "*" entries overlaid with the tool's own. It returns nil when none are set.
+func (c *Config) ToolEnv(tool string) map[string]string {
+	if len(c.Tools.Env["*"]) == 0 && len(c.Tools.Env[tool]) == 0 {
+		return nil
+	}
+	env := make(map[string]string)
+	for k, v := range c.Tools.Env["*"] {
+		env[k] = v
+	}
+	for k, v := range c.Tools.Env[tool] {
+		env[k] = v
+	}
+	return env
+}
+
+// Location returns the zone from display.timezone, or the server's local
+// zone when it is unset. Load has already checked the name.
+func (c *Config) Location() *time.Location {
+	if c.Display.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.Display.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// NmapPrivileged reports whether nmap scans may use raw-socket features.
+func (c *Config) NmapPrivileged() bool {
+	if c.Tools.Nmap.Privileged != nil {
+		return *c.Tools.Nmap.Privileged
+	}
+	return os.Geteuid() == 0
+}
+
+// normalizeOrigin checks that origin is "*" or a scheme://host[:port] origin
+// and returns it lower-cased without a trailing slash, as browsers send it.
+func normalizeOrigin(origin string) (string, error) {
+	o := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+	if o == "*" {
+		return o, nil
+	}
+	u, err := url.Parse(o)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		u.Path != "" || u.RawQuery != "" || u.Fragment != "" || u.User != nil {
+		return "", fmt.Errorf("%q is not an origin like https://dash.example.com", origin)
 	}
+	return o, nil
+}
+
+// resolvePaths makes DataDir absolute, relative to base, and roots relative
+// database, backup, reports and raw output paths under it. A relative disclaimer file is taken
+// relative to base.
+func (c *Config) resolvePaths(base string) error {
+	if c.DataDir == "" {
+		c.DataDir = "."
+	}
+	if !filepath.IsAbs(c.DataDir) {
+		c.DataDir = filepath.Join(base, c.DataDir)
+	}
+	dir, err := filepath.Abs(c.DataDir)
+	if err != nil {
+		return fmt.Errorf("resolving data_dir: %w", err)
+	}
+	c.DataDir = dir
+
+	// ":memory:" and "file:" URIs are SQLite DSNs, not paths
+	if p := c.Database.Path; p != ":memory:" && !strings.HasPrefix(p, "file:") && !filepath.IsAbs(p) {
+		c.Database.Path = filepath.Join(c.DataDir, p)
+	}
+	if !filepath.IsAbs(c.Database.BackupDir) {
+		c.Database.BackupDir = filepath.Join(c.DataDir, c.Database.BackupDir)
+	}
+	if !filepath.IsAbs(c.Reports.Directory) {
+		c.Reports.Directory = filepath.Join(c.DataDir, c.Reports.Directory)
+	}
+	if !filepath.IsAbs(c.Scans.RawOutputDir) {
+		c.Scans.RawOutputDir = filepath.Join(c.DataDir, c.Scans.RawOutputDir)
+	}
+	if f := c.Security.Disclaimer.File; f != "" && !filepath.IsAbs(f) {
+		c.Security.Disclaimer.File = filepath.Join(base, f)
+	}
+	return nil
+}
+
+// EnsureDirs creates the data directory and the directories holding the
+// database and reports.
+func (c *Config) EnsureDirs() error {
+	dirs := []string{c.DataDir, c.Reports.Directory}
+	if filepath.IsAbs(c.Database.Path) {
+		dirs = append(dirs, filepath.Dir(c.Database.Path))
+	}
+	for _, dir := range dirs {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", dir, err)
+		}
+	}
+	return nil
 }
 
 func Load(path string) (*Config, error) {
 	cfg := defaults()
 
 	data, err := os.ReadFile(path)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return cfg, nil
-		}
+	if err != nil && !os.IsNotExist(err) {
 		return nil, fmt.Errorf("reading config: %w", err)
 	}
 
@@ -56,5 +343,62 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("parsing config: %w", err)
 	}
 
+	// without a config file, relative paths stay relative to the working directory
+	base := "."
+	if data != nil {
+		base = filepath.Dir(path)
+	}
+	if err := cfg.resolvePaths(base); err != nil {
+		return nil, err
+	}
+
+	if cfg.Server.MaxUploadSize <= 0 {
+		return nil, fmt.Errorf("server.max_upload_size must be positive")
+	}
+
+	if cfg.Database.BackupKeep < 0 {
+		return nil, fmt.Errorf("database.backup_keep must not be negative")
+	}
+
+	if cfg.Network.SourceIP != "" && net.ParseIP(cfg.Network.SourceIP) == nil {
+		return nil, fmt.Errorf("network.source_ip %q is not a valid IP address", cfg.Network.SourceIP)
+	}
+
+	if login := cfg.Security.Login; login.Username != "" || login.PasswordHash != "" {
+		if !login.Enabled() {
+			return nil, fmt.Errorf("security.login needs both username and password_hash")
+		}
+		if _, err := bcrypt.Cost([]byte(login.PasswordHash)); err != nil {
+			return nil, fmt.Errorf("security.login.password_hash is not a bcrypt hash: %w", err)
+		}
+		if login.SessionHours <= 0 {
+			return nil, fmt.Errorf("security.login.session_hours must be positive")
+		}
+	}
+
+	for i, origin := range cfg.Security.AllowedOrigins {
+		o, err := normalizeOrigin(origin)
+		if err != nil {
+			return nil, fmt.Errorf("security.allowed_origins: %w", err)
+		}
+		cfg.Security.AllowedOrigins[i] = o
+	}
+
+	if cfg.Display.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Display.Timezone); err != nil {
+			return nil, fmt.Errorf("display.timezone: %w", err)
+		}
+	}
+
+	for tool, env := range cfg.Tools.Env {
+		if _, err := tools.EnvList(env); err != nil {
+			return nil, fmt.Errorf("tools.env.%s: %w", tool, err)
+		}
+	}
+
+	if len(cfg.Web.InterestingHeaders) == 0 {
+		cfg.Web.InterestingHeaders = DefaultInterestingHeaders
+	}
+
 	return cfg, nil
 }
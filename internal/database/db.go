@@ -1,32 +1,69 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// ErrNotFound is returned by operations that target a missing row.
+var ErrNotFound = errors.New("not found")
+
 type DB struct {
 	*sql.DB
 }
 
-func New(dsn string) (*DB, error) {
+// Options tunes the connection pool and per-connection pragmas.
+type Options struct {
+	// BusyTimeout is how long a connection waits on a lock held by another
+	// writer before failing with "database is locked".
+	BusyTimeout time.Duration
+	// MaxOpenConns caps the pool; 0 means 1, which serializes all access.
+	MaxOpenConns int
+	// CacheSizeKB sets the page cache per connection; 0 keeps SQLite's default.
+	CacheSizeKB int
+}
+
+func New(dsn string, opts Options) (*DB, error) {
+	// Pragmas in the DSN are applied to every pooled connection, not just
+	// whichever one happens to run an Exec.
+	pragmas := []string{
+		fmt.Sprintf("busy_timeout(%d)", opts.BusyTimeout.Milliseconds()),
+		"foreign_keys(1)",
+	}
+	if opts.CacheSizeKB > 0 {
+		pragmas = append(pragmas, fmt.Sprintf("cache_size(-%d)", opts.CacheSizeKB))
+	}
+	sep := "?"
+	if strings.Contains(dsn, "?") {
+		sep = "&"
+	}
+	for _, p := range pragmas {
+		dsn += sep + "_pragma=" + p
+		sep = "&"
+	}
+
 	sqlDB, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("opening database: %w", err)
 	}
 
-	sqlDB.SetMaxOpenConns(1)
+	maxConns := opts.MaxOpenConns
+	if maxConns < 1 {
+		maxConns = 1
+	}
+	sqlDB.SetMaxOpenConns(maxConns)
 
 	if _, err := sqlDB.Exec("PRAGMA journal_mode=WAL"); err != nil {
 		sqlDB.Close()
 		return nil, fmt.Errorf("setting WAL mode: %w", err)
 	}
-	if _, err := sqlDB.Exec("PRAGMA foreign_keys=ON"); err != nil {
-		sqlDB.Close()
-		return nil, fmt.Errorf("enabling foreign keys: %w", err)
-	}
 
 	db := &DB{sqlDB}
 	if err := db.migrate(); err != nil {
@@ -37,7 +74,71 @@ func New(dsn string) (*DB, error) {
 	return db, nil
 }
 
+// Backup writes a consistent copy of the database to path with VACUUM INTO,
+// which reads from a single snapshot, so it is safe while the server keeps
+// writing under WAL. The copy is written next to path and renamed into
+// place, so path never holds a partial file. Raw output kept in files is
+// not included.
+func (db *DB) Backup(ctx context.Context, path string) error {
+	tmp := path + ".tmp"
+	os.Remove(tmp) // VACUUM INTO refuses an existing file
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("backup database: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("backup database: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) migrate() error {
-	_, err := db.Exec(schema)
-	return err
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	for _, m := range columnMigrations {
+		exists, err := db.columnExists(m.table, m.column)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, m.table, m.column, m.definition)); err != nil {
+			return fmt.Errorf("adding %s.%s: %w", m.table, m.column, err)
+		}
+		if m.backfill != "" {
+			if _, err := db.Exec(m.backfill); err != nil {
+				return fmt.Errorf("backfilling %s.%s: %w", m.table, m.column, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (db *DB) columnExists(table, column string) (bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`PRAGMA table_info(%s)`, table))
+	if err != nil {
+		return false, fmt.Errorf("table info %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("scan table info: %w", err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
 }
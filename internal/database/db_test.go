@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrentWritesDoNotFailBusy(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxOpenConns int
+	}{
+		{"single connection", 1},
+		{"pooled", 4},
+		{"pool larger than writers", 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := New(filepath.Join(t.TempDir(), "recon.db"), Options{
+				BusyTimeout:  5 * time.Second,
+				MaxOpenConns: tt.maxOpenConns,
+			})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			defer db.Close()
+
+			const writers, scansPerWriter, resultsPerScan = 8, 10, 20
+			var wg sync.WaitGroup
+			errs := make(chan error, writers*scansPerWriter)
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < scansPerWriter; i++ {
+						scan := &Scan{ScanType: "dns", Tool: "dig", Target: fmt.Sprintf("w%d-%d.example.com", w, i), Status: "running"}
+						if err := db.CreateScan(scan); err != nil {
+							errs <- err
+							continue
+						}
+						results := make([]Result, resultsPerScan)
+						for j := range results {
+							results[j] = Result{ScanID: scan.ID, ResultType: "dns", Key: "A", Value: fmt.Sprintf("192.0.2.%d", j)}
+						}
+						if err := db.CreateResults(results); err != nil {
+							errs <- err
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if strings.Contains(err.Error(), "SQLITE_BUSY") || strings.Contains(err.Error(), "database is locked") {
+					t.Errorf("busy error: %v", err)
+				} else {
+					t.Errorf("write: %v", err)
+				}
+			}
+
+			var scans, results int
+			if err := db.QueryRow(`SELECT COUNT(*) FROM scans`).Scan(&scans); err != nil {
+				t.Fatalf("count scans: %v", err)
+			}
+			if err := db.QueryRow(`SELECT COUNT(*) FROM results`).Scan(&results); err != nil {
+				t.Fatalf("count results: %v", err)
+			}
+			if scans != writers*scansPerWriter || results != writers*scansPerWriter*resultsPerScan {
+				t.Errorf("stored %d scans and %d results, want %d and %d",
+					scans, results, writers*scansPerWriter, writers*scansPerWriter*resultsPerScan)
+			}
+		})
+	}
+}
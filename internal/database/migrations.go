@@ -6,6 +6,7 @@ CREATE TABLE IF NOT EXISTS projects (
     name TEXT NOT NULL,
     description TEXT DEFAULT '',
     scope TEXT DEFAULT '',
+    pinned INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
@@ -16,9 +17,12 @@ CREATE TABLE IF NOT EXISTS scans (
     scan_type TEXT NOT NULL,
     tool TEXT NOT NULL,
     target TEXT NOT NULL,
+    label TEXT NOT NULL DEFAULT '',
+    tags TEXT NOT NULL DEFAULT '',
     parameters TEXT DEFAULT '{}',
     status TEXT DEFAULT 'pending',
     raw_output TEXT DEFAULT '',
+    raw_output_file TEXT NOT NULL DEFAULT '',
     started_at DATETIME,
     completed_at DATETIME,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
@@ -31,6 +35,9 @@ CREATE TABLE IF NOT EXISTS results (
     key TEXT NOT NULL,
     value TEXT DEFAULT '',
     details TEXT DEFAULT '',
+    interesting INTEGER NOT NULL DEFAULT 0,
+    tool TEXT NOT NULL DEFAULT '',
+    scan_type TEXT NOT NULL DEFAULT '',
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
@@ -44,9 +51,44 @@ CREATE TABLE IF NOT EXISTS reports (
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS disclaimer_acceptances (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    client_ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    terms_hash TEXT NOT NULL DEFAULT '',
+    accepted_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
 CREATE INDEX IF NOT EXISTS idx_scans_project ON scans(project_id);
 CREATE INDEX IF NOT EXISTS idx_scans_status ON scans(status);
 CREATE INDEX IF NOT EXISTS idx_results_scan ON results(scan_id);
+CREATE INDEX IF NOT EXISTS idx_scans_subject ON scans(project_id, tool, target, created_at);
 CREATE INDEX IF NOT EXISTS idx_results_type ON results(result_type);
+CREATE INDEX IF NOT EXISTS idx_results_scan_key ON results(scan_id, result_type, key);
 CREATE INDEX IF NOT EXISTS idx_reports_project ON reports(project_id);
 `
+
+// columnMigrations adds columns introduced after the initial schema. Each is
+// applied only when the column is missing, so existing databases upgrade in
+// place; backfill, if set, runs once right after the column is added.
+var columnMigrations = []struct {
+	table      string
+	column     string
+	definition string
+	backfill   string
+}{
+	{"results", "interesting", "INTEGER NOT NULL DEFAULT 0", ""},
+	{"scans", "label", "TEXT NOT NULL DEFAULT ''", ""},
+	{"projects", "pinned", "INTEGER NOT NULL DEFAULT 0", ""},
+	{"results", "tool", "TEXT NOT NULL DEFAULT ''",
+		`UPDATE results SET tool = COALESCE((SELECT tool FROM scans WHERE scans.id = results.scan_id), '')`},
+	{"results", "scan_type", "TEXT NOT NULL DEFAULT ''",
+		`UPDATE results SET scan_type = COALESCE((SELECT scan_type FROM scans WHERE scans.id = results.scan_id), '')`},
+	{"scans", "tags", "TEXT NOT NULL DEFAULT ''", ""},
+	// Output files used to be referenced in-band as raw_output
+	// "file://<path>"; only values naming the scan's own scan-<id>.log move
+	// over, so tool output that merely starts with file:// stays output.
+	{"scans", "raw_output_file", "TEXT NOT NULL DEFAULT ''",
+		`UPDATE scans SET raw_output_file = substr(raw_output, 8), raw_output = ''
+		 WHERE raw_output LIKE 'file://%/scan-' || id || '.log' AND instr(raw_output, char(10)) = 0`},
+}
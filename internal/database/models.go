@@ -7,32 +7,91 @@ type Project struct {
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
 	Scope       string    `json:"scope"`
+	Pinned      bool      `json:"pinned"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type Scan struct {
-	ID          int64      `json:"id"`
-	ProjectID   int64      `json:"project_id"`
-	ScanType    string     `json:"scan_type"`
-	Tool        string     `json:"tool"`
-	Target      string     `json:"target"`
-	Parameters  string     `json:"parameters"`
-	Status      string     `json:"status"`
-	RawOutput   string     `json:"raw_output,omitempty"`
-	StartedAt   *time.Time `json:"started_at,omitempty"`
-	CompletedAt *time.Time `json:"completed_at,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
+	ID         int64  `json:"id"`
+	ProjectID  int64  `json:"project_id"`
+	ScanType   string `json:"scan_type"`
+	Tool       string `json:"tool"`
+	Target     string `json:"target"`
+	Label      string `json:"label,omitempty"`
+	Parameters string `json:"parameters"`
+	Status     string `json:"status"`
+	RawOutput  string `json:"raw_output,omitempty"`
+	// Tags sort a scan within its project, by phase or methodology
+	// ("initial", "follow-up"). They are lower-case and never contain commas.
+	Tags []string `json:"tags"`
+	// RawOutputFile is where output too large to keep inline was written;
+	// RawOutput is then empty. ReadRawOutput returns either. The path stays
+	// on the server: JSON only says, through RawOutputInFile, that there is
+	// one.
+	RawOutputFile   string     `json:"-"`
+	RawOutputInFile bool       `json:"raw_output_in_file,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+
+	// QueuePosition and QueuedAhead (how many scans will start before it)
+	// are computed by the executor for queued scans, ElapsedSeconds for
+	// running ones. None are stored.
+	QueuePosition  int   `json:"queue_position,omitempty"`
+	QueuedAhead    *int  `json:"queued_ahead,omitempty"`
+	ElapsedSeconds int64 `json:"elapsed_seconds,omitempty"`
+}
+
+// Finished reports whether the scan has reached a final status: completed,
+// failed (tool error), timed_out (hit its tool timeout) or cancelled.
+func (s *Scan) Finished() bool {
+	switch s.Status {
+	case "completed", "failed", "timed_out", "cancelled":
+		return true
+	}
+	return false
 }
 
 type Result struct {
-	ID         int64     `json:"id"`
-	ScanID     int64     `json:"scan_id"`
-	ResultType string    `json:"result_type"`
-	Key        string    `json:"key"`
-	Value      string    `json:"value"`
-	Details    string    `json:"details,omitempty"`
-	CreatedAt  time.Time `json:"created_at"`
+	ID          int64     `json:"id"`
+	ScanID      int64     `json:"scan_id"`
+	ResultType  string    `json:"result_type"`
+	Key         string    `json:"key"`
+	Value       string    `json:"value"`
+	Details     string    `json:"details,omitempty"`
+	Interesting bool      `json:"interesting"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Tool and ScanType are copied from the scan when the result is stored.
+	Tool     string `json:"tool"`
+	ScanType string `json:"scan_type"`
+
+	// Stale is computed when results are listed by scan or project: a later
+	// completed scan of the same tool and target did not report the result
+	// again. AgeSeconds, since CreatedAt, is filled in by the API. Neither is
+	// stored.
+	Stale      bool  `json:"stale,omitempty"`
+	AgeSeconds int64 `json:"age_seconds,omitempty"`
+}
+
+// ProjectResult is a result annotated with the scan and project it came from,
+// as returned by cross-project searches. ProjectID is 0 for quick scans.
+type ProjectResult struct {
+	Result
+	Target      string `json:"target"`
+	ProjectID   int64  `json:"project_id"`
+	ProjectName string `json:"project_name"`
+}
+
+// ResultFilter narrows a cross-project result search. Empty fields match
+// everything; Value is a substring match.
+type ResultFilter struct {
+	ResultType string
+	Key        string
+	Value      string
+	Limit      int
+	Offset     int
 }
 
 type Report struct {
@@ -44,3 +103,25 @@ type Report struct {
 	FilePath  string    `json:"file_path"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// DisclaimerAcceptance records one acceptance of the welcome page terms.
+// TermsHash identifies the text that was accepted.
+type DisclaimerAcceptance struct {
+	ID         int64     `json:"id"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	TermsHash  string    `json:"terms_hash"`
+	AcceptedAt time.Time `json:"accepted_at"`
+}
+
+// Activity is one event in the instance-wide activity feed. Type is
+// scan_started, scan_<final status> (scan_completed, scan_failed, ...),
+// project_created or report_generated; ID is the scan, project or report.
+type Activity struct {
+	Type      string    `json:"type"`
+	ID        int64     `json:"id"`
+	ProjectID int64     `json:"project_id,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Summary   string    `json:"summary"`
+	Link      string    `json:"link"`
+}
@@ -3,6 +3,8 @@ package database
 import (
 	"database/sql"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -23,8 +25,8 @@ func (db *DB) CreateProject(p *Project) error {
 func (db *DB) GetProject(id int64) (*Project, error) {
 	p := &Project{}
 	err := db.QueryRow(
-		`SELECT id, name, description, scope, created_at, updated_at FROM projects WHERE id = ?`, id,
-	).Scan(&p.ID, &p.Name, &p.Description, &p.Scope, &p.CreatedAt, &p.UpdatedAt)
+		`SELECT id, name, description, scope, pinned, created_at, updated_at FROM projects WHERE id = ?`, id,
+	).Scan(&p.ID, &p.Name, &p.Description, &p.Scope, &p.Pinned, &p.CreatedAt, &p.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -34,8 +36,9 @@ func (db *DB) GetProject(id int64) (*Project, error) {
 	return p, nil
 }
 
+// ListProjects returns pinned projects first, then the most recently updated.
 func (db *DB) ListProjects() ([]Project, error) {
-	rows, err := db.Query(`SELECT id, name, description, scope, created_at, updated_at FROM projects ORDER BY updated_at DESC`)
+	rows, err := db.Query(`SELECT id, name, description, scope, pinned, created_at, updated_at FROM projects ORDER BY pinned DESC, updated_at DESC`)
 	if err != nil {
 		return nil, fmt.Errorf("list projects: %w", err)
 	}
@@ -44,7 +47,7 @@ func (db *DB) ListProjects() ([]Project, error) {
 	var projects []Project
 	for rows.Next() {
 		var p Project
-		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Scope, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Scope, &p.Pinned, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, fmt.Errorf("scan project: %w", err)
 		}
 		projects = append(projects, p)
@@ -63,11 +66,31 @@ func (db *DB) UpdateProject(p *Project) error {
 	return nil
 }
 
+// ToggleProjectPinned flips a project's pinned flag and returns the new value.
+// It deliberately leaves updated_at alone so pinning doesn't reorder the rest.
+func (db *DB) ToggleProjectPinned(id int64) (bool, error) {
+	var pinned bool
+	err := db.QueryRow(
+		`UPDATE projects SET pinned = NOT pinned WHERE id = ? RETURNING pinned`, id,
+	).Scan(&pinned)
+	if err == sql.ErrNoRows {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("toggle project pin: %w", err)
+	}
+	return pinned, nil
+}
+
 func (db *DB) DeleteProject(id int64) error {
-	_, err := db.Exec(`DELETE FROM projects WHERE id = ?`, id)
+	files, err := db.rawOutputFiles(`project_id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("delete project: %w", err)
 	}
+	if _, err := db.Exec(`DELETE FROM projects WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete project: %w", err)
+	}
+	removeRawOutputFiles(files)
 	return nil
 }
 
@@ -79,8 +102,8 @@ func (db *DB) CreateScan(s *Scan) error {
 		projectID = nil
 	}
 	res, err := db.Exec(
-		`INSERT INTO scans (project_id, scan_type, tool, target, parameters, status) VALUES (?, ?, ?, ?, ?, ?)`,
-		projectID, s.ScanType, s.Tool, s.Target, s.Parameters, s.Status,
+		`INSERT INTO scans (project_id, scan_type, tool, target, label, tags, parameters, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		projectID, s.ScanType, s.Tool, s.Target, s.Label, strings.Join(s.Tags, ","), s.Parameters, s.Status,
 	)
 	if err != nil {
 		return fmt.Errorf("insert scan: %w", err)
@@ -92,10 +115,11 @@ func (db *DB) CreateScan(s *Scan) error {
 func (db *DB) GetScan(id int64) (*Scan, error) {
 	s := &Scan{}
 	var projectID sql.NullInt64
+	var tags string
 	err := db.QueryRow(
-		`SELECT id, project_id, scan_type, tool, target, parameters, status, raw_output, started_at, completed_at, created_at
+		`SELECT id, project_id, scan_type, tool, target, label, tags, parameters, status, raw_output, raw_output_file, started_at, completed_at, created_at
 		 FROM scans WHERE id = ?`, id,
-	).Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Parameters, &s.Status, &s.RawOutput, &s.StartedAt, &s.CompletedAt, &s.CreatedAt)
+	).Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Label, &tags, &s.Parameters, &s.Status, &s.RawOutput, &s.RawOutputFile, &s.StartedAt, &s.CompletedAt, &s.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -105,12 +129,14 @@ func (db *DB) GetScan(id int64) (*Scan, error) {
 	if projectID.Valid {
 		s.ProjectID = projectID.Int64
 	}
+	s.Tags = splitScanTags(tags)
+	s.RawOutputInFile = s.RawOutputFile != ""
 	return s, nil
 }
 
 func (db *DB) ListScansByProject(projectID int64) ([]Scan, error) {
 	rows, err := db.Query(
-		`SELECT id, project_id, scan_type, tool, target, parameters, status, raw_output, started_at, completed_at, created_at
+		`SELECT id, project_id, scan_type, tool, target, label, tags, parameters, status, raw_output, raw_output_file, started_at, completed_at, created_at
 		 FROM scans WHERE project_id = ? ORDER BY created_at DESC`, projectID,
 	)
 	if err != nil {
@@ -122,24 +148,27 @@ func (db *DB) ListScansByProject(projectID int64) ([]Scan, error) {
 	for rows.Next() {
 		var s Scan
 		var projectID sql.NullInt64
-		if err := rows.Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Parameters, &s.Status, &s.RawOutput, &s.StartedAt, &s.CompletedAt, &s.CreatedAt); err != nil {
+		var tags string
+		if err := rows.Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Label, &tags, &s.Parameters, &s.Status, &s.RawOutput, &s.RawOutputFile, &s.StartedAt, &s.CompletedAt, &s.CreatedAt); err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
 		if projectID.Valid {
 			s.ProjectID = projectID.Int64
 		}
+		s.Tags = splitScanTags(tags)
+		s.RawOutputInFile = s.RawOutputFile != ""
 		scans = append(scans, s)
 	}
 	return scans, rows.Err()
 }
 
 func (db *DB) UpdateScanStatus(id int64, status string) error {
-	now := time.Now()
+	now := time.Now().UTC() // like CURRENT_TIMESTAMP, so all stored times are UTC
 	switch status {
 	case "running":
 		_, err := db.Exec(`UPDATE scans SET status = ?, started_at = ? WHERE id = ?`, status, now, id)
 		return err
-	case "completed", "failed":
+	case "completed", "failed", "timed_out", "cancelled":
 		_, err := db.Exec(`UPDATE scans SET status = ?, completed_at = ? WHERE id = ?`, status, now, id)
 		return err
 	default:
@@ -148,17 +177,72 @@ func (db *DB) UpdateScanStatus(id int64, status string) error {
 	}
 }
 
+// PruneScanHistory deletes all but the newest keep finished scans of tool
+// against target in a project, along with their results and raw output
+// files. Scans still pending or running are never touched. It returns how
+// many scans were removed.
+func (db *DB) PruneScanHistory(projectID int64, tool, target string, keep int) (int64, error) {
+	var pid interface{} = projectID
+	if projectID == 0 {
+		pid = nil
+	}
+	const stale = `id IN (
+			SELECT id FROM scans
+			WHERE project_id IS ? AND tool = ? AND target = ?
+			  AND status IN ('completed', 'failed', 'timed_out', 'cancelled')
+			ORDER BY created_at DESC, id DESC
+			LIMIT -1 OFFSET ?)`
+	files, err := db.rawOutputFiles(stale, pid, tool, target, keep)
+	if err != nil {
+		return 0, fmt.Errorf("prune scan history: %w", err)
+	}
+	res, err := db.Exec(`DELETE FROM scans WHERE `+stale, pid, tool, target, keep)
+	if err != nil {
+		return 0, fmt.Errorf("prune scan history: %w", err)
+	}
+	removeRawOutputFiles(files)
+	return res.RowsAffected()
+}
+
+// SetScanTags replaces a scan's tags, returning ErrNotFound for a missing
+// scan. Tags are stored comma-separated and must not contain commas.
+func (db *DB) SetScanTags(id int64, tags []string) error {
+	res, err := db.Exec(`UPDATE scans SET tags = ? WHERE id = ?`, strings.Join(tags, ","), id)
+	if err != nil {
+		return fmt.Errorf("set scan tags: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// splitScanTags reads the tags column, giving an empty list rather than nil
+// so scans always carry a tags array in JSON.
+func splitScanTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Split(tags, ",")
+}
+
 func (db *DB) UpdateScanRawOutput(id int64, output string) error {
-	_, err := db.Exec(`UPDATE scans SET raw_output = ? WHERE id = ?`, output, id)
+	_, err := db.Exec(`UPDATE scans SET raw_output = ?, raw_output_file = '' WHERE id = ?`, output, id)
 	return err
 }
 
 // --- Results ---
 
+// insertResultSQL copies the scan's tool and type onto the result so readers
+// don't need to join scans for them.
+const insertResultSQL = `INSERT INTO results (scan_id, result_type, key, value, details, tool, scan_type)
+	VALUES (?, ?, ?, ?, ?,
+		COALESCE((SELECT tool FROM scans WHERE id = ?), ''),
+		COALESCE((SELECT scan_type FROM scans WHERE id = ?), ''))`
+
 func (db *DB) CreateResult(r *Result) error {
 	res, err := db.Exec(
-		`INSERT INTO results (scan_id, result_type, key, value, details) VALUES (?, ?, ?, ?, ?)`,
-		r.ScanID, r.ResultType, r.Key, r.Value, r.Details,
+		insertResultSQL, r.ScanID, r.ResultType, r.Key, r.Value, r.Details, r.ScanID, r.ScanID,
 	)
 	if err != nil {
 		return fmt.Errorf("insert result: %w", err)
@@ -174,24 +258,82 @@ func (db *DB) CreateResults(results []Result) error {
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(`INSERT INTO results (scan_id, result_type, key, value, details) VALUES (?, ?, ?, ?, ?)`)
+	stmt, err := tx.Prepare(insertResultSQL)
 	if err != nil {
 		return fmt.Errorf("prepare: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, r := range results {
-		if _, err := stmt.Exec(r.ScanID, r.ResultType, r.Key, r.Value, r.Details); err != nil {
+	for i, r := range results {
+		res, err := stmt.Exec(r.ScanID, r.ResultType, r.Key, r.Value, r.Details, r.ScanID, r.ScanID)
+		if err != nil {
 			return fmt.Errorf("exec: %w", err)
 		}
+		results[i].ID, _ = res.LastInsertId()
+	}
+	return tx.Commit()
+}
+
+// UpdateResultDetails rewrites the Details of already stored results.
+func (db *DB) UpdateResultDetails(results []Result) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`UPDATE results SET details = ? WHERE id = ?`)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range results {
+		if _, err := stmt.Exec(r.Details, r.ID); err != nil {
+			return fmt.Errorf("update result details: %w", err)
+		}
 	}
 	return tx.Commit()
 }
 
+// volatileResultTypes hold values that differ on every run (timings, the
+// empty-scan summary), so for them a later scan re-observes a result when
+// it reports the same type and key, whatever the value.
+const volatileResultTypes = `'timing', 'summary'`
+
+// resultsWithStale selects results r, joined to their scan s, with a
+// computed stale column, for the results whose scan matches scope. latest
+// holds the newest completed scan per project, tool and target, found once;
+// a result is stale when that scan is later than its own and has no result
+// of the same type, key and value. Results of the newest scan are never
+// stale. idx_scans_subject and idx_results_scan_key keep both lookups
+// indexed.
+func resultsWithStale(scope string) string {
+	return `WITH latest AS (
+		SELECT id, project_id, tool, target, created_at FROM (
+			SELECT id, project_id, tool, target, created_at,
+			       ROW_NUMBER() OVER (PARTITION BY project_id, tool, target ORDER BY created_at DESC, id DESC) AS n
+			FROM scans s WHERE status = 'completed' AND ` + scope + `)
+		WHERE n = 1)
+	SELECT r.id, r.scan_id, r.result_type, r.key, r.value, r.details, r.interesting, r.tool, r.scan_type, r.created_at,
+	       l.id IS NOT NULL AND (l.created_at > s.created_at OR (l.created_at = s.created_at AND l.id > s.id))
+	       AND NOT EXISTS (
+	           SELECT 1 FROM results lr
+	           WHERE lr.scan_id = l.id AND lr.result_type = r.result_type AND lr.key = r.key
+	             AND (lr.value = r.value OR r.result_type IN (` + volatileResultTypes + `)))
+	FROM results r
+	JOIN scans s ON r.scan_id = s.id
+	LEFT JOIN latest l ON l.project_id IS s.project_id AND l.tool = s.tool AND l.target = s.target
+	WHERE ` + scope
+}
+
 func (db *DB) GetResultsByScan(scanID int64) ([]Result, error) {
+	// scope: the scan and the other scans of its tool and target
+	const scope = `s.project_id IS (SELECT project_id FROM scans WHERE id = ?)
+		AND s.tool = (SELECT tool FROM scans WHERE id = ?) AND s.target = (SELECT target FROM scans WHERE id = ?)`
 	rows, err := db.Query(
-		`SELECT id, scan_id, result_type, key, value, details, created_at
-		 FROM results WHERE scan_id = ? ORDER BY id`, scanID,
+		resultsWithStale(scope)+` AND r.scan_id = ? ORDER BY r.id`,
+		scanID, scanID, scanID, scanID, scanID, scanID, scanID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("list results by scan: %w", err)
@@ -201,7 +343,7 @@ func (db *DB) GetResultsByScan(scanID int64) ([]Result, error) {
 	var results []Result
 	for rows.Next() {
 		var r Result
-		if err := rows.Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Key, &r.Value, &r.Details, &r.CreatedAt); err != nil {
+		if err := rows.Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Key, &r.Value, &r.Details, &r.Interesting, &r.Tool, &r.ScanType, &r.CreatedAt, &r.Stale); err != nil {
 			return nil, fmt.Errorf("scan result: %w", err)
 		}
 		results = append(results, r)
@@ -210,11 +352,7 @@ func (db *DB) GetResultsByScan(scanID int64) ([]Result, error) {
 }
 
 func (db *DB) GetResultsByProject(projectID int64) ([]Result, error) {
-	rows, err := db.Query(
-		`SELECT r.id, r.scan_id, r.result_type, r.key, r.value, r.details, r.created_at
-		 FROM results r JOIN scans s ON r.scan_id = s.id
-		 WHERE s.project_id = ? ORDER BY r.id`, projectID,
-	)
+	rows, err := db.Query(resultsWithStale(`s.project_id = ?`)+` ORDER BY r.id`, projectID, projectID)
 	if err != nil {
 		return nil, fmt.Errorf("list results by project: %w", err)
 	}
@@ -223,14 +361,156 @@ func (db *DB) GetResultsByProject(projectID int64) ([]Result, error) {
 	var results []Result
 	for rows.Next() {
 		var r Result
-		if err := rows.Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Key, &r.Value, &r.Details, &r.CreatedAt); err != nil {
+		if err := rows.Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Key, &r.Value, &r.Details, &r.Interesting, &r.Tool, &r.ScanType, &r.CreatedAt, &r.Stale); err != nil {
+			return nil, fmt.Errorf("scan result: %w", err)
+		}
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+// GetResult returns one result with its scan's target and project, or nil if
+// there is no such result.
+func (db *DB) GetResult(id int64) (*ProjectResult, error) {
+	r := &ProjectResult{}
+	var projectID sql.NullInt64
+	err := db.QueryRow(
+		`SELECT r.id, r.scan_id, r.result_type, r.key, r.value, r.details, r.interesting, r.tool, r.scan_type, r.created_at,
+		 s.target, s.project_id, COALESCE(p.name, '')
+		 FROM results r
+		 JOIN scans s ON r.scan_id = s.id
+		 LEFT JOIN projects p ON s.project_id = p.id
+		 WHERE r.id = ?`, id,
+	).Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Key, &r.Value, &r.Details, &r.Interesting, &r.Tool, &r.ScanType, &r.CreatedAt,
+		&r.Target, &projectID, &r.ProjectName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get result: %w", err)
+	}
+	r.ProjectID = projectID.Int64
+	return r, nil
+}
+
+// SearchResults finds results across every project, newest first.
+func (db *DB) SearchResults(f ResultFilter) ([]ProjectResult, error) {
+	query := `SELECT r.id, r.scan_id, r.result_type, r.key, r.value, r.details, r.interesting, r.tool, r.scan_type, r.created_at,
+		 s.target, s.project_id, COALESCE(p.name, '')
+		 FROM results r
+		 JOIN scans s ON r.scan_id = s.id
+		 LEFT JOIN projects p ON s.project_id = p.id
+		 WHERE 1=1`
+	var args []any
+	if f.ResultType != "" {
+		query += ` AND r.result_type = ?`
+		args = append(args, f.ResultType)
+	}
+	if f.Key != "" {
+		query += ` AND r.key = ?`
+		args = append(args, f.Key)
+	}
+	if f.Value != "" {
+		query += ` AND r.value LIKE ? ESCAPE '\'`
+		args = append(args, "%"+escapeLike(f.Value)+"%")
+	}
+	query += ` ORDER BY r.id DESC LIMIT ? OFFSET ?`
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []ProjectResult
+	for rows.Next() {
+		var r ProjectResult
+		var projectID sql.NullInt64
+		if err := rows.Scan(&r.ID, &r.ScanID, &r.ResultType, &r.Key, &r.Value, &r.Details, &r.Interesting, &r.Tool, &r.ScanType, &r.CreatedAt,
+			&r.Target, &projectID, &r.ProjectName); err != nil {
 			return nil, fmt.Errorf("scan result: %w", err)
 		}
+		r.ProjectID = projectID.Int64
 		results = append(results, r)
 	}
 	return results, rows.Err()
 }
 
+// escapeLike escapes LIKE wildcards so user input matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// SearchProjects finds projects whose name, description or scope contains term.
+func (db *DB) SearchProjects(term string, limit int) ([]Project, error) {
+	like := "%" + escapeLike(term) + "%"
+	rows, err := db.Query(
+		`SELECT id, name, description, scope, pinned, created_at, updated_at FROM projects
+		 WHERE name LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\' OR scope LIKE ? ESCAPE '\'
+		 ORDER BY pinned DESC, updated_at DESC LIMIT ?`, like, like, like, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search projects: %w", err)
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var p Project
+		if err := rows.Scan(&p.ID, &p.Name, &p.Description, &p.Scope, &p.Pinned, &p.CreatedAt, &p.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("scan project: %w", err)
+		}
+		projects = append(projects, p)
+	}
+	return projects, rows.Err()
+}
+
+// SearchScans finds scans whose target or label contains term, newest first.
+// Raw output is not loaded.
+func (db *DB) SearchScans(term string, limit int) ([]Scan, error) {
+	like := "%" + escapeLike(term) + "%"
+	rows, err := db.Query(
+		`SELECT id, project_id, scan_type, tool, target, label, tags, parameters, status, '', '', started_at, completed_at, created_at
+		 FROM scans WHERE target LIKE ? ESCAPE '\' OR label LIKE ? ESCAPE '\'
+		 ORDER BY id DESC LIMIT ?`, like, like, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search scans: %w", err)
+	}
+	defer rows.Close()
+
+	var scans []Scan
+	for rows.Next() {
+		var s Scan
+		var projectID sql.NullInt64
+		var tags string
+		if err := rows.Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Label, &tags, &s.Parameters, &s.Status, &s.RawOutput, &s.RawOutputFile, &s.StartedAt, &s.CompletedAt, &s.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		s.ProjectID = projectID.Int64
+		s.Tags = splitScanTags(tags)
+		s.RawOutputInFile = s.RawOutputFile != ""
+		scans = append(scans, s)
+	}
+	return scans, rows.Err()
+}
+
+// ToggleResultInteresting flips a result's interesting flag and returns the new value.
+func (db *DB) ToggleResultInteresting(id int64) (bool, error) {
+	var interesting bool
+	err := db.QueryRow(
+		`UPDATE results SET interesting = NOT interesting WHERE id = ? RETURNING interesting`, id,
+	).Scan(&interesting)
+	if err == sql.ErrNoRows {
+		return false, ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("toggle result flag: %w", err)
+	}
+	return interesting, nil
+}
+
 // --- Reports ---
 
 func (db *DB) CreateReport(r *Report) error {
@@ -245,6 +525,20 @@ func (db *DB) CreateReport(r *Report) error {
 	return nil
 }
 
+// RecordDisclaimerAcceptance stores that a client accepted the terms shown
+// on the welcome page.
+func (db *DB) RecordDisclaimerAcceptance(a *DisclaimerAcceptance) error {
+	res, err := db.Exec(
+		`INSERT INTO disclaimer_acceptances (client_ip, user_agent, terms_hash) VALUES (?, ?, ?)`,
+		a.ClientIP, a.UserAgent, a.TermsHash,
+	)
+	if err != nil {
+		return fmt.Errorf("insert disclaimer acceptance: %w", err)
+	}
+	a.ID, _ = res.LastInsertId()
+	return nil
+}
+
 func (db *DB) GetReport(id int64) (*Report, error) {
 	r := &Report{}
 	err := db.QueryRow(
@@ -259,6 +553,14 @@ func (db *DB) GetReport(id int64) (*Report, error) {
 	return r, nil
 }
 
+func (db *DB) DeleteReport(id int64) error {
+	_, err := db.Exec(`DELETE FROM reports WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete report: %w", err)
+	}
+	return nil
+}
+
 func (db *DB) ListReportsByProject(projectID int64) ([]Report, error) {
 	rows, err := db.Query(
 		`SELECT id, project_id, title, format, file_path, created_at
@@ -307,19 +609,35 @@ type DashboardStats struct {
 	ProjectCount int `json:"project_count"`
 	ScanCount    int `json:"scan_count"`
 	ResultCount  int `json:"result_count"`
+
+	ScansByStatus map[string]int `json:"scans_by_status"`
 }
 
 func (db *DB) GetStats() (*DashboardStats, error) {
-	stats := &DashboardStats{}
+	stats := &DashboardStats{ScansByStatus: make(map[string]int)}
 	db.QueryRow(`SELECT COUNT(*) FROM projects`).Scan(&stats.ProjectCount)
 	db.QueryRow(`SELECT COUNT(*) FROM scans`).Scan(&stats.ScanCount)
 	db.QueryRow(`SELECT COUNT(*) FROM results`).Scan(&stats.ResultCount)
-	return stats, nil
+
+	rows, err := db.Query(`SELECT status, COUNT(*) FROM scans GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("count scans by status: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, fmt.Errorf("scan status count: %w", err)
+		}
+		stats.ScansByStatus[status] = n
+	}
+	return stats, rows.Err()
 }
 
 func (db *DB) ListRecentScans(limit int) ([]Scan, error) {
 	rows, err := db.Query(
-		`SELECT id, project_id, scan_type, tool, target, parameters, status, '', started_at, completed_at, created_at
+		`SELECT id, project_id, scan_type, tool, target, label, tags, parameters, status, '', '', started_at, completed_at, created_at
 		 FROM scans ORDER BY created_at DESC LIMIT ?`, limit,
 	)
 	if err != nil {
@@ -331,13 +649,105 @@ func (db *DB) ListRecentScans(limit int) ([]Scan, error) {
 	for rows.Next() {
 		var s Scan
 		var projectID sql.NullInt64
-		if err := rows.Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Parameters, &s.Status, &s.RawOutput, &s.StartedAt, &s.CompletedAt, &s.CreatedAt); err != nil {
+		var tags string
+		if err := rows.Scan(&s.ID, &projectID, &s.ScanType, &s.Tool, &s.Target, &s.Label, &tags, &s.Parameters, &s.Status, &s.RawOutput, &s.RawOutputFile, &s.StartedAt, &s.CompletedAt, &s.CreatedAt); err != nil {
 			return nil, fmt.Errorf("scan row: %w", err)
 		}
 		if projectID.Valid {
 			s.ProjectID = projectID.Int64
 		}
+		s.Tags = splitScanTags(tags)
+		s.RawOutputInFile = s.RawOutputFile != ""
 		scans = append(scans, s)
 	}
 	return scans, rows.Err()
 }
+
+// RecentActivity returns up to limit of the newest scan, project and report
+// events, newest first. Timestamps are stored in more than one text format
+// (CURRENT_TIMESTAMP defaults and Go times), so each source is read
+// separately, newest rows first, and the events are merged here. Link is left
+// for the caller.
+func (db *DB) RecentActivity(limit int) ([]Activity, error) {
+	var events []Activity
+
+	rows, err := db.Query(
+		`SELECT id, project_id, tool, target, label, status, started_at, completed_at
+		 FROM scans WHERE started_at IS NOT NULL ORDER BY id DESC LIMIT ?`, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list scan activity: %w", err)
+	}
+	for rows.Next() {
+		var s Scan
+		var projectID sql.NullInt64
+		if err := rows.Scan(&s.ID, &projectID, &s.Tool, &s.Target, &s.Label, &s.Status, &s.StartedAt, &s.CompletedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan row: %w", err)
+		}
+		name := s.Tool + " on " + s.Target
+		if s.Label != "" {
+			name = s.Label + " (" + name + ")"
+		}
+		events = append(events, Activity{
+			Type: "scan_started", ID: s.ID, ProjectID: projectID.Int64,
+			Timestamp: *s.StartedAt, Summary: "Started " + name,
+		})
+		if s.CompletedAt != nil {
+			events = append(events, Activity{
+				Type: "scan_" + s.Status, ID: s.ID, ProjectID: projectID.Int64,
+				Timestamp: *s.CompletedAt, Summary: name + " " + strings.ReplaceAll(s.Status, "_", " "),
+			})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT id, name, created_at FROM projects ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list project activity: %w", err)
+	}
+	for rows.Next() {
+		var a Activity
+		var name string
+		if err := rows.Scan(&a.ID, &name, &a.Timestamp); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan project: %w", err)
+		}
+		a.Type, a.ProjectID, a.Summary = "project_created", a.ID, "Created project "+name
+		events = append(events, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = db.Query(`SELECT id, project_id, title, format, created_at FROM reports ORDER BY id DESC LIMIT ?`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list report activity: %w", err)
+	}
+	for rows.Next() {
+		var a Activity
+		var projectID sql.NullInt64
+		var title, format string
+		if err := rows.Scan(&a.ID, &projectID, &title, &format, &a.Timestamp); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan report: %w", err)
+		}
+		a.Type, a.ProjectID = "report_generated", projectID.Int64
+		a.Summary = "Generated " + strings.ToUpper(format) + " report " + title
+		events = append(events, a)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Timestamp.After(events[j].Timestamp) })
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	return events, nil
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+)
+
+// ReadRawOutput returns the scan's raw output, reading it from its file when
+// it was stored outside the database.
+func (s *Scan) ReadRawOutput() (string, error) {
+	if s.RawOutputFile == "" {
+		return s.RawOutput, nil
+	}
+	data, err := os.ReadFile(s.RawOutputFile)
+	if err != nil {
+		return "", fmt.Errorf("read raw output: %w", err)
+	}
+	return string(data), nil
+}
+
+// UpdateScanRawOutputFile records that a scan's raw output was written to
+// path instead of being stored inline.
+func (db *DB) UpdateScanRawOutputFile(id int64, path string) error {
+	_, err := db.Exec(`UPDATE scans SET raw_output = '', raw_output_file = ? WHERE id = ?`, path, id)
+	return err
+}
+
+// rawOutputFiles lists the output files of the scans matched by where, so
+// they can be removed along with the rows.
+func (db *DB) rawOutputFiles(where string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(`SELECT raw_output_file FROM scans WHERE raw_output_file != '' AND `+where, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// removeRawOutputFiles deletes output files whose scans are gone. A failure
+// only leaves a stray file behind, so it is logged rather than returned.
+func removeRawOutputFiles(paths []string) {
+	for _, p := range paths {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			slog.Warn("remove raw output file failed", "path", p, "error", err)
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// ErrInvalidDiff is returned for a change report request that names scans or
+// times that can't be compared.
+var ErrInvalidDiff = errors.New("invalid diff")
+
+// DiffOptions selects what a change report compares: either two scans, or
+// the state of a project at two points in time. Output is "markdown" (the
+// default) or "pdf".
+type DiffOptions struct {
+	BaseScanID int64      `json:"base_scan_id,omitempty"`
+	HeadScanID int64      `json:"head_scan_id,omitempty"`
+	From       *time.Time `json:"from,omitempty"`
+	To         *time.Time `json:"to,omitempty"`
+	Output     string     `json:"output,omitempty"`
+}
+
+// Validate checks that exactly one way of choosing the two sides is used.
+func (o DiffOptions) Validate() error {
+	byScan := o.BaseScanID != 0 || o.HeadScanID != 0
+	byTime := o.From != nil || o.To != nil
+	switch {
+	case byScan && byTime:
+		return fmt.Errorf("give either base_scan_id and head_scan_id, or from and to, not both")
+	case byScan:
+		if o.BaseScanID == 0 || o.HeadScanID == 0 {
+			return fmt.Errorf("base_scan_id and head_scan_id are both required")
+		}
+		if o.BaseScanID == o.HeadScanID {
+			return fmt.Errorf("base_scan_id and head_scan_id must differ")
+		}
+	case byTime:
+		if o.From == nil || o.To == nil {
+			return fmt.Errorf("from and to are both required")
+		}
+		if !o.From.Before(*o.To) {
+			return fmt.Errorf("from must be before to")
+		}
+	default:
+		return fmt.Errorf("base_scan_id and head_scan_id, or from and to, are required")
+	}
+	switch o.Output {
+	case "", "markdown", "pdf":
+	default:
+		return fmt.Errorf("output must be 'markdown' or 'pdf'")
+	}
+	return nil
+}
+
+// DiffModel is what changed in a project between two scans or two points in
+// time, grouped by the tool and target that observed it.
+type DiffModel struct {
+	Project     database.Project `json:"project"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Base        string           `json:"base"`
+	Head        string           `json:"head"`
+	Added       int              `json:"added"`
+	Removed     int              `json:"removed"`
+	Changed     int              `json:"changed"`
+	TypeCounts  []DiffCount      `json:"type_counts"`
+	Scans       []ScanDiff       `json:"scans"`
+}
+
+// DiffCount tallies the changes to one result type.
+type DiffCount struct {
+	ResultType string `json:"result_type"`
+	Added      int    `json:"added"`
+	Removed    int    `json:"removed"`
+	Changed    int    `json:"changed"`
+}
+
+// ScanDiff compares one tool's view of one target. Base or Head is nil when
+// the target was only scanned on the other side.
+type ScanDiff struct {
+	Heading string         `json:"heading"`
+	Base    *database.Scan `json:"base,omitempty"`
+	Head    *database.Scan `json:"head,omitempty"`
+	Changes []ResultChange `json:"changes"`
+}
+
+// ResultChange is one finding that appeared, disappeared or changed value.
+type ResultChange struct {
+	Change     string `json:"change"` // added, removed or changed
+	ResultType string `json:"result_type"`
+	Key        string `json:"key"`
+	Before     string `json:"before,omitempty"`
+	After      string `json:"after,omitempty"`
+}
+
+// diffResults compares two sets of results by type and key. A key holding a
+// single value on both sides that differs is reported as changed (a port going
+// from open to filtered, a new certificate expiry); otherwise values are
+// compared as sets, so extra DNS records or SANs show up as added or removed.
+func diffResults(base, head []database.Result) []ResultChange {
+	type slot struct{ resultType, key string }
+	values := func(results []database.Result) map[slot][]string {
+		m := make(map[slot][]string)
+		for _, r := range results {
+			k := slot{r.ResultType, r.Key}
+			m[k] = append(m[k], r.Value)
+		}
+		return m
+	}
+	before, after := values(base), values(head)
+
+	slots := make(map[slot]bool)
+	for k := range before {
+		slots[k] = true
+	}
+	for k := range after {
+		slots[k] = true
+	}
+
+	var changes []ResultChange
+	for k := range slots {
+		b, a := before[k], after[k]
+		if len(b) == 1 && len(a) == 1 {
+			if b[0] != a[0] {
+				changes = append(changes, ResultChange{Change: "changed", ResultType: k.resultType, Key: k.key, Before: b[0], After: a[0]})
+			}
+			continue
+		}
+		for _, v := range missingFrom(a, b) {
+			changes = append(changes, ResultChange{Change: "added", ResultType: k.resultType, Key: k.key, After: v})
+		}
+		for _, v := range missingFrom(b, a) {
+			changes = append(changes, ResultChange{Change: "removed", ResultType: k.resultType, Key: k.key, Before: v})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		x, y := changes[i], changes[j]
+		if x.ResultType != y.ResultType {
+			return x.ResultType < y.ResultType
+		}
+		if x.Key != y.Key {
+			return x.Key < y.Key
+		}
+		if x.Change != y.Change {
+			return x.Change < y.Change
+		}
+		return x.Before+x.After < y.Before+y.After
+	})
+	return changes
+}
+
+// missingFrom returns the values of xs not present in ys, counting repeats.
+func missingFrom(xs, ys []string) []string {
+	have := make(map[string]int)
+	for _, y := range ys {
+		have[y]++
+	}
+	var out []string
+	for _, x := range xs {
+		if have[x] > 0 {
+			have[x]--
+			continue
+		}
+		out = append(out, x)
+	}
+	return out
+}
+
+// buildDiffModel loads both sides of a change report and compares them.
+func (g *Generator) buildDiffModel(projectID int64, opts DiffOptions) (*DiffModel, error) {
+	project, err := g.db.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+	m := &DiffModel{Project: *project, GeneratedAt: time.Now().In(g.loc), Scans: []ScanDiff{}}
+
+	var pairs [][2]*database.Scan
+	if opts.BaseScanID != 0 {
+		base, err := g.diffScan(projectID, opts.BaseScanID)
+		if err != nil {
+			return nil, err
+		}
+		head, err := g.diffScan(projectID, opts.HeadScanID)
+		if err != nil {
+			return nil, err
+		}
+		m.Base = fmt.Sprintf("scan #%d (%s)", base.ID, scanTime(base).Format("2006-01-02 15:04"))
+		m.Head = fmt.Sprintf("scan #%d (%s)", head.ID, scanTime(head).Format("2006-01-02 15:04"))
+		pairs = append(pairs, [2]*database.Scan{base, head})
+	} else {
+		scans, err := g.db.ListScansByProject(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("listing scans: %w", err)
+		}
+		for i := range scans {
+			localizeScan(&scans[i], g.loc)
+		}
+		m.Base = opts.From.In(g.loc).Format("2006-01-02 15:04 MST")
+		m.Head = opts.To.In(g.loc).Format("2006-01-02 15:04 MST")
+		pairs = pairScansAt(scans, *opts.From, *opts.To)
+	}
+
+	typeCounts := make(map[string]*DiffCount)
+	for _, pair := range pairs {
+		base, head := pair[0], pair[1]
+		var baseResults, headResults []database.Result
+		if base != nil {
+			if baseResults, err = g.db.GetResultsByScan(base.ID); err != nil {
+				return nil, fmt.Errorf("listing results: %w", err)
+			}
+		}
+		if head != nil {
+			if headResults, err = g.db.GetResultsByScan(head.ID); err != nil {
+				return nil, fmt.Errorf("listing results: %w", err)
+			}
+		}
+
+		changes := diffResults(baseResults, headResults)
+		if len(changes) == 0 && opts.BaseScanID == 0 {
+			continue // nothing changed for this tool and target
+		}
+		sd := ScanDiff{Base: base, Head: head, Changes: changes}
+		if head != nil {
+			sd.Heading = scanHeading(*head)
+		} else {
+			sd.Heading = scanHeading(*base)
+		}
+		if sd.Changes == nil {
+			sd.Changes = []ResultChange{}
+		}
+		m.Scans = append(m.Scans, sd)
+
+		for _, c := range changes {
+			tc := typeCounts[c.ResultType]
+			if tc == nil {
+				tc = &DiffCount{ResultType: c.ResultType}
+				typeCounts[c.ResultType] = tc
+			}
+			switch c.Change {
+			case "added":
+				m.Added++
+				tc.Added++
+			case "removed":
+				m.Removed++
+				tc.Removed++
+			case "changed":
+				m.Changed++
+				tc.Changed++
+			}
+		}
+	}
+
+	m.TypeCounts = []DiffCount{}
+	for _, tc := range typeCounts {
+		m.TypeCounts = append(m.TypeCounts, *tc)
+	}
+	sort.Slice(m.TypeCounts, func(i, j int) bool { return m.TypeCounts[i].ResultType < m.TypeCounts[j].ResultType })
+	return m, nil
+}
+
+// diffScan loads a finished scan of the project for a change report.
+func (g *Generator) diffScan(projectID, scanID int64) (*database.Scan, error) {
+	scan, err := g.db.GetScan(scanID)
+	if err != nil {
+		return nil, fmt.Errorf("loading scan: %w", err)
+	}
+	if scan == nil || scan.ProjectID != projectID {
+		return nil, fmt.Errorf("%w: scan %d is not in project %d", ErrInvalidDiff, scanID, projectID)
+	}
+	if !scan.Finished() {
+		return nil, fmt.Errorf("%w: scan %d is still %s", ErrInvalidDiff, scanID, scan.Status)
+	}
+	localizeScan(scan, g.loc)
+	return scan, nil
+}
+
+// pairScansAt finds, for every tool and target, the latest completed scan as
+// of from and as of to. Either side is nil when the target had not been
+// scanned with that tool by then.
+func pairScansAt(scans []database.Scan, from, to time.Time) [][2]*database.Scan {
+	type subject struct{ tool, target string }
+	latest := func(at time.Time) map[subject]*database.Scan {
+		m := make(map[subject]*database.Scan)
+		for i := range scans {
+			s := &scans[i]
+			if s.Status != "completed" || scanTime(s).After(at) {
+				continue
+			}
+			k := subject{s.Tool, s.Target}
+			if prev := m[k]; prev == nil || scanTime(s).After(scanTime(prev)) {
+				m[k] = s
+			}
+		}
+		return m
+	}
+	before, after := latest(from), latest(to)
+
+	var keys []subject
+	seen := make(map[subject]bool)
+	for _, m := range []map[subject]*database.Scan{before, after} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].tool != keys[j].tool {
+			return keys[i].tool < keys[j].tool
+		}
+		return keys[i].target < keys[j].target
+	})
+
+	var pairs [][2]*database.Scan
+	for _, k := range keys {
+		pairs = append(pairs, [2]*database.Scan{before[k], after[k]})
+	}
+	return pairs
+}
+
+// scanTime is when a scan's results were observed: its completion time, or
+// its creation time when it never recorded one.
+func scanTime(s *database.Scan) time.Time {
+	if s.CompletedAt != nil {
+		return *s.CompletedAt
+	}
+	return s.CreatedAt
+}
+
+func renderDiffMarkdown(m *DiffModel) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("# Change Report: %s\n\n", m.Project.Name))
+	b.WriteString(fmt.Sprintf("**Generated:** %s  \n", m.GeneratedAt.Format("January 2, 2006 15:04:05 MST")))
+	b.WriteString(fmt.Sprintf("**Compared:** %s → %s  \n\n", m.Base, m.Head))
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString(fmt.Sprintf("%d added, %d removed and %d changed finding(s) across %d tool/target pair(s).\n\n",
+		m.Added, m.Removed, m.Changed, len(m.Scans)))
+	if len(m.TypeCounts) > 0 {
+		b.WriteString("| Finding Type | Added | Removed | Changed |\n")
+		b.WriteString("|---|---|---|---|\n")
+		for _, c := range m.TypeCounts {
+			b.WriteString(fmt.Sprintf("| %s | %d | %d | %d |\n", c.ResultType, c.Added, c.Removed, c.Changed))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Changes\n\n")
+	if len(m.Scans) == 0 {
+		b.WriteString("No changes.\n\n")
+	}
+	for _, sd := range m.Scans {
+		b.WriteString(fmt.Sprintf("### %s\n\n", sd.Heading))
+		b.WriteString(fmt.Sprintf("**Before:** %s  \n", diffSide(sd.Base)))
+		b.WriteString(fmt.Sprintf("**After:** %s  \n\n", diffSide(sd.Head)))
+
+		if len(sd.Changes) == 0 {
+			b.WriteString("No changes.\n\n")
+			continue
+		}
+		b.WriteString("| Change | Type | Key | Before | After |\n")
+		b.WriteString("|---|---|---|---|---|\n")
+		for _, c := range sd.Changes {
+			b.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s |\n",
+				c.Change, c.ResultType, c.Key, truncate(c.Before, 100), truncate(c.After, 100)))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// diffSide describes one side of a scan pair.
+func diffSide(s *database.Scan) string {
+	if s == nil {
+		return "not scanned"
+	}
+	return fmt.Sprintf("scan #%d, %s %s", s.ID, s.Status, scanTime(s).Format(time.RFC3339))
+}
+
+func renderDiffPDF(m *DiffModel) ([]byte, error) {
+	pdf, p, err := newPDF()
+	if err != nil {
+		return nil, err
+	}
+
+	pdf.AddPage()
+	p.y = 200
+	p.setFont(24)
+	p.writeCenter("Change Report")
+	p.y += 40
+	p.setFont(18)
+	p.writeCenter(m.Project.Name)
+	p.y += 30
+	p.setFont(12)
+	p.writeCenter(m.Base + " → " + m.Head)
+	p.y += 15
+	p.writeCenter("Generated by ReconSuite")
+
+	pdf.AddPage()
+	p.y = 40
+	p.heading("Summary")
+	p.text(fmt.Sprintf("%d added, %d removed and %d changed finding(s) across %d tool/target pair(s).",
+		m.Added, m.Removed, m.Changed, len(m.Scans)))
+	p.y += 10
+	if len(m.TypeCounts) > 0 {
+		p.tableRow3("Finding Type", "Added / Removed", "Changed", true)
+		for _, c := range m.TypeCounts {
+			p.tableRow3(c.ResultType, fmt.Sprintf("%d / %d", c.Added, c.Removed), fmt.Sprintf("%d", c.Changed), false)
+		}
+		p.y += 10
+	}
+
+	p.heading("Changes")
+	if len(m.Scans) == 0 {
+		p.text("No changes.")
+	}
+	for _, sd := range m.Scans {
+		p.subheading(truncate(sd.Heading, 70))
+		p.text("Before: " + diffSide(sd.Base))
+		p.text("After: " + diffSide(sd.Head))
+		if len(sd.Changes) == 0 {
+			p.text("No changes.")
+			continue
+		}
+		p.tableRow3("Change", "Type: Key", "Before → After", true)
+		for _, c := range sd.Changes {
+			value := c.After
+			switch c.Change {
+			case "removed":
+				value = c.Before
+			case "changed":
+				value = c.Before + " → " + c.After
+			}
+			p.tableRow3(c.Change, c.ResultType+": "+c.Key, value, false)
+		}
+		p.y += 5
+	}
+
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("writing PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// SaveDiff renders a change report in opts.Output and records it like any
+// other report, titled with what was compared.
+func (g *Generator) SaveDiff(projectID int64, opts DiffOptions) (string, *database.Report, error) {
+	m, err := g.buildDiffModel(projectID, opts)
+	if err != nil {
+		return "", nil, err
+	}
+
+	name := projectSlug(m.Project)
+	title := fmt.Sprintf("Change Report — %s: %s → %s", name, m.Base, m.Head)
+	if opts.Output == "pdf" {
+		data, err := renderDiffPDF(m)
+		if err != nil {
+			return "", nil, err
+		}
+		return g.storeReport(m.Project.ID, name+"-diff", title, m.GeneratedAt, "pdf", "pdf", data, false)
+	}
+	return g.storeReport(m.Project.ID, name+"-diff", title, m.GeneratedAt, "markdown", "md", []byte(renderDiffMarkdown(m)), true)
+}
@@ -13,43 +13,112 @@ import (
 type Generator struct {
 	db         *database.DB
 	reportsDir string
+	loc        *time.Location // zone report timestamps are shown in
 }
 
-func NewGenerator(db *database.DB, reportsDir string) *Generator {
-	return &Generator{db: db, reportsDir: reportsDir}
+func NewGenerator(db *database.DB, reportsDir string, loc *time.Location) *Generator {
+	return &Generator{db: db, reportsDir: reportsDir, loc: loc}
 }
 
-func (g *Generator) GenerateMarkdown(projectID int64) (string, error) {
-	project, err := g.db.GetProject(projectID)
-	if err != nil || project == nil {
-		return "", fmt.Errorf("project not found")
+// Options tunes what a generated report includes.
+type Options struct {
+	InterestingOnly bool `json:"interesting_only"` // only include results flagged as interesting
+	Evidence        bool `json:"evidence"`         // add an appendix with the HTTP exchanges behind findings
+	RedactTargets   bool `json:"redact_targets"`   // replace target hostnames and IPs with TARGET-n pseudonyms
+}
+
+func (o Options) filter(results []database.Result) []database.Result {
+	if !o.InterestingOnly {
+		return results
+	}
+	var filtered []database.Result
+	for _, r := range results {
+		if r.Interesting {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// scanHeading names a scan in report headers, preferring its label.
+func scanHeading(scan database.Scan) string {
+	if scan.Label != "" {
+		return fmt.Sprintf("%s (%s — %s)", scan.Label, scan.Tool, scan.Target)
+	}
+	return fmt.Sprintf("%s — %s", scan.Tool, scan.Target)
+}
+
+// scanOutcomes summarizes scan statuses ("3 completed, 1 timed_out") when any
+// scan did not complete, and returns "" otherwise.
+func scanOutcomes(m *ReportModel) string {
+	if len(m.StatusCounts) == 0 || len(m.StatusCounts) == 1 && m.StatusCounts[0].Name == "completed" {
+		return ""
+	}
+	parts := make([]string, len(m.StatusCounts))
+	for i, c := range m.StatusCounts {
+		parts[i] = fmt.Sprintf("%d %s", c.Count, c.Name)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// DeleteReport removes a report record and its file on disk. Files outside the
+// configured reports directory are never touched.
+func (g *Generator) DeleteReport(rpt *database.Report) error {
+	if rpt.FilePath != "" {
+		inside, err := g.withinReportsDir(rpt.FilePath)
+		if err != nil {
+			return err
+		}
+		if inside {
+			if err := os.Remove(rpt.FilePath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("removing report file: %w", err)
+			}
+		}
 	}
+	return g.db.DeleteReport(rpt.ID)
+}
 
-	scans, err := g.db.ListScansByProject(projectID)
+func (g *Generator) withinReportsDir(path string) (bool, error) {
+	dir, err := filepath.Abs(g.reportsDir)
+	if err != nil {
+		return false, fmt.Errorf("resolving reports directory: %w", err)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false, fmt.Errorf("resolving report path: %w", err)
+	}
+	rel, err := filepath.Rel(dir, abs)
 	if err != nil {
-		return "", fmt.Errorf("listing scans: %w", err)
+		return false, nil
 	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
 
-	results, err := g.db.GetResultsByProject(projectID)
+func (g *Generator) GenerateMarkdown(projectID int64, opts Options) (string, error) {
+	m, err := g.buildReportModel(projectID, opts)
 	if err != nil {
-		return "", fmt.Errorf("listing results: %w", err)
+		return "", err
 	}
+	return renderMarkdown(m), nil
+}
 
+func renderMarkdown(m *ReportModel) string {
 	var b strings.Builder
 
 	// Title
-	b.WriteString(fmt.Sprintf("# Reconnaissance Report: %s\n\n", project.Name))
-	b.WriteString(fmt.Sprintf("**Generated:** %s  \n", time.Now().Format("January 2, 2006 15:04:05 MST")))
-	b.WriteString(fmt.Sprintf("**Tool:** ReconSuite  \n\n"))
+	b.WriteString(fmt.Sprintf("# Reconnaissance Report: %s\n\n", m.Project.Name))
+	b.WriteString(fmt.Sprintf("**Generated:** %s  \n", m.GeneratedAt.Format("January 2, 2006 15:04:05 MST")))
+	b.WriteString(fmt.Sprintf("**Tool:** ReconSuite  \n"))
+	if m.Options.RedactTargets {
+		b.WriteString("**Targets:** redacted (shown as TARGET-n)  \n")
+	}
+	b.WriteString("\n")
 
 	// Scope
 	b.WriteString("## Scope\n\n")
-	if project.Scope != "" {
-		for _, target := range strings.Split(project.Scope, "\n") {
-			target = strings.TrimSpace(target)
-			if target != "" {
-				b.WriteString(fmt.Sprintf("- `%s`\n", target))
-			}
+	if len(m.Scope) > 0 {
+		for _, target := range m.Scope {
+			b.WriteString(fmt.Sprintf("- `%s`\n", target))
 		}
 	} else {
 		b.WriteString("No scope defined.\n")
@@ -58,19 +127,28 @@ func (g *Generator) GenerateMarkdown(projectID int64) (string, error) {
 
 	// Executive Summary
 	b.WriteString("## Executive Summary\n\n")
-	b.WriteString(fmt.Sprintf("This report covers %d scan(s) performed against the defined scope. ", len(scans)))
-	b.WriteString(fmt.Sprintf("A total of %d finding(s) were recorded.\n\n", len(results)))
+	b.WriteString(fmt.Sprintf("This report covers %d scan(s) performed against the defined scope. ", m.ScanCount))
+	b.WriteString(fmt.Sprintf("A total of %d finding(s) were recorded.\n\n", m.FindingCount))
+	if m.StaleCount > 0 {
+		b.WriteString(fmt.Sprintf("%d finding(s), marked _(stale)_ below, were not reported again by the latest scan of the same tool and target.\n\n", m.StaleCount))
+	}
+	if outcomes := scanOutcomes(m); outcomes != "" {
+		b.WriteString(fmt.Sprintf("Scan outcomes: %s.\n\n", outcomes))
+	}
 
-	// Count by type
-	typeCounts := make(map[string]int)
-	for _, r := range results {
-		typeCounts[r.ResultType]++
+	if len(m.SeverityCounts) > 0 {
+		b.WriteString("| Severity | Count |\n")
+		b.WriteString("|---|---|\n")
+		for _, c := range m.SeverityCounts {
+			b.WriteString(fmt.Sprintf("| %s | %d |\n", c.Name, c.Count))
+		}
+		b.WriteString("\n")
 	}
-	if len(typeCounts) > 0 {
+	if len(m.TypeCounts) > 0 {
 		b.WriteString("| Finding Type | Count |\n")
 		b.WriteString("|---|---|\n")
-		for t, c := range typeCounts {
-			b.WriteString(fmt.Sprintf("| %s | %d |\n", t, c))
+		for _, c := range m.TypeCounts {
+			b.WriteString(fmt.Sprintf("| %s | %d |\n", c.Name, c.Count))
 		}
 		b.WriteString("\n")
 	}
@@ -78,60 +156,34 @@ func (g *Generator) GenerateMarkdown(projectID int64) (string, error) {
 	// Methodology
 	b.WriteString("## Methodology\n\n")
 	b.WriteString("The following tools were used during reconnaissance:\n\n")
-	toolSet := make(map[string]bool)
-	for _, s := range scans {
-		toolSet[s.Tool] = true
-	}
-	for tool := range toolSet {
+	for _, tool := range m.Tools {
 		b.WriteString(fmt.Sprintf("- %s\n", tool))
 	}
 	b.WriteString("\n")
 
 	// Findings grouped by scan type
-	scansByType := map[string][]database.Scan{
-		"passive": {},
-		"active":  {},
-		"web":     {},
-	}
-	for _, s := range scans {
-		scansByType[s.ScanType] = append(scansByType[s.ScanType], s)
-	}
+	for _, sec := range m.Sections {
+		b.WriteString(fmt.Sprintf("## %s Findings\n\n", sec.Title))
 
-	sections := []struct {
-		title    string
-		scanType string
-	}{
-		{"Passive Reconnaissance Findings", "passive"},
-		{"Active Reconnaissance Findings", "active"},
-		{"Web Reconnaissance Findings", "web"},
-	}
-
-	for _, sec := range sections {
-		sectionScans := scansByType[sec.scanType]
-		if len(sectionScans) == 0 {
-			continue
-		}
-
-		b.WriteString(fmt.Sprintf("## %s\n\n", sec.title))
-
-		for _, scan := range sectionScans {
-			scanResults, _ := g.db.GetResultsByScan(scan.ID)
-
-			b.WriteString(fmt.Sprintf("### %s — %s\n\n", scan.Tool, scan.Target))
+		for _, scan := range sec.Scans {
+			b.WriteString(fmt.Sprintf("### %s\n\n", scan.Heading))
 			b.WriteString(fmt.Sprintf("**Status:** %s  \n", scan.Status))
 			if scan.StartedAt != nil {
 				b.WriteString(fmt.Sprintf("**Started:** %s  \n", scan.StartedAt.Format(time.RFC3339)))
 			}
 			b.WriteString("\n")
 
-			if len(scanResults) > 0 {
+			if len(scan.Results) > 0 {
 				b.WriteString("| Type | Key | Value |\n")
 				b.WriteString("|---|---|---|\n")
-				for _, r := range scanResults {
+				for _, r := range scan.Results {
 					val := r.Value
 					if len(val) > 100 {
 						val = val[:100] + "..."
 					}
+					if r.Stale {
+						val += " _(stale)_"
+					}
 					b.WriteString(fmt.Sprintf("| %s | %s | %s |\n", r.ResultType, r.Key, val))
 				}
 				b.WriteString("\n")
@@ -141,55 +193,49 @@ func (g *Generator) GenerateMarkdown(projectID int64) (string, error) {
 		}
 	}
 
-	// Raw Output Appendix
-	b.WriteString("## Appendix: Raw Tool Output\n\n")
-	for _, scan := range scans {
-		if scan.RawOutput == "" {
-			continue
+	// Evidence Appendix
+	if len(m.Evidence) > 0 {
+		b.WriteString("## Appendix: Evidence\n\n")
+		for _, ev := range m.Evidence {
+			b.WriteString(fmt.Sprintf("### %s: %s\n\n", ev.ResultType, ev.Key))
+			b.WriteString(fmt.Sprintf("**Scan:** %s  \n", ev.Scan))
+			b.WriteString(fmt.Sprintf("**Finding:** %s (%s)\n\n", ev.Value, ev.Severity))
+			b.WriteString("Request:\n\n```http\n")
+			b.WriteString(strings.TrimRight(ev.Request, "\n"))
+			b.WriteString("\n```\n\nResponse:\n\n```http\n")
+			b.WriteString(strings.TrimRight(ev.Response, "\n"))
+			b.WriteString("\n```\n\n")
+			if ev.Truncated {
+				b.WriteString("_Exchange truncated._\n\n")
+			}
 		}
-		b.WriteString(fmt.Sprintf("### %s — %s\n\n", scan.Tool, scan.Target))
-		b.WriteString("```\n")
-		output := scan.RawOutput
-		if len(output) > 5000 {
-			output = output[:5000] + "\n... (truncated)"
+	}
+
+	// Raw Output Appendix: the sections hold every scan between them
+	b.WriteString("## Appendix: Raw Tool Output\n\n")
+	for _, sec := range m.Sections {
+		for _, scan := range sec.Scans {
+			if scan.RawOutput == "" {
+				continue
+			}
+			b.WriteString(fmt.Sprintf("### %s\n\n", scan.Heading))
+			b.WriteString("```\n")
+			output := scan.RawOutput
+			if len(output) > 5000 {
+				output = output[:5000] + "\n... (truncated)"
+			}
+			b.WriteString(output)
+			b.WriteString("\n```\n\n")
 		}
-		b.WriteString(output)
-		b.WriteString("\n```\n\n")
 	}
 
-	return b.String(), nil
+	return b.String()
 }
 
-func (g *Generator) SaveMarkdown(projectID int64) (string, *database.Report, error) {
-	content, err := g.GenerateMarkdown(projectID)
+func (g *Generator) SaveMarkdown(projectID int64, opts Options) (string, *database.Report, error) {
+	m, err := g.buildReportModel(projectID, opts)
 	if err != nil {
 		return "", nil, err
 	}
-
-	project, _ := g.db.GetProject(projectID)
-	name := "report"
-	if project != nil {
-		name = strings.ReplaceAll(strings.ToLower(project.Name), " ", "-")
-	}
-
-	os.MkdirAll(g.reportsDir, 0755)
-	filename := fmt.Sprintf("%s-%s.md", name, time.Now().Format("20060102-150405"))
-	path := filepath.Join(g.reportsDir, filename)
-
-	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
-		return "", nil, fmt.Errorf("writing report: %w", err)
-	}
-
-	rpt := &database.Report{
-		ProjectID: projectID,
-		Title:     fmt.Sprintf("Recon Report — %s", name),
-		Format:    "markdown",
-		Content:   content,
-		FilePath:  path,
-	}
-	if err := g.db.CreateReport(rpt); err != nil {
-		return "", nil, fmt.Errorf("saving report record: %w", err)
-	}
-
-	return path, rpt, nil
+	return g.saveReport(m, "markdown", "md", []byte(renderMarkdown(m)), true)
 }
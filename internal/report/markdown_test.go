@@ -0,0 +1,58 @@
+package report
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // the zones below without system zoneinfo
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+func TestGenerateMarkdownTimezone(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "recon.db"), database.Options{})
+	if err != nil {
+		t.Fatalf("database.New: %v", err)
+	}
+	defer db.Close()
+
+	project := &database.Project{Name: "tz"}
+	if err := db.CreateProject(project); err != nil {
+		t.Fatalf("CreateProject: %v", err)
+	}
+	scan := &database.Scan{ProjectID: project.ID, ScanType: "passive", Tool: "whois", Target: "example.com", Status: "completed"}
+	if err := db.CreateScan(scan); err != nil {
+		t.Fatalf("CreateScan: %v", err)
+	}
+	// stored as UpdateScanStatus stores it: in UTC
+	started := time.Date(2024, time.March, 10, 14, 30, 0, 0, time.UTC)
+	if _, err := db.Exec(`UPDATE scans SET started_at = ? WHERE id = ?`, started, scan.ID); err != nil {
+		t.Fatalf("set started_at: %v", err)
+	}
+
+	tests := []struct {
+		zone string
+		want string
+	}{
+		{"UTC", "2024-03-10T14:30:00Z"},
+		{"Europe/Berlin", "2024-03-10T15:30:00+01:00"},
+		{"America/New_York", "2024-03-10T10:30:00-04:00"}, // hours after the DST change
+		{"Asia/Kolkata", "2024-03-10T20:00:00+05:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.zone, func(t *testing.T) {
+			loc, err := time.LoadLocation(tt.zone)
+			if err != nil {
+				t.Fatalf("LoadLocation: %v", err)
+			}
+			md, err := NewGenerator(db, t.TempDir(), loc).GenerateMarkdown(project.ID, Options{})
+			if err != nil {
+				t.Fatalf("GenerateMarkdown: %v", err)
+			}
+			if want := "**Started:** " + tt.want; !strings.Contains(md, want) {
+				t.Errorf("report does not contain %q:\n%s", want, md)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// ErrProjectNotFound is returned when a report is asked for a project that
+// does not exist.
+var ErrProjectNotFound = errors.New("project not found")
+
+// ReportModel is everything a report says, assembled once and handed to each
+// renderer. The json format serializes it as is.
+type ReportModel struct {
+	Project        database.Project `json:"project"`
+	GeneratedAt    time.Time        `json:"generated_at"`
+	Options        Options          `json:"options"`
+	Scope          []string         `json:"scope"`
+	ScanCount      int              `json:"scan_count"`
+	StatusCounts   []Count          `json:"status_counts"`
+	FindingCount   int              `json:"finding_count"`
+	StaleCount     int              `json:"stale_count"`
+	TypeCounts     []Count          `json:"type_counts"`
+	SeverityCounts []Count          `json:"severity_counts"`
+	Tools          []string         `json:"tools"`
+	Sections       []ReportSection  `json:"sections"`
+	Evidence       []EvidenceItem   `json:"evidence,omitempty"`
+}
+
+// Count is one row of a rollup table.
+type Count struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// EvidenceItem is the captured HTTP exchange behind one finding.
+type EvidenceItem struct {
+	Scan       string `json:"scan"`
+	ResultType string `json:"result_type"`
+	Key        string `json:"key"`
+	Value      string `json:"value"`
+	Severity   string `json:"severity"`
+	Request    string `json:"request"`
+	Response   string `json:"response"`
+	Truncated  bool   `json:"truncated,omitempty"`
+}
+
+// ReportSection groups the scans of one scan type.
+type ReportSection struct {
+	Title    string         `json:"title"`
+	ScanType string         `json:"scan_type"`
+	Scans    []ScanFindings `json:"scans"`
+}
+
+// ScanFindings is a scan together with the results it produced.
+type ScanFindings struct {
+	database.Scan
+	Heading string            `json:"heading"`
+	Results []database.Result `json:"results"`
+}
+
+var reportSections = []struct {
+	title    string
+	scanType string
+}{
+	{"Passive Reconnaissance", "passive"},
+	{"Active Reconnaissance", "active"},
+	{"Web Reconnaissance", "web"},
+}
+
+// buildReportModel loads a project's scans and results and derives the
+// summary figures every format shows.
+func (g *Generator) buildReportModel(projectID int64, opts Options) (*ReportModel, error) {
+	project, err := g.db.GetProject(projectID)
+	if err != nil {
+		return nil, err
+	}
+	if project == nil {
+		return nil, ErrProjectNotFound
+	}
+
+	scans, err := g.db.ListScansByProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("listing scans: %w", err)
+	}
+	// output kept in files reads as if it were stored inline
+	for i := range scans {
+		localizeScan(&scans[i], g.loc)
+		if scans[i].RawOutputFile == "" {
+			continue
+		}
+		out, err := scans[i].ReadRawOutput()
+		if err != nil {
+			out = fmt.Sprintf("(raw output unavailable: %v)", err)
+		}
+		scans[i].RawOutput = out
+	}
+
+	results, err := g.db.GetResultsByProject(projectID)
+	if err != nil {
+		return nil, fmt.Errorf("listing results: %w", err)
+	}
+	results = opts.filter(results)
+
+	m := &ReportModel{
+		Project:      *project,
+		GeneratedAt:  time.Now().In(g.loc),
+		Options:      opts,
+		Scope:        []string{},
+		ScanCount:    len(scans),
+		FindingCount: len(results),
+		Tools:        []string{},
+		Sections:     []ReportSection{},
+	}
+
+	for _, target := range strings.Split(project.Scope, "\n") {
+		if target = strings.TrimSpace(target); target != "" {
+			m.Scope = append(m.Scope, target)
+		}
+	}
+
+	typeCounts := make(map[string]int)
+	severityCounts := make(map[string]int)
+	byScan := make(map[int64][]database.Result)
+	for _, r := range results {
+		if r.Stale {
+			m.StaleCount++
+		}
+		typeCounts[r.ResultType]++
+		severityCounts[resultSeverity(r)]++
+		byScan[r.ScanID] = append(byScan[r.ScanID], r)
+	}
+	m.TypeCounts = sortedCounts(typeCounts)
+	m.SeverityCounts = sortedCounts(severityCounts)
+
+	statusCounts := make(map[string]int)
+	for _, s := range scans {
+		statusCounts[s.Status]++
+	}
+	m.StatusCounts = sortedCounts(statusCounts)
+
+	toolSet := make(map[string]bool)
+	for _, s := range scans {
+		if !toolSet[s.Tool] {
+			toolSet[s.Tool] = true
+			m.Tools = append(m.Tools, s.Tool)
+		}
+	}
+	sort.Strings(m.Tools)
+
+	// scans of a type without a section of its own go under "other", so
+	// every scan is in some section
+	sections := make([]ReportSection, 0, len(reportSections)+1)
+	sectionOf := make(map[string]int)
+	for _, sec := range reportSections {
+		sectionOf[sec.scanType] = len(sections)
+		sections = append(sections, ReportSection{Title: sec.title, ScanType: sec.scanType})
+	}
+	other := len(sections)
+	sections = append(sections, ReportSection{Title: "Other Reconnaissance", ScanType: "other"})
+	for _, s := range scans {
+		i, ok := sectionOf[s.ScanType]
+		if !ok {
+			i = other
+		}
+		scanResults := byScan[s.ID]
+		if scanResults == nil {
+			scanResults = []database.Result{}
+		}
+		sections[i].Scans = append(sections[i].Scans, ScanFindings{Scan: s, Heading: scanHeading(s), Results: scanResults})
+	}
+	for _, section := range sections {
+		if len(section.Scans) == 0 {
+			continue
+		}
+		m.Sections = append(m.Sections, section)
+		if opts.Evidence {
+			for _, s := range section.Scans {
+				m.Evidence = append(m.Evidence, resultEvidence(s.Heading, s.Results)...)
+			}
+		}
+	}
+
+	if opts.RedactTargets {
+		redactTargets(m)
+	}
+	return m, nil
+}
+
+// localizeScan moves a scan's timestamps, stored in UTC, into loc.
+func localizeScan(s *database.Scan, loc *time.Location) {
+	s.CreatedAt = s.CreatedAt.In(loc)
+	if s.StartedAt != nil {
+		t := s.StartedAt.In(loc)
+		s.StartedAt = &t
+	}
+	if s.CompletedAt != nil {
+		t := s.CompletedAt.In(loc)
+		s.CompletedAt = &t
+	}
+}
+
+// resultSeverity reads the severity a builtin recorded in Details, treating
+// results without one as informational.
+func resultSeverity(r database.Result) string {
+	var d struct {
+		Severity string `json:"severity"`
+	}
+	if r.Details != "" && json.Unmarshal([]byte(r.Details), &d) == nil && d.Severity != "" {
+		return d.Severity
+	}
+	return "info"
+}
+
+// resultEvidence collects the HTTP exchanges web builtins stored under
+// "exchange" in result details.
+func resultEvidence(heading string, results []database.Result) []EvidenceItem {
+	var items []EvidenceItem
+	for _, r := range results {
+		var d struct {
+			Exchange *struct {
+				Request   string `json:"request"`
+				Response  string `json:"response"`
+				Truncated bool   `json:"truncated"`
+			} `json:"exchange"`
+		}
+		if r.Details == "" || json.Unmarshal([]byte(r.Details), &d) != nil || d.Exchange == nil {
+			continue
+		}
+		items = append(items, EvidenceItem{
+			Scan:       heading,
+			ResultType: r.ResultType,
+			Key:        r.Key,
+			Value:      r.Value,
+			Severity:   resultSeverity(r),
+			Request:    d.Exchange.Request,
+			Response:   d.Exchange.Response,
+			Truncated:  d.Exchange.Truncated,
+		})
+	}
+	return items
+}
+
+// sortedCounts orders a rollup by count, then name, so output is stable.
+func sortedCounts(counts map[string]int) []Count {
+	out := make([]Count, 0, len(counts))
+	for name, c := range counts {
+		out = append(out, Count{Name: name, Count: c})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+// SaveJSON writes the report model as indented JSON.
+func (g *Generator) SaveJSON(projectID int64, opts Options) (string, *database.Report, error) {
+	m, err := g.buildReportModel(projectID, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("encoding report: %w", err)
+	}
+	return g.saveReport(m, "json", "json", data, true)
+}
+
+// saveReport writes rendered report bytes under the reports directory and
+// records them. Text formats also keep a copy in the database.
+func (g *Generator) saveReport(m *ReportModel, format, ext string, data []byte, storeContent bool) (string, *database.Report, error) {
+	name := projectSlug(m.Project)
+	return g.storeReport(m.Project.ID, name, fmt.Sprintf("Recon Report — %s", name), m.GeneratedAt, format, ext, data, storeContent)
+}
+
+// storeReport writes data to "<prefix>-<timestamp>.<ext>" under the reports
+// directory and creates its Report record.
+func (g *Generator) storeReport(projectID int64, prefix, title string, at time.Time, format, ext string, data []byte, storeContent bool) (string, *database.Report, error) {
+	os.MkdirAll(g.reportsDir, 0755)
+	filename := fmt.Sprintf("%s-%s.%s", prefix, at.Format("20060102-150405"), ext)
+	path := filepath.Join(g.reportsDir, filename)
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", nil, fmt.Errorf("writing report: %w", err)
+	}
+
+	rpt := &database.Report{
+		ProjectID: projectID,
+		Title:     title,
+		Format:    format,
+		FilePath:  path,
+	}
+	if storeContent {
+		rpt.Content = string(data)
+	}
+	if err := g.db.CreateReport(rpt); err != nil {
+		return "", nil, fmt.Errorf("saving report record: %w", err)
+	}
+
+	return path, rpt, nil
+}
+
+// projectSlug turns a project name into the prefix of its report files.
+func projectSlug(p database.Project) string {
+	return strings.ReplaceAll(strings.ToLower(p.Name), " ", "-")
+}
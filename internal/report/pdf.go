@@ -1,45 +1,25 @@
 package report
 
 import (
+	"bytes"
 	"fmt"
-	"os"
-	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/jamesruggles/reconsuite/internal/database"
 	"github.com/signintech/gopdf"
 )
 
-func (g *Generator) SavePDF(projectID int64) (string, *database.Report, error) {
-	project, err := g.db.GetProject(projectID)
-	if err != nil || project == nil {
-		return "", nil, fmt.Errorf("project not found")
-	}
-
-	scans, err := g.db.ListScansByProject(projectID)
+func (g *Generator) SavePDF(projectID int64, opts Options) (string, *database.Report, error) {
+	m, err := g.buildReportModel(projectID, opts)
 	if err != nil {
-		return "", nil, fmt.Errorf("listing scans: %w", err)
+		return "", nil, err
 	}
 
-	results, err := g.db.GetResultsByProject(projectID)
+	pdf, p, err := newPDF()
 	if err != nil {
-		return "", nil, fmt.Errorf("listing results: %w", err)
-	}
-
-	pdf := gopdf.GoPdf{}
-	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
-
-	// Use built-in Helvetica (no external font file needed)
-	if err := pdf.AddTTFFont("helvetica", "/System/Library/Fonts/Helvetica.ttc"); err != nil {
-		// Fallback: try a common path on Linux
-		if err2 := pdf.AddTTFFont("helvetica", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"); err2 != nil {
-			return "", nil, fmt.Errorf("loading font: %w (also tried: %v)", err, err2)
-		}
+		return "", nil, err
 	}
 
-	p := &pdfWriter{pdf: &pdf, y: 40, pageH: 842, pageW: 595, marginL: 40, marginR: 40}
-
 	// Title page
 	pdf.AddPage()
 	p.y = 200
@@ -47,23 +27,24 @@ func (g *Generator) SavePDF(projectID int64) (string, *database.Report, error) {
 	p.writeCenter("Reconnaissance Report")
 	p.y += 40
 	p.setFont(18)
-	p.writeCenter(project.Name)
+	p.writeCenter(m.Project.Name)
 	p.y += 30
 	p.setFont(12)
-	p.writeCenter(time.Now().Format("January 2, 2006"))
+	p.writeCenter(m.GeneratedAt.Format("January 2, 2006"))
 	p.y += 15
 	p.writeCenter("Generated by ReconSuite")
+	if m.Options.RedactTargets {
+		p.y += 15
+		p.writeCenter("Targets redacted (shown as TARGET-n)")
+	}
 
 	// Scope page
 	pdf.AddPage()
 	p.y = 40
 	p.heading("Scope")
-	if project.Scope != "" {
-		for _, target := range strings.Split(project.Scope, "\n") {
-			target = strings.TrimSpace(target)
-			if target != "" {
-				p.bullet(target)
-			}
+	if len(m.Scope) > 0 {
+		for _, target := range m.Scope {
+			p.bullet(target)
 		}
 	} else {
 		p.text("No scope defined.")
@@ -71,18 +52,27 @@ func (g *Generator) SavePDF(projectID int64) (string, *database.Report, error) {
 
 	// Executive Summary
 	p.heading("Executive Summary")
-	p.text(fmt.Sprintf("This report covers %d scan(s) performed against the defined scope.", len(scans)))
-	p.text(fmt.Sprintf("A total of %d finding(s) were recorded.", len(results)))
+	p.text(fmt.Sprintf("This report covers %d scan(s) performed against the defined scope.", m.ScanCount))
+	p.text(fmt.Sprintf("A total of %d finding(s) were recorded.", m.FindingCount))
+	if m.StaleCount > 0 {
+		p.text(fmt.Sprintf("%d finding(s), marked (stale), were not reported again by the latest scan of the same tool and target.", m.StaleCount))
+	}
+	if outcomes := scanOutcomes(m); outcomes != "" {
+		p.text(fmt.Sprintf("Scan outcomes: %s.", outcomes))
+	}
 	p.y += 10
 
-	typeCounts := make(map[string]int)
-	for _, r := range results {
-		typeCounts[r.ResultType]++
+	if len(m.SeverityCounts) > 0 {
+		p.tableRow("Severity", "Count", true)
+		for _, c := range m.SeverityCounts {
+			p.tableRow(c.Name, fmt.Sprintf("%d", c.Count), false)
+		}
+		p.y += 10
 	}
-	if len(typeCounts) > 0 {
+	if len(m.TypeCounts) > 0 {
 		p.tableRow("Finding Type", "Count", true)
-		for t, c := range typeCounts {
-			p.tableRow(t, fmt.Sprintf("%d", c), false)
+		for _, c := range m.TypeCounts {
+			p.tableRow(c.Name, fmt.Sprintf("%d", c.Count), false)
 		}
 		p.y += 10
 	}
@@ -90,52 +80,28 @@ func (g *Generator) SavePDF(projectID int64) (string, *database.Report, error) {
 	// Methodology
 	p.heading("Methodology")
 	p.text("The following tools were used during reconnaissance:")
-	toolSet := make(map[string]bool)
-	for _, s := range scans {
-		toolSet[s.Tool] = true
-	}
-	for tool := range toolSet {
+	for _, tool := range m.Tools {
 		p.bullet(tool)
 	}
 
 	// Findings
-	scansByType := map[string][]database.Scan{
-		"passive": {}, "active": {}, "web": {},
-	}
-	for _, s := range scans {
-		scansByType[s.ScanType] = append(scansByType[s.ScanType], s)
-	}
+	for _, sec := range m.Sections {
+		p.heading(sec.Title)
 
-	sections := []struct {
-		title    string
-		scanType string
-	}{
-		{"Passive Reconnaissance", "passive"},
-		{"Active Reconnaissance", "active"},
-		{"Web Reconnaissance", "web"},
-	}
-
-	for _, sec := range sections {
-		sectionScans := scansByType[sec.scanType]
-		if len(sectionScans) == 0 {
-			continue
-		}
-
-		p.heading(sec.title)
-
-		for _, scan := range sectionScans {
-			scanResults, _ := g.db.GetResultsByScan(scan.ID)
-
-			p.subheading(fmt.Sprintf("%s — %s", scan.Tool, scan.Target))
+		for _, scan := range sec.Scans {
+			p.subheading(scan.Heading)
 			p.text(fmt.Sprintf("Status: %s", scan.Status))
 
-			if len(scanResults) > 0 {
+			if len(scan.Results) > 0 {
 				p.tableRow3("Type", "Key", "Value", true)
-				for _, r := range scanResults {
+				for _, r := range scan.Results {
 					val := r.Value
 					if len(val) > 60 {
 						val = val[:60] + "..."
 					}
+					if r.Stale {
+						val += " (stale)"
+					}
 					p.tableRow3(r.ResultType, r.Key, val, false)
 				}
 				p.y += 5
@@ -143,27 +109,44 @@ func (g *Generator) SavePDF(projectID int64) (string, *database.Report, error) {
 		}
 	}
 
-	// Save
-	os.MkdirAll(g.reportsDir, 0755)
-	name := strings.ReplaceAll(strings.ToLower(project.Name), " ", "-")
-	filename := fmt.Sprintf("%s-%s.pdf", name, time.Now().Format("20060102-150405"))
-	path := filepath.Join(g.reportsDir, filename)
+	// Evidence
+	if len(m.Evidence) > 0 {
+		p.heading("Appendix: Evidence")
+		for _, ev := range m.Evidence {
+			p.subheading(truncate(ev.ResultType+": "+ev.Key, 70))
+			p.text(fmt.Sprintf("Scan: %s", ev.Scan))
+			p.text(fmt.Sprintf("Finding: %s (%s)", ev.Value, ev.Severity))
+			p.code(ev.Request)
+			p.y += 5
+			p.code(ev.Response)
+			if ev.Truncated {
+				p.text("Exchange truncated.")
+			}
+			p.y += 5
+		}
+	}
 
-	if err := pdf.WritePdf(path); err != nil {
+	var buf bytes.Buffer
+	if _, err := pdf.WriteTo(&buf); err != nil {
 		return "", nil, fmt.Errorf("writing PDF: %w", err)
 	}
+	return g.saveReport(m, "pdf", "pdf", buf.Bytes(), false)
+}
 
-	rpt := &database.Report{
-		ProjectID: projectID,
-		Title:     fmt.Sprintf("Recon Report — %s", name),
-		Format:    "pdf",
-		FilePath:  path,
-	}
-	if err := g.db.CreateReport(rpt); err != nil {
-		return "", nil, fmt.Errorf("saving report record: %w", err)
+// newPDF starts an A4 document with the report font loaded.
+func newPDF() (*gopdf.GoPdf, *pdfWriter, error) {
+	pdf := &gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+
+	// Use built-in Helvetica (no external font file needed)
+	if err := pdf.AddTTFFont("helvetica", "/System/Library/Fonts/Helvetica.ttc"); err != nil {
+		// Fallback: try a common path on Linux
+		if err2 := pdf.AddTTFFont("helvetica", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf"); err2 != nil {
+			return nil, nil, fmt.Errorf("loading font: %w (also tried: %v)", err, err2)
+		}
 	}
 
-	return path, rpt, nil
+	return pdf, &pdfWriter{pdf: pdf, y: 40, pageH: 842, pageW: 595, marginL: 40, marginR: 40}, nil
 }
 
 // pdfWriter is a helper for writing structured content to a GoPdf.
@@ -261,6 +244,19 @@ func (p *pdfWriter) text(text string) {
 	}
 }
 
+// code writes preformatted text line by line in a small font, cutting lines
+// that would run off the page.
+func (p *pdfWriter) code(text string) {
+	p.setFont(8)
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		p.checkPage(12)
+		p.pdf.SetX(p.marginL + 10)
+		p.pdf.SetY(p.y)
+		p.pdf.Cell(nil, truncate(strings.ReplaceAll(line, "\t", "    "), 110))
+		p.y += 11
+	}
+}
+
 func (p *pdfWriter) bullet(text string) {
 	p.checkPage(18)
 	p.setFont(10)
@@ -0,0 +1,193 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactor replaces target hostnames and IPs with stable pseudonyms
+// (TARGET-1, TARGET-2, ...) so a report can be shared without naming them.
+type redactor struct {
+	names   map[string]string // lower-cased host → pseudonym
+	pattern *regexp.Regexp
+}
+
+// newRedactor numbers the hosts of targets in order of first appearance.
+// It returns nil when there is nothing to redact.
+func newRedactor(targets []string) *redactor {
+	rd := &redactor{names: make(map[string]string)}
+	var hosts []string
+	for _, t := range targets {
+		host := targetHost(t)
+		if host == "" || rd.names[host] != "" {
+			continue
+		}
+		rd.names[host] = fmt.Sprintf("TARGET-%d", len(rd.names)+1)
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	// longest first, so www.example.com wins over example.com
+	sort.SliceStable(hosts, func(i, j int) bool { return len(hosts[i]) > len(hosts[j]) })
+	quoted := make([]string, len(hosts))
+	for i, h := range hosts {
+		quoted[i] = regexp.QuoteMeta(h)
+	}
+	rd.pattern = regexp.MustCompile(`(?i)` + strings.Join(quoted, "|"))
+	return rd
+}
+
+// resolvedAddresses returns the addresses the model's results show targets,
+// or names under them, resolving to: "resolution" results and A/AAAA "dns"
+// records. Without them a redacted report would still give each target's IP.
+func resolvedAddresses(m *ReportModel, targets []string) []string {
+	hosts := make(map[string]bool)
+	for _, t := range targets {
+		if h := targetHost(t); h != "" {
+			hosts[h] = true
+		}
+	}
+	underTarget := func(name string) bool {
+		name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+		for name != "" {
+			if hosts[name] {
+				return true
+			}
+			_, name, _ = strings.Cut(name, ".")
+		}
+		return false
+	}
+
+	var addrs []string
+	for _, sec := range m.Sections {
+		for _, s := range sec.Scans {
+			for _, r := range s.Results {
+				switch {
+				case r.ResultType == "resolution" && underTarget(r.Key):
+					var d struct {
+						Addresses []string `json:"addresses"`
+					}
+					json.Unmarshal([]byte(r.Details), &d)
+					addrs = append(addrs, d.Addresses...)
+				case r.ResultType == "dns" && (r.Key == "A" || r.Key == "AAAA"):
+					var d struct {
+						Name string `json:"name"`
+					}
+					json.Unmarshal([]byte(r.Details), &d)
+					if underTarget(d.Name) || d.Name == "" && underTarget(s.Target) {
+						addrs = append(addrs, r.Value)
+					}
+				}
+			}
+		}
+	}
+	var out []string
+	for _, a := range addrs {
+		if net.ParseIP(strings.TrimSpace(a)) != nil {
+			out = append(out, strings.TrimSpace(a))
+		}
+	}
+	return out
+}
+
+// targetHost takes the hostname or IP out of a scope entry or scan target:
+// a bare host, host:port, URL, CIDR range or *.wildcard.
+func targetHost(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil {
+			target = u.Hostname()
+		}
+	}
+	target, _, _ = strings.Cut(target, "/")
+	if host, _, err := net.SplitHostPort(target); err == nil {
+		target = host
+	}
+	target = strings.TrimPrefix(strings.Trim(target, "[]"), "*.")
+	return strings.ToLower(strings.TrimSuffix(target, "."))
+}
+
+// replace swaps every target host in s for its pseudonym. A match must not
+// sit inside a longer name or number, so 10.0.0.1 leaves 10.0.0.15 alone;
+// subdomains keep their prefix (api.TARGET-1).
+func (rd *redactor) replace(s string) string {
+	if s == "" {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range rd.pattern.FindAllStringIndex(s, -1) {
+		start, end := loc[0], loc[1]
+		if start > 0 && hostChar(s[start-1]) || end < len(s) && hostChar(s[end]) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(rd.names[strings.ToLower(s[start:end])])
+		last = end
+	}
+	if last == 0 {
+		return s
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func hostChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+// redactTargets pseudonymizes the scope and scan targets, and the addresses
+// they resolved to, across the whole model: project details, scan headings,
+// parameters and raw output, result keys, values and details, and the
+// evidence appendix. The model's structure and counts are left as they are.
+func redactTargets(m *ReportModel) {
+	targets := append([]string{}, m.Scope...)
+	for _, sec := range m.Sections {
+		for _, s := range sec.Scans {
+			targets = append(targets, s.Target)
+		}
+	}
+	targets = append(targets, resolvedAddresses(m, targets)...)
+	rd := newRedactor(targets)
+	if rd == nil {
+		return
+	}
+
+	m.Project.Name = rd.replace(m.Project.Name)
+	m.Project.Description = rd.replace(m.Project.Description)
+	m.Project.Scope = rd.replace(m.Project.Scope)
+	for i := range m.Scope {
+		m.Scope[i] = rd.replace(m.Scope[i])
+	}
+	for i := range m.Sections {
+		for j := range m.Sections[i].Scans {
+			s := &m.Sections[i].Scans[j]
+			s.Target = rd.replace(s.Target)
+			s.Label = rd.replace(s.Label)
+			s.Parameters = rd.replace(s.Parameters)
+			s.RawOutput = rd.replace(s.RawOutput)
+			s.Heading = rd.replace(s.Heading)
+			for k := range s.Results {
+				r := &s.Results[k]
+				r.Key = rd.replace(r.Key)
+				r.Value = rd.replace(r.Value)
+				r.Details = rd.replace(r.Details)
+			}
+		}
+	}
+	for i := range m.Evidence {
+		ev := &m.Evidence[i]
+		ev.Scan = rd.replace(ev.Scan)
+		ev.Key = rd.replace(ev.Key)
+		ev.Value = rd.replace(ev.Value)
+		ev.Request = rd.replace(ev.Request)
+		ev.Response = rd.replace(ev.Response)
+	}
+}
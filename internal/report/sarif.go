@@ -0,0 +1,172 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+// The SARIF types below cover the subset of the 2.1.0 log format that
+// security dashboards read: one run, a rule per result type, and a result
+// per finding located at the scan target.
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool      `json:"tool"`
+	Results    []sarifResult  `json:"results"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	Name             string       `json:"name"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID     string          `json:"ruleId"`
+	RuleIndex  int             `json:"ruleIndex"`
+	Level      string          `json:"level"`
+	Message    sarifMessage    `json:"message"`
+	Locations  []sarifLocation `json:"locations"`
+	Properties map[string]any  `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifLevel maps a finding severity onto SARIF's result levels.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "critical", "high":
+		return "error"
+	case "medium":
+		return "warning"
+	case "low":
+		return "note"
+	}
+	return "none"
+}
+
+// renderSARIF converts the report model into a SARIF log. Rules are the
+// result types present, sorted by name so rule indexes are stable.
+func renderSARIF(m *ReportModel) *sarifLog {
+	types := make([]string, 0, len(m.TypeCounts))
+	for _, c := range m.TypeCounts {
+		types = append(types, c.Name)
+	}
+	sort.Strings(types)
+
+	rules := make([]sarifRule, len(types))
+	ruleIndex := make(map[string]int, len(types))
+	for i, t := range types {
+		rules[i] = sarifRule{ID: t, Name: t, ShortDescription: sarifMessage{Text: t + " finding"}}
+		ruleIndex[t] = i
+	}
+
+	results := []sarifResult{}
+	for _, sec := range m.Sections {
+		for _, sf := range sec.Scans {
+			for _, r := range sf.Results {
+				results = append(results, sarifFinding(sf.Scan, r, ruleIndex[r.ResultType]))
+			}
+		}
+	}
+
+	return &sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "Raccoon Recon", Rules: rules}},
+			Results: results,
+			Properties: map[string]any{
+				"project":      m.Project.Name,
+				"generated_at": m.GeneratedAt,
+				"scope":        m.Scope,
+			},
+		}},
+	}
+}
+
+// sarifFinding turns one result into a SARIF result located at its scan's
+// target. Details are carried as properties when they hold valid JSON.
+func sarifFinding(scan database.Scan, r database.Result, ruleIndex int) sarifResult {
+	severity := resultSeverity(r)
+	text := r.Key
+	if r.Value != "" {
+		text += ": " + r.Value
+	}
+
+	props := map[string]any{
+		"scan_id":     r.ScanID,
+		"tool":        scan.Tool,
+		"scan_type":   scan.ScanType,
+		"severity":    severity,
+		"key":         r.Key,
+		"value":       r.Value,
+		"interesting": r.Interesting,
+	}
+	if r.Details != "" && json.Valid([]byte(r.Details)) {
+		props["details"] = json.RawMessage(r.Details)
+	}
+
+	return sarifResult{
+		RuleID:    r.ResultType,
+		RuleIndex: ruleIndex,
+		Level:     sarifLevel(severity),
+		Message:   sarifMessage{Text: text},
+		Locations: []sarifLocation{{
+			PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: scan.Target},
+			},
+		}},
+		Properties: props,
+	}
+}
+
+// SaveSARIF writes the project's findings as a SARIF 2.1.0 log.
+func (g *Generator) SaveSARIF(projectID int64, opts Options) (string, *database.Report, error) {
+	m, err := g.buildReportModel(projectID, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := json.MarshalIndent(renderSARIF(m), "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("encoding sarif report: %w", err)
+	}
+	return g.saveReport(m, "sarif", "sarif", data, true)
+}
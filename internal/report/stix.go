@@ -0,0 +1,450 @@
+package report
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const (
+	stixVersion = "2.1"
+	// stixSCONamespace is the UUIDv5 namespace STIX 2.1 defines for
+	// deterministic cyber-observable IDs.
+	stixSCONamespace = "00abedb4aa42466c9c01fed23315a9b7"
+	// stixIdentityID names Raccoon Recon as the creator of every export, so
+	// consumers see one producer across bundles.
+	stixIdentityID = "identity--d9a1a4b1-1efe-4fba-ab65-99474841a8d8"
+)
+
+// stixTimeFormat is the UTC, millisecond-precision timestamp STIX requires.
+const stixTimeFormat = "2006-01-02T15:04:05.000Z"
+
+// The STIX types below cover what the export writes: a bundle holding the
+// producer's identity, the observables (ipv4-addr, ipv6-addr, domain-name,
+// url, network-traffic), an observed-data object per scan and an indicator
+// per starred finding.
+type stixBundle struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Objects []any  `json:"objects"`
+}
+
+type stixIdentity struct {
+	Type          string `json:"type"`
+	SpecVersion   string `json:"spec_version"`
+	ID            string `json:"id"`
+	Created       string `json:"created"`
+	Modified      string `json:"modified"`
+	Name          string `json:"name"`
+	IdentityClass string `json:"identity_class"`
+}
+
+type stixObservable struct {
+	Type           string   `json:"type"`
+	SpecVersion    string   `json:"spec_version"`
+	ID             string   `json:"id"`
+	Value          string   `json:"value,omitempty"`
+	ResolvesToRefs []string `json:"resolves_to_refs,omitempty"`
+	DstRef         string   `json:"dst_ref,omitempty"`
+	DstPort        int      `json:"dst_port,omitempty"`
+	Protocols      []string `json:"protocols,omitempty"`
+}
+
+type stixObservedData struct {
+	Type           string   `json:"type"`
+	SpecVersion    string   `json:"spec_version"`
+	ID             string   `json:"id"`
+	CreatedByRef   string   `json:"created_by_ref"`
+	Created        string   `json:"created"`
+	Modified       string   `json:"modified"`
+	FirstObserved  string   `json:"first_observed"`
+	LastObserved   string   `json:"last_observed"`
+	NumberObserved int      `json:"number_observed"`
+	ObjectRefs     []string `json:"object_refs"`
+	ScanID         int64    `json:"x_reconsuite_scan_id"`
+	Tool           string   `json:"x_reconsuite_tool"`
+	Target         string   `json:"x_reconsuite_target"`
+}
+
+type stixIndicator struct {
+	Type         string `json:"type"`
+	SpecVersion  string `json:"spec_version"`
+	ID           string `json:"id"`
+	CreatedByRef string `json:"created_by_ref"`
+	Created      string `json:"created"`
+	Modified     string `json:"modified"`
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Pattern      string `json:"pattern"`
+	PatternType  string `json:"pattern_type"`
+	ValidFrom    string `json:"valid_from"`
+	ResultType   string `json:"x_reconsuite_result_type"`
+	Severity     string `json:"x_reconsuite_severity"`
+}
+
+// stixBuilder collects observables once each, in the order first seen, so
+// every finding naming the same domain or address points at one object.
+type stixBuilder struct {
+	byID  map[string]*stixObservable
+	order []string
+}
+
+// observable returns obj's ID, derived from its ID-contributing properties,
+// adding obj on first use.
+func (b *stixBuilder) observable(obj stixObservable, idProps map[string]any) string {
+	obj.SpecVersion = stixVersion
+	obj.ID = obj.Type + "--" + stixUUIDv5(idProps)
+	if b.byID[obj.ID] == nil {
+		b.byID[obj.ID] = &obj
+		b.order = append(b.order, obj.ID)
+	}
+	return obj.ID
+}
+
+// domain adds a domain-name, returning "" for anything that isn't a
+// hostname.
+func (b *stixBuilder) domain(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	if !stixHostname(name) {
+		return ""
+	}
+	return b.observable(stixObservable{Type: "domain-name", Value: name}, map[string]any{"value": name})
+}
+
+// ip adds an ipv4-addr or ipv6-addr, returning "" for anything else.
+func (b *stixBuilder) ip(addr string) string {
+	ip := net.ParseIP(strings.Trim(strings.TrimSpace(addr), "[]"))
+	if ip == nil {
+		return ""
+	}
+	typ := "ipv6-addr"
+	if ip.To4() != nil {
+		typ = "ipv4-addr"
+	}
+	return b.observable(stixObservable{Type: typ, Value: ip.String()}, map[string]any{"value": ip.String()})
+}
+
+// host adds an address or a domain-name, whichever name is.
+func (b *stixBuilder) host(name string) string {
+	if id := b.ip(name); id != "" {
+		return id
+	}
+	return b.domain(name)
+}
+
+// url adds a url, returning "" unless raw is an absolute http(s) URL.
+func (b *stixBuilder) url(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	v := u.String()
+	return b.observable(stixObservable{Type: "url", Value: v}, map[string]any{"value": v})
+}
+
+// resolves records that the domain-name from resolved to another object.
+func (b *stixBuilder) resolves(from, to string) {
+	d := b.byID[from]
+	if d == nil || to == "" || from == to {
+		return
+	}
+	for _, ref := range d.ResolvesToRefs {
+		if ref == to {
+			return
+		}
+	}
+	d.ResolvesToRefs = append(d.ResolvesToRefs, to)
+}
+
+// stixFinding maps one result onto observables, returning the IDs it
+// touched and, for the primary observable, a STIX pattern matching it.
+// Result types without a clean cyber-observable equivalent (technologies,
+// headers, TLS checks and the like) yield nothing.
+func (b *stixBuilder) stixFinding(r database.Result) (refs []string, pattern string) {
+	add := func(ids ...string) {
+		for _, id := range ids {
+			if id != "" {
+				refs = append(refs, id)
+			}
+		}
+	}
+	valuePattern := func(id string) string {
+		obj := b.byID[id]
+		return fmt.Sprintf("[%s:value = '%s']", obj.Type, stixQuote(obj.Value))
+	}
+
+	switch r.ResultType {
+	case "resolution":
+		var d struct {
+			Addresses []string `json:"addresses"`
+		}
+		json.Unmarshal([]byte(r.Details), &d)
+		domain := b.domain(r.Key)
+		if domain == "" {
+			return nil, ""
+		}
+		add(domain)
+		for _, addr := range d.Addresses {
+			ip := b.ip(addr)
+			b.resolves(domain, ip)
+			add(ip)
+		}
+		pattern = valuePattern(domain)
+
+	case "dns":
+		var d struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal([]byte(r.Details), &d)
+		owner := b.domain(d.Name)
+		if owner == "" {
+			return nil, ""
+		}
+		add(owner)
+		var to string
+		switch r.Key {
+		case "A", "AAAA":
+			to = b.ip(r.Value)
+		case "CNAME":
+			to = b.domain(r.Value)
+		}
+		b.resolves(owner, to)
+		add(to)
+		pattern = valuePattern(owner)
+
+	case "ct":
+		if r.Key != "subdomain" {
+			return nil, ""
+		}
+		if id := b.domain(r.Value); id != "" {
+			add(id)
+			pattern = valuePattern(id)
+		}
+
+	case "takeover":
+		if id := b.domain(r.Key); id != "" {
+			add(id)
+			pattern = valuePattern(id)
+		}
+
+	case "port":
+		if r.Value != "open" {
+			return nil, ""
+		}
+		var d struct {
+			Host string `json:"host"`
+		}
+		json.Unmarshal([]byte(r.Details), &d)
+		host := b.host(d.Host)
+		num, proto, _ := strings.Cut(r.Key, "/")
+		port, err := strconv.Atoi(num)
+		if host == "" || err != nil || port < 1 || port > 65535 {
+			return nil, ""
+		}
+		protocols := []string{strings.ToLower(proto)}
+		if proto == "" {
+			protocols = []string{"tcp"}
+		}
+		traffic := b.observable(
+			stixObservable{Type: "network-traffic", DstRef: host, DstPort: port, Protocols: protocols},
+			map[string]any{"dst_ref": host, "dst_port": port, "protocols": protocols},
+		)
+		add(host, traffic)
+		dst := b.byID[host]
+		pattern = fmt.Sprintf("[network-traffic:dst_ref.type = '%s' AND network-traffic:dst_ref.value = '%s' AND network-traffic:dst_port = %d]",
+			dst.Type, stixQuote(dst.Value), port)
+
+	case "metadata":
+		if r.Key != "final_url" {
+			return nil, ""
+		}
+		if id := b.url(r.Value); id != "" {
+			add(id)
+			pattern = valuePattern(id)
+		}
+
+	case "dir_listing":
+		if id := b.url(r.Key); id != "" {
+			add(id)
+			pattern = valuePattern(id)
+		}
+
+	case "disallowed_live":
+		var d struct {
+			URL string `json:"url"`
+		}
+		json.Unmarshal([]byte(r.Details), &d)
+		if id := b.url(d.URL); id != "" {
+			add(id)
+			pattern = valuePattern(id)
+		}
+	}
+	return refs, pattern
+}
+
+// renderSTIX converts the report model into a STIX 2.1 bundle. Each scan
+// with mappable findings becomes an observed-data object referencing the
+// observables it saw; starred findings additionally become indicators, as
+// the ones an analyst chose to share. Observable IDs are deterministic, so
+// the same host exported twice keeps its ID.
+func renderSTIX(m *ReportModel) *stixBundle {
+	created := m.GeneratedAt.UTC().Format(stixTimeFormat)
+	b := &stixBuilder{byID: make(map[string]*stixObservable)}
+	var sdos []any
+
+	for _, sec := range m.Sections {
+		for _, sf := range sec.Scans {
+			first := sf.CreatedAt
+			if sf.StartedAt != nil {
+				first = *sf.StartedAt
+			}
+			last := first
+			if sf.CompletedAt != nil && sf.CompletedAt.After(first) {
+				last = *sf.CompletedAt
+			}
+
+			var objectRefs []string
+			seen := make(map[string]bool)
+			for _, r := range sf.Results {
+				refs, pattern := b.stixFinding(r)
+				for _, id := range refs {
+					if !seen[id] {
+						seen[id] = true
+						objectRefs = append(objectRefs, id)
+					}
+				}
+				if !r.Interesting || pattern == "" {
+					continue
+				}
+				sdos = append(sdos, stixIndicator{
+					Type:         "indicator",
+					SpecVersion:  stixVersion,
+					ID:           "indicator--" + stixUUIDv4(),
+					CreatedByRef: stixIdentityID,
+					Created:      created,
+					Modified:     created,
+					Name:         fmt.Sprintf("%s %s", r.ResultType, r.Key),
+					Description:  r.Value,
+					Pattern:      pattern,
+					PatternType:  "stix",
+					ValidFrom:    first.UTC().Format(stixTimeFormat),
+					ResultType:   r.ResultType,
+					Severity:     resultSeverity(r),
+				})
+			}
+			if len(objectRefs) == 0 {
+				continue
+			}
+			sdos = append(sdos, stixObservedData{
+				Type:           "observed-data",
+				SpecVersion:    stixVersion,
+				ID:             "observed-data--" + stixUUIDv4(),
+				CreatedByRef:   stixIdentityID,
+				Created:        created,
+				Modified:       created,
+				FirstObserved:  first.UTC().Format(stixTimeFormat),
+				LastObserved:   last.UTC().Format(stixTimeFormat),
+				NumberObserved: 1,
+				ObjectRefs:     objectRefs,
+				ScanID:         sf.ID,
+				Tool:           sf.Tool,
+				Target:         sf.Target,
+			})
+		}
+	}
+
+	objects := []any{stixIdentity{
+		Type:          "identity",
+		SpecVersion:   stixVersion,
+		ID:            stixIdentityID,
+		Created:       created,
+		Modified:      created,
+		Name:          "Raccoon Recon",
+		IdentityClass: "system",
+	}}
+	for _, id := range b.order {
+		objects = append(objects, b.byID[id])
+	}
+	objects = append(objects, sdos...)
+	return &stixBundle{Type: "bundle", ID: "bundle--" + stixUUIDv4(), Objects: objects}
+}
+
+// stixHostname reports whether name looks like a DNS name with at least two
+// labels, so scan keys and wildcard certificate names are not exported as
+// domains.
+func stixHostname(name string) bool {
+	if len(name) > 253 || !strings.Contains(name, ".") || net.ParseIP(name) != nil {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stixQuote escapes a string for a single-quoted STIX pattern literal.
+func stixQuote(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s)
+}
+
+// stixUUIDv5 derives an observable's UUID from its ID-contributing
+// properties, serialized as canonical JSON (sorted keys, no HTML escaping)
+// the way STIX 2.1 specifies.
+func stixUUIDv5(props map[string]any) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(props)
+	ns, _ := hex.DecodeString(stixSCONamespace)
+	h := sha1.New()
+	h.Write(ns)
+	h.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	var u [16]byte
+	copy(u[:], h.Sum(nil))
+	u[6] = u[6]&0x0f | 0x50
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+// stixUUIDv4 returns a random UUID for the objects a bundle creates.
+func stixUUIDv4() string {
+	var u [16]byte
+	rand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return formatUUID(u)
+}
+
+func formatUUID(u [16]byte) string {
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:16])
+}
+
+// SaveSTIX writes the project's host, domain and URL findings as a STIX 2.1
+// bundle for threat-intel platforms.
+func (g *Generator) SaveSTIX(projectID int64, opts Options) (string, *database.Report, error) {
+	m, err := g.buildReportModel(projectID, opts)
+	if err != nil {
+		return "", nil, err
+	}
+	data, err := json.MarshalIndent(renderSTIX(m), "", "  ")
+	if err != nil {
+		return "", nil, fmt.Errorf("encoding stix bundle: %w", err)
+	}
+	return g.saveReport(m, "stix", "json", data, true)
+}
@@ -0,0 +1,143 @@
+package scanner
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+var (
+	htmlFormRe   = regexp.MustCompile(`(?is)<form\b([^>]*)>(.*?)</form>`)
+	htmlInputRe  = regexp.MustCompile(`(?i)<input\b[^>]*>`)
+	htmlAnchorRe = regexp.MustCompile(`(?i)<a\b[^>]*>`)
+	htmlMetaRe   = regexp.MustCompile(`(?i)<meta\b[^>]*>`)
+	htmlAttrRe   = regexp.MustCompile(`([\w:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+	// loginActionRe matches form actions commonly used for sign-in.
+	loginActionRe = regexp.MustCompile(`(?i)(/log-?in|/sign-?in|/auth(?:enticate)?\b|/session|/sso\b|/saml|/wp-login\.php|/j_security_check|/user/login|/account/login|/admin(?:/|$|\?))`)
+
+	// csrfFieldRe matches anti-CSRF token field and meta names across frameworks.
+	csrfFieldRe = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity_token|__requestverificationtoken|_token$|csrfmiddlewaretoken)`)
+)
+
+// oauthProviders maps substrings of authorization URLs to the identity
+// provider they belong to. Generic "/oauth" and "/authorize" paths come last.
+var oauthProviders = []struct {
+	pattern  string
+	provider string
+}{
+	{"accounts.google.com/o/oauth2", "Google"},
+	{"login.microsoftonline.com", "Microsoft"},
+	{"github.com/login/oauth", "GitHub"},
+	{"facebook.com/dialog/oauth", "Facebook"},
+	{"appleid.apple.com/auth", "Apple"},
+	{".okta.com/oauth2", "Okta"},
+	{".auth0.com/authorize", "Auth0"},
+	{"/protocol/openid-connect/auth", "Keycloak"},
+	{"/oauth2/authorize", "OAuth"},
+	{"/oauth/authorize", "OAuth"},
+}
+
+// --- Login / Auth Portal Detection ---
+
+// detectAuthPortals looks through a fetched page for authentication surfaces:
+// forms with a password field, forms posting to a login-like action, anti-CSRF
+// tokens and OAuth/SSO authorization links. Relative URLs are resolved
+// against base.
+func detectAuthPortals(scanID int64, base *url.URL, html string) []database.Result {
+	var results []database.Result
+	seen := make(map[string]bool)
+	add := func(key, value string, d authPortalDetails) {
+		if seen[d.Indicator+"|"+key] {
+			return
+		}
+		seen[d.Indicator+"|"+key] = true
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "auth_portal",
+			Key:        key,
+			Value:      value,
+			Details:    detailsJSON(d),
+		})
+	}
+
+	for _, m := range htmlFormRe.FindAllStringSubmatch(html, -1) {
+		attrs, body := m[1], m[2]
+		action := resolveHTMLRef(base, htmlAttr(attrs, "action"))
+		method := strings.ToUpper(htmlAttr(attrs, "method"))
+		if method == "" {
+			method = "GET"
+		}
+
+		hasPassword := false
+		csrfField := ""
+		for _, input := range htmlInputRe.FindAllString(body, -1) {
+			switch strings.ToLower(htmlAttr(input, "type")) {
+			case "password":
+				hasPassword = true
+			case "hidden":
+				if name := htmlAttr(input, "name"); csrfFieldRe.MatchString(name) {
+					csrfField = name
+				}
+			}
+		}
+
+		switch {
+		case hasPassword:
+			add(action, "login form ("+method+")", authPortalDetails{Indicator: "password_field", Method: method, CSRFField: csrfField})
+		case loginActionRe.MatchString(action):
+			add(action, "form posting to a login endpoint ("+method+")", authPortalDetails{Indicator: "login_action", Method: method, CSRFField: csrfField})
+		}
+		if csrfField != "" {
+			add(csrfField, "anti-CSRF token field", authPortalDetails{Indicator: "csrf_token", Form: action})
+		}
+	}
+
+	for _, meta := range htmlMetaRe.FindAllString(html, -1) {
+		if name := htmlAttr(meta, "name"); csrfFieldRe.MatchString(name) {
+			add(name, "anti-CSRF token meta tag", authPortalDetails{Indicator: "csrf_token"})
+		}
+	}
+
+	for _, a := range htmlAnchorRe.FindAllString(html, -1) {
+		href := htmlAttr(a, "href")
+		if href == "" {
+			continue
+		}
+		link := resolveHTMLRef(base, href)
+		lower := strings.ToLower(link)
+		for _, p := range oauthProviders {
+			if strings.Contains(lower, p.pattern) {
+				add(link, p.provider+" OAuth/SSO authorization link", authPortalDetails{Indicator: "oauth_link", Provider: p.provider})
+				break
+			}
+		}
+	}
+
+	return results
+}
+
+// htmlAttr returns the value of attr in a tag or attribute list, quoted or not.
+func htmlAttr(tag, attr string) string {
+	for _, m := range htmlAttrRe.FindAllStringSubmatch(tag, -1) {
+		if strings.EqualFold(m[1], attr) {
+			return strings.TrimSpace(m[2] + m[3] + m[4])
+		}
+	}
+	return ""
+}
+
+// resolveHTMLRef resolves an href or form action against the page URL; an
+// empty action means the form posts back to the page itself.
+func resolveHTMLRef(base *url.URL, ref string) string {
+	if base == nil {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const (
+	basicAuthUserParam = "basic_auth_user"
+	basicAuthPassParam = "basic_auth_pass"
+	maxBasicAuthLen    = 256
+	redactedSecret     = "********"
+)
+
+// basicAuth holds HTTP basic credentials for a scan. The password lives only
+// in memory for the life of the scan; it is stripped from the parameters
+// before the scan is stored.
+type basicAuth struct {
+	user string
+	pass string
+}
+
+// scanBasicAuth reads and validates the basic_auth_user / basic_auth_pass
+// parameters. It returns nil when neither is set.
+func scanBasicAuth(scan *database.Scan) (*basicAuth, error) {
+	var params map[string]string
+	if scan.Parameters != "" && scan.Parameters != "{}" {
+		json.Unmarshal([]byte(scan.Parameters), &params)
+	}
+	user, pass := params[basicAuthUserParam], params[basicAuthPassParam]
+	if user == "" && pass == "" {
+		return nil, nil
+	}
+	if user == "" {
+		return nil, fmt.Errorf("basic_auth_user is required with basic_auth_pass")
+	}
+	if strings.Contains(user, ":") {
+		return nil, fmt.Errorf("basic_auth_user cannot contain ':'")
+	}
+	for name, v := range map[string]string{"basic_auth_user": user, "basic_auth_pass": pass} {
+		if len(v) > maxBasicAuthLen {
+			return nil, fmt.Errorf("%s is longer than %d characters", name, maxBasicAuthLen)
+		}
+		if strings.IndexFunc(v, unicode.IsControl) >= 0 {
+			return nil, fmt.Errorf("%s contains control characters", name)
+		}
+	}
+	return &basicAuth{user: user, pass: pass}, nil
+}
+
+// stripBasicAuthPass removes the password from the scan's stored parameters.
+func stripBasicAuthPass(scan *database.Scan) {
+	var params map[string]any
+	if json.Unmarshal([]byte(scan.Parameters), &params) != nil {
+		return
+	}
+	if _, ok := params[basicAuthPassParam]; !ok {
+		return
+	}
+	delete(params, basicAuthPassParam)
+	if data, err := json.Marshal(params); err == nil {
+		scan.Parameters = string(data)
+	}
+}
+
+// redactArgs returns args with the password masked, for showing a command.
+func (a *basicAuth) redactArgs(args []string) []string {
+	if a == nil || a.pass == "" {
+		return args
+	}
+	pair := a.user + ":" + a.pass
+	out := make([]string, len(args))
+	for i, arg := range args {
+		if arg == a.pass {
+			out[i] = redactedSecret
+		} else {
+			out[i] = strings.ReplaceAll(arg, pair, a.user+":"+redactedSecret)
+		}
+	}
+	return out
+}
+
+// basicAuthTransport adds credentials to requests for the scan target's host
+// only, so they never follow redirects or script fetches to other sites.
+type basicAuthTransport struct {
+	base http.RoundTripper
+	auth *basicAuth
+	host string
+}
+
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !strings.EqualFold(req.URL.Hostname(), t.host) || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.auth.user, t.auth.pass)
+	return t.base.RoundTrip(req)
+}
+
+// wrap returns c with credentials added for target's host. A nil receiver
+// returns c unchanged.
+func (a *basicAuth) wrap(c *http.Client, target string) *http.Client {
+	if a == nil {
+		return c
+	}
+	c.Transport = &basicAuthTransport{base: c.Transport, auth: a, host: authTargetHost(target)}
+	return c
+}
+
+// authTargetHost is the host credentials are sent to: the target URL's host,
+// or the target itself when it is a bare hostname.
+func authTargetHost(target string) string {
+	if !strings.Contains(target, "://") {
+		target = "https://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -2,22 +2,33 @@ package scanner
 
 import (
 	"context"
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jamesruggles/reconsuite/internal/database"
 	"github.com/jamesruggles/reconsuite/internal/tools"
 )
 
-// runBuiltinScan handles tools that don't require external binaries.
-func (e *Executor) runBuiltinScan(ctx context.Context, scan *database.Scan) {
+// runBuiltinScan handles tools that don't require external binaries. Builtins
+// that take a while hand each result to the sink as they find it; the quick
+// ones return theirs at the end.
+func (e *Executor) runBuiltinScan(ctx context.Context, scan *database.Scan, auth *basicAuth) {
 	e.db.UpdateScanStatus(scan.ID, "running")
 
+	sink := e.newResultSink(scan.ID)
+	progress := func(msg string) { e.broadcastLines(scan.ID, msg) }
+	client := func(timeout time.Duration) *http.Client { return auth.wrap(e.httpClient(timeout), scan.Target) }
 	var results []database.Result
 	var err error
 
@@ -29,34 +40,138 @@ func (e *Executor) runBuiltinScan(ctx context.Context, scan *database.Scan) {
 		results = generateOSINTLinks(scan.ID, scan.Target)
 		e.broadcastLines(scan.ID, "Generated OSINT resource links for: "+scan.Target)
 	case "ssl_check":
-		results, err = checkSSL(scan.ID, scan.Target)
+		results, err = checkSSL(e.dialer, scan.ID, scan.Target, scanParam(scan, "starttls"))
 	case "robots_sitemap":
-		results, err = fetchRobotsSitemap(ctx, scan.ID, scan.Target)
+		results, err = fetchRobotsSitemap(ctx, client(15*time.Second), scan.ID, scan.Target, progress)
+		if probe, _ := probeDisallowedParam(scanParam(scan, "probe_disallowed")); probe && err == nil {
+			e.broadcastLines(scan.ID, "Probing disallowed paths for live content")
+			workers, _ := e.builtinConcurrency(scan) // validated by PlanScan
+			err = probeDisallowed(ctx, client(15*time.Second), scan.ID, scan.Target, results, workers,
+				e.cfg.Web.CaptureEvidence, sink.emitResult, progress)
+		}
 	case "metadata_extract":
 		e.broadcastLines(scan.ID, "Extracting metadata from: "+scan.Target)
-		results, err = extractMetadata(ctx, scan.ID, scan.Target)
-	}
-
-	if err != nil {
+		results, err = extractMetadata(ctx, client(20*time.Second), scan.ID, scan.Target, e.cfg.Web.InterestingHeaders, e.cfg.Web.CaptureEvidence, progress)
+	case "js_fingerprint":
+		e.broadcastLines(scan.ID, "Fingerprinting JavaScript libraries on: "+scan.Target)
+		err = fingerprintJSLibraries(ctx, client(20*time.Second), scan.ID, scan.Target, sink.emitResult, progress)
+	case "well_known":
+		e.broadcastLines(scan.ID, "Probing /.well-known/ resources on: "+scan.Target)
+		err = probeWellKnown(ctx, client(15*time.Second), scan.ID, scan.Target, e.cfg.Web.CaptureEvidence, sink.emitResult, progress)
+	case "framing_check":
+		e.broadcastLines(scan.ID, "Checking framing protections on: "+scan.Target)
+		results, err = checkFraming(ctx, client(15*time.Second), scan.ID, scan.Target, e.cfg.Web.CaptureEvidence, progress)
+	case "takeover_check":
+		e.broadcastLines(scan.ID, "Checking for subdomain takeover indicators: "+scan.Target)
+		workers, _ := e.builtinConcurrency(scan) // validated by PlanScan
+		err = checkTakeover(ctx, e.resolver, client(10*time.Second), scan.ID, scan.Target, workers,
+			e.cfg.Web.CaptureEvidence, sink.emitResult, progress)
+	case "caa_check":
+		e.broadcastLines(scan.ID, "Looking up CAA records for: "+scan.Target)
+		results, err = checkCAA(ctx, e.dialer, e.cfg.Network.DNSResolver, scan.ID, scan.Target, progress)
+	case "ct_logs":
+		e.broadcastLines(scan.ID, "Searching certificate transparency logs for: "+scan.Target)
+		days, _ := ctDays(scanParam(scan, "days")) // validated by PlanScan
+		var cursor ctCursor
+		if resume, _ := ctResume(scanParam(scan, "resume")); resume {
+			if cursor, err = e.loadCTCursor(scan); err != nil {
+				err = fmt.Errorf("load resume cursor: %w", err)
+				break
+			}
+		}
+		results, err = analyzeCTLogs(ctx, client(90*time.Second), scan.ID, scan.Target, days, cursor, progress)
+	case "http_methods":
+		e.broadcastLines(scan.ID, "Enumerating HTTP methods on: "+scan.Target)
+		err = probeHTTPMethods(ctx, client(15*time.Second), scan.ID, scan.Target, e.cfg.Web.CaptureEvidence, sink.emitResult, progress)
+	case "http_trace":
+		e.broadcastLines(scan.ID, "Tracing the HTTP response chain of: "+scan.Target)
+		results, err = traceHTTP(ctx, auth.wrap(e.traceClient(20*time.Second), scan.Target), scan.ID, scan.Target, progress)
+	}
+	sink.emitResults(results)
+
+	if err != nil && ctx.Err() != nil {
+		if n := sink.close(true); n > 0 {
+			e.broadcastLines(scan.ID, fmt.Sprintf("Kept %d partial result(s) found before the scan stopped", n))
+		}
+		e.db.UpdateScanStatus(scan.ID, "cancelled")
+		e.broadcaster.Broadcast(scan.ID, tools.OutputLine{
+			Timestamp: time.Now(), Stream: "stderr", Line: "Scan cancelled",
+		})
+	} else if err != nil {
+		sink.close(false)
 		e.db.UpdateScanStatus(scan.ID, "failed")
 		e.broadcaster.Broadcast(scan.ID, tools.OutputLine{
 			Timestamp: time.Now(), Stream: "stderr", Line: "Error: " + err.Error(),
 		})
 	} else {
-		if len(results) > 0 {
-			for _, r := range results {
-				e.broadcaster.Broadcast(scan.ID, tools.OutputLine{
-					Timestamp: time.Now(), Stream: "stdout", Line: r.Key + ": " + r.Value,
-				})
-			}
-			e.db.CreateResults(results)
+		if sink.count() == 0 {
+			sink.emitResult(emptyScanSummary(scan))
 		}
+		sink.close(false)
 		e.db.UpdateScanStatus(scan.ID, "completed")
 	}
 
 	e.broadcaster.Broadcast(scan.ID, tools.OutputLine{Done: true, Timestamp: time.Now()})
 }
 
+// builtinChecks describes what each builtin looks at, for the summary of a
+// scan that found nothing.
+var builtinChecks = map[string]string{
+	"ssl_check":        "the TLS certificate and handshake",
+	"robots_sitemap":   "robots.txt and sitemap.xml",
+	"metadata_extract": "response headers, title and meta tags",
+	"js_fingerprint":   "page scripts for known JavaScript libraries",
+	"well_known":       "/.well-known/ resources",
+	"framing_check":    "framing protections",
+	"takeover_check":   "CNAMEs against takeover fingerprints",
+	"caa_check":        "CAA records",
+	"ct_logs":          "certificate transparency logs",
+	"http_methods":     "allowed HTTP methods",
+	"http_trace":       "the HTTP response chain",
+}
+
+// emptyScanSummary is the "summary" result stored for a builtin scan that
+// completed without findings, so "checked, nothing found" reads differently
+// from a scan that produced no output at all.
+func emptyScanSummary(scan *database.Scan) database.Result {
+	checked := builtinChecks[scan.Tool]
+	if checked == "" {
+		checked = scan.Tool
+	}
+	return database.Result{
+		ScanID:     scan.ID,
+		ResultType: "summary",
+		Key:        scan.Tool,
+		Value:      fmt.Sprintf("Checked %s on %s: nothing found", checked, scan.Target),
+		Details:    detailsJSON(summaryDetails{Checked: checked}),
+	}
+}
+
+// forEachConcurrent calls fn(0..n-1) from at most workers goroutines and
+// returns once all calls finish. Indexes not yet started when ctx is done are
+// skipped.
+func forEachConcurrent(ctx context.Context, n, workers int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	idx := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range idx {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		idx <- i
+	}
+	close(idx)
+	wg.Wait()
+}
+
 func (e *Executor) broadcastLines(scanID int64, msg string) {
 	for _, line := range strings.Split(msg, "\n") {
 		e.broadcaster.Broadcast(scanID, tools.OutputLine{
@@ -91,7 +206,7 @@ func generateGoogleDorks(scanID int64, target string) []database.Result {
 			ResultType: "google_dork",
 			Key:        d.category,
 			Value:      "https://www.google.com/search?q=" + strings.ReplaceAll(d.query, " ", "+"),
-			Details:    fmt.Sprintf(`{"query":"%s"}`, d.query),
+			Details:    detailsJSON(dorkDetails{Query: d.query}),
 		})
 	}
 	return results
@@ -141,15 +256,20 @@ func generateOSINTLinks(scanID int64, target string) []database.Result {
 
 // --- SSL/TLS Check ---
 
-func checkSSL(scanID int64, target string) ([]database.Result, error) {
-	host := target
-	if !strings.Contains(host, ":") {
-		host = host + ":443"
+// checkSSL connects to the target's port (443 unless the target names one)
+// and records the negotiated TLS parameters and certificate. starttls selects
+// the negotiation path; see sslNegotiation.
+func checkSSL(dialer *net.Dialer, scanID int64, target, starttls string) ([]database.Result, error) {
+	host, port := sslTarget(target)
+	mode := sslNegotiation(starttls, port)
+
+	cfg := &tls.Config{InsecureSkipVerify: true}
+	// SNI must carry a hostname; IP literals are not permitted there
+	if net.ParseIP(host) == nil {
+		cfg.ServerName = host
 	}
 
-	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", host, &tls.Config{
-		InsecureSkipVerify: true,
-	})
+	conn, err := dialSSL(dialer, net.JoinHostPort(host, port), mode, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("TLS connection failed: %w", err)
 	}
@@ -158,6 +278,13 @@ func checkSSL(scanID int64, target string) ([]database.Result, error) {
 	state := conn.ConnectionState()
 	var results []database.Result
 
+	results = append(results, database.Result{
+		ScanID:     scanID,
+		ResultType: "ssl",
+		Key:        "negotiation",
+		Value:      negotiationName(mode) + " on port " + port,
+	})
+
 	results = append(results, database.Result{
 		ScanID:     scanID,
 		ResultType: "ssl",
@@ -204,11 +331,38 @@ func checkSSL(scanID int64, target string) ([]database.Result, error) {
 			Key:        "san",
 			Value:      strings.Join(cert.DNSNames, ", "),
 		})
+
+		keyDesc, keySeverity, keyNote := certKeyStrength(cert)
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "ssl",
+			Key:        "public_key",
+			Value:      keyDesc,
+			Details:    detailsJSON(sslStrengthDetails{Severity: keySeverity, Note: keyNote}),
+		})
+		sigSeverity, sigNote := signatureStrength(cert.SignatureAlgorithm)
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "ssl",
+			Key:        "signature_algorithm",
+			Value:      cert.SignatureAlgorithm.String(),
+			Details:    detailsJSON(sslStrengthDetails{Severity: sigSeverity, Note: sigNote}),
+		})
 	}
 
 	return results, nil
 }
 
+// splitHostPortDefault separates an optional port from target, falling back to
+// defaultPort. It accepts hostnames, IPv4, bare IPv6 literals ("2001:db8::1"),
+// and bracketed IPv6 with or without a port ("[2001:db8::1]:8443").
+func splitHostPortDefault(target, defaultPort string) (host, port string) {
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		return h, p
+	}
+	return strings.Trim(target, "[]"), defaultPort
+}
+
 func tlsVersionName(v uint16) string {
 	switch v {
 	case tls.VersionTLS10:
@@ -224,21 +378,63 @@ func tlsVersionName(v uint16) string {
 	}
 }
 
+// certKeyStrength describes a certificate's public key ("RSA 2048", "EC
+// P-256") and rates it: RSA or DSA under 2048 bits is high, any DSA key or
+// an EC curve under 256 bits is medium.
+func certKeyStrength(cert *x509.Certificate) (desc, severity, note string) {
+	switch k := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		bits := k.N.BitLen()
+		desc = fmt.Sprintf("RSA %d", bits)
+		if bits < 2048 {
+			return desc, "high", "RSA keys under 2048 bits can be factored with modest resources"
+		}
+	case *ecdsa.PublicKey:
+		desc = "EC " + k.Curve.Params().Name
+		if k.Curve.Params().BitSize < 256 {
+			return desc, "medium", "elliptic curves under 256 bits are below current guidance"
+		}
+	case ed25519.PublicKey:
+		desc = "Ed25519"
+	case *dsa.PublicKey:
+		bits := k.P.BitLen()
+		desc = fmt.Sprintf("DSA %d", bits)
+		if bits < 2048 {
+			return desc, "high", "DSA is deprecated and this key is under 2048 bits"
+		}
+		return desc, "medium", "DSA is deprecated for TLS certificates"
+	default:
+		desc = cert.PublicKeyAlgorithm.String()
+	}
+	return desc, "info", ""
+}
+
+// signatureStrength rates a certificate's signature algorithm: MD2/MD5 are
+// high, SHA-1 is medium, everything newer is info.
+func signatureStrength(alg x509.SignatureAlgorithm) (severity, note string) {
+	switch alg {
+	case x509.MD2WithRSA, x509.MD5WithRSA:
+		return "high", "MD2 and MD5 signatures can be forged"
+	case x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return "medium", "SHA-1 signatures are vulnerable to chosen-prefix collisions and rejected by browsers"
+	}
+	return "info", ""
+}
+
 // --- Robots.txt / Sitemap ---
 
-func fetchRobotsSitemap(ctx context.Context, scanID int64, target string) ([]database.Result, error) {
+func fetchRobotsSitemap(ctx context.Context, client *http.Client, scanID int64, target string, progress func(string)) ([]database.Result, error) {
 	if !strings.HasPrefix(target, "http") {
 		target = "https://" + target
 	}
 	target = strings.TrimRight(target, "/")
 
-	client := &http.Client{Timeout: 15 * time.Second}
 	var results []database.Result
 
 	// Fetch robots.txt
 	robotsURL := target + "/robots.txt"
 	req, _ := http.NewRequestWithContext(ctx, "GET", robotsURL, nil)
-	resp, err := client.Do(req)
+	resp, err := doWithRetry(ctx, client, req, progress)
 	if err == nil {
 		defer resp.Body.Close()
 		if resp.StatusCode == 200 {
@@ -271,7 +467,7 @@ func fetchRobotsSitemap(ctx context.Context, scanID int64, target string) ([]dat
 	// Fetch sitemap.xml
 	sitemapURL := target + "/sitemap.xml"
 	req2, _ := http.NewRequestWithContext(ctx, "GET", sitemapURL, nil)
-	resp2, err := client.Do(req2)
+	resp2, err := doWithRetry(ctx, client, req2, progress)
 	if err == nil {
 		defer resp2.Body.Close()
 		if resp2.StatusCode == 200 {
@@ -294,19 +490,16 @@ func fetchRobotsSitemap(ctx context.Context, scanID int64, target string) ([]dat
 
 // --- Metadata Extractor ---
 
-func extractMetadata(ctx context.Context, scanID int64, target string) ([]database.Result, error) {
+func extractMetadata(ctx context.Context, client *http.Client, scanID int64, target string, interestingHeaders []string, evidence bool, progress func(string)) ([]database.Result, error) {
 	if !strings.HasPrefix(target, "http") {
 		target = "https://" + target
 	}
 
-	client := &http.Client{
-		Timeout: 20 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			if len(via) >= 10 {
-				return fmt.Errorf("too many redirects")
-			}
-			return nil
-		},
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("too many redirects")
+		}
+		return nil
 	}
 
 	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
@@ -315,7 +508,7 @@ func extractMetadata(ctx context.Context, scanID int64, target string) ([]databa
 	}
 	req.Header.Set("User-Agent", "RaccoonRecon/1.0 (Metadata Extractor)")
 
-	resp, err := client.Do(req)
+	resp, err := doWithRetry(ctx, client, req, progress)
 	if err != nil {
 		return nil, fmt.Errorf("fetch URL: %w", err)
 	}
@@ -336,14 +529,6 @@ func extractMetadata(ctx context.Context, scanID int64, target string) ([]databa
 	})
 
 	// Interesting response headers
-	interestingHeaders := []string{
-		"Server", "X-Powered-By", "Content-Type",
-		"X-Frame-Options", "X-Content-Type-Options",
-		"Strict-Transport-Security", "Content-Security-Policy",
-		"X-XSS-Protection", "Access-Control-Allow-Origin",
-		"Via", "X-Cache", "X-AspNet-Version", "X-Generator",
-	}
-
 	for _, hdr := range interestingHeaders {
 		if val := resp.Header.Get(hdr); val != "" {
 			results = append(results, database.Result{
@@ -353,13 +538,25 @@ func extractMetadata(ctx context.Context, scanID int64, target string) ([]databa
 		}
 	}
 
+	// Server-side software named by the headers above
+	results = append(results, headerTechnologies(scanID, resp.Header)...)
+
+	// Cookie attributes
+	results = append(results, analyzeCookies(scanID, resp, evidence)...)
+
 	// Read body (limit 2MB)
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 2*1024*1024))
 	if err != nil {
 		return results, nil
 	}
 
-	htmlStr := string(body)
+	htmlStr, encoding := decodeHTML(body, resp.Header.Get("Content-Type"))
+	if encoding != "utf-8" {
+		results = append(results, database.Result{
+			ScanID: scanID, ResultType: "metadata",
+			Key: "charset", Value: encoding,
+		})
+	}
 
 	// Extract <title>
 	if title := extractHTMLTag(htmlStr, "title"); title != "" {
@@ -399,6 +596,12 @@ func extractMetadata(ctx context.Context, scanID int64, target string) ([]databa
 		})
 	}
 
+	// Login forms, CSRF tokens and SSO links
+	results = append(results, detectAuthPortals(scanID, resp.Request.URL, htmlStr)...)
+
+	// Auto-generated directory indexes
+	results = append(results, detectDirListing(scanID, resp, body, evidence)...)
+
 	return results, nil
 }
 
@@ -0,0 +1,25 @@
+package scanner
+
+import "testing"
+
+func TestSplitHostPortDefault(t *testing.T) {
+	tests := []struct {
+		target, host, port string
+	}{
+		{"example.com", "example.com", "443"},
+		{"example.com:8443", "example.com", "8443"},
+		{"192.0.2.1", "192.0.2.1", "443"},
+		{"192.0.2.1:8443", "192.0.2.1", "8443"},
+		{"2001:db8::1", "2001:db8::1", "443"},
+		{"[2001:db8::1]", "2001:db8::1", "443"},
+		{"[2001:db8::1]:8443", "2001:db8::1", "8443"},
+		{"::1", "::1", "443"},
+		{"[::1]:993", "::1", "993"},
+	}
+	for _, tt := range tests {
+		host, port := splitHostPortDefault(tt.target, "443")
+		if host != tt.host || port != tt.port {
+			t.Errorf("splitHostPortDefault(%q) = %q, %q; want %q, %q", tt.target, host, port, tt.host, tt.port)
+		}
+	}
+}
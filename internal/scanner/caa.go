@@ -0,0 +1,322 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// typeCAA is the CAA record type (RFC 8659); dnsmessage has no constant for it.
+const typeCAA dnsmessage.Type = 257
+
+const dnsQueryTimeout = 5 * time.Second
+
+// caaRecord is one CAA resource record.
+type caaRecord struct {
+	flags uint8
+	tag   string
+	value string
+}
+
+// --- CAA Check ---
+
+// checkCAA finds the CAA record set that governs target, climbing from the
+// name through each parent up to the TLD as RFC 8659 describes, and reports each
+// issue/issuewild/iodef entry plus a summary of which CAs may issue. server is
+// network.dns_resolver; empty uses the first nameserver in /etc/resolv.conf.
+func checkCAA(ctx context.Context, dialer *net.Dialer, server string, scanID int64, target string, progress func(string)) ([]database.Result, error) {
+	domain := caaDomain(target)
+	if domain == "" {
+		return nil, fmt.Errorf("no domain given")
+	}
+	server = dnsServer(server)
+
+	var records []caaRecord
+	found := ""
+	for name := domain; name != ""; _, name, _ = strings.Cut(name, ".") {
+		rrs, err := lookupCAA(ctx, dialer, server, name)
+		if err != nil {
+			return nil, fmt.Errorf("CAA lookup for %s: %w", name, err)
+		}
+		if len(rrs) > 0 {
+			records, found = rrs, name
+			break
+		}
+		progress("No CAA records at " + name)
+	}
+
+	if len(records) == 0 {
+		return []database.Result{{
+			ScanID:     scanID,
+			ResultType: "caa",
+			Key:        "policy",
+			Value:      "no CAA records: any CA may issue certificates for " + domain,
+			Details:    detailsJSON(caaDetails{Severity: "info", Domain: domain}),
+		}}, nil
+	}
+
+	var results []database.Result
+	var issuers, wildIssuers []string
+	issueSet, wildSet := false, false
+	for _, rr := range records {
+		d := caaDetails{Severity: "info", Domain: found, Flags: rr.flags, Critical: rr.flags&0x80 != 0}
+		switch strings.ToLower(rr.tag) {
+		case "issue":
+			issueSet = true
+			if ca := caaIssuer(rr.value); ca != "" {
+				issuers = append(issuers, ca)
+			}
+		case "issuewild":
+			wildSet = true
+			if ca := caaIssuer(rr.value); ca != "" {
+				wildIssuers = append(wildIssuers, ca)
+			}
+		case "iodef":
+		default:
+			if d.Critical {
+				d.Note = "unknown critical tag: CAs must refuse to issue"
+			}
+		}
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "caa",
+			Key:        strings.ToLower(rr.tag),
+			Value:      rr.value,
+			Details:    detailsJSON(d),
+		})
+	}
+
+	// issuewild falls back to the issue entries when absent
+	if !wildSet {
+		wildIssuers, wildSet = issuers, issueSet
+	}
+	value := "issue: " + caaPolicy(issuers, issueSet) + "; issuewild: " + caaPolicy(wildIssuers, wildSet)
+	if found != domain {
+		value += " (inherited from " + found + ")"
+	}
+	results = append(results, database.Result{
+		ScanID:     scanID,
+		ResultType: "caa",
+		Key:        "policy",
+		Value:      value,
+		Details:    detailsJSON(caaDetails{Severity: "info", Domain: found}),
+	})
+	return results, nil
+}
+
+// caaDomain takes the hostname out of a bare domain or URL target.
+func caaDomain(target string) string {
+	target = strings.TrimSpace(target)
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil {
+			target = u.Hostname()
+		}
+	}
+	return strings.ToLower(strings.TrimSuffix(target, "."))
+}
+
+// caaIssuer returns the CA domain of an issue/issuewild value, dropping any
+// parameters ("letsencrypt.org; validationmethods=dns-01"). An empty result
+// (value ";") forbids issuance.
+func caaIssuer(value string) string {
+	ca, _, _ := strings.Cut(value, ";")
+	return strings.TrimSpace(ca)
+}
+
+func caaPolicy(issuers []string, set bool) string {
+	switch {
+	case !set:
+		return "any CA"
+	case len(issuers) == 0:
+		return "no CA"
+	}
+	return strings.Join(issuers, ", ")
+}
+
+// dnsServer resolves the DNS server to query directly: addr as given (port 53
+// by default), else the first nameserver in /etc/resolv.conf.
+func dnsServer(addr string) string {
+	if addr == "" {
+		addr = "127.0.0.1"
+		if f, err := os.Open("/etc/resolv.conf"); err == nil {
+			defer f.Close()
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				if fields := strings.Fields(sc.Text()); len(fields) >= 2 && fields[0] == "nameserver" {
+					addr = fields[1]
+					break
+				}
+			}
+		}
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "53")
+	}
+	return addr
+}
+
+// lookupCAA queries server for name's CAA records, over UDP and again over TCP
+// if the answer was truncated. NXDOMAIN and an empty answer both return none.
+func lookupCAA(ctx context.Context, dialer *net.Dialer, server, name string) ([]caaRecord, error) {
+	query, id, err := caaQuery(name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := dnsExchange(ctx, dialer, "udp", server, query)
+	if err != nil {
+		return nil, err
+	}
+	records, truncated, err := parseCAAResponse(resp, id)
+	if err == nil && truncated {
+		if resp, err = dnsExchange(ctx, dialer, "tcp", server, query); err != nil {
+			return nil, err
+		}
+		records, _, err = parseCAAResponse(resp, id)
+	}
+	return records, err
+}
+
+func caaQuery(name string) ([]byte, uint16, error) {
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	var idBuf [2]byte
+	rand.Read(idBuf[:])
+	id := binary.BigEndian.Uint16(idBuf[:])
+
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, 0, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: qname, Type: typeCAA, Class: dnsmessage.ClassINET}); err != nil {
+		return nil, 0, err
+	}
+	// EDNS0 so larger answers fit in one UDP response
+	if err := b.StartAdditionals(); err != nil {
+		return nil, 0, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(4096, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, 0, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, 0, err
+	}
+	msg, err := b.Finish()
+	return msg, id, err
+}
+
+// dnsExchange sends one query and reads the reply. TCP messages carry a
+// two-byte length prefix.
+func dnsExchange(ctx context.Context, dialer *net.Dialer, network, server string, query []byte) ([]byte, error) {
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(dnsQueryTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if network == "udp" {
+		if _, err := conn.Write(query); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, 4096)
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	}
+
+	msg := binary.BigEndian.AppendUint16(nil, uint16(len(query)))
+	if _, err := conn.Write(append(msg, query...)); err != nil {
+		return nil, err
+	}
+	var size [2]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, binary.BigEndian.Uint16(size[:]))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// parseCAAResponse reads the CAA records from a reply. Answers for a CNAME
+// target come back in the same section and count as the name's own, as RFC
+// 8659 requires.
+func parseCAAResponse(msg []byte, id uint16) ([]caaRecord, bool, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return nil, false, err
+	}
+	if h.ID != id {
+		return nil, false, errors.New("mismatched DNS response ID")
+	}
+	if h.Truncated {
+		return nil, true, nil
+	}
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, false, nil
+	default:
+		return nil, false, fmt.Errorf("DNS error %s", h.RCode)
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, false, err
+	}
+
+	var records []caaRecord
+	for {
+		rh, err := p.AnswerHeader()
+		if err == dnsmessage.ErrSectionDone {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		if rh.Type != typeCAA {
+			if err := p.SkipAnswer(); err != nil {
+				return nil, false, err
+			}
+			continue
+		}
+		r, err := p.UnknownResource()
+		if err != nil {
+			return nil, false, err
+		}
+		// flags (1) | tag length (1) | tag | value
+		if len(r.Data) < 2 || len(r.Data) < 2+int(r.Data[1]) {
+			continue
+		}
+		tagEnd := 2 + int(r.Data[1])
+		records = append(records, caaRecord{
+			flags: r.Data[0],
+			tag:   string(r.Data[2:tagEnd]),
+			value: string(r.Data[tagEnd:]),
+		})
+	}
+	return records, false, nil
+}
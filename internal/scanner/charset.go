@@ -0,0 +1,28 @@
+package scanner
+
+import (
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// decodeHTML returns an HTML body as UTF-8 text for the tag parsers, with
+// the name of the encoding it was decoded from. The encoding comes from a
+// byte order mark, the Content-Type charset, or a <meta charset> /
+// <meta http-equiv="Content-Type"> tag in the first 1024 bytes, names
+// mapped as browsers do (ISO-8859-1 is read as windows-1252). A body that
+// declares nothing, or only in a meta tag, but is valid UTF-8 is kept as
+// it is, since charset's fallback guess is windows-1252 and a mislabelled
+// page is more likely UTF-8 than not. A body that fails to decode is also
+// returned unchanged.
+func decodeHTML(body []byte, contentType string) (text, encoding string) {
+	enc, name, certain := charset.DetermineEncoding(body, contentType)
+	if name == "utf-8" || (!certain && utf8.Valid(body)) {
+		return string(body), "utf-8"
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return string(body), "utf-8"
+	}
+	return string(decoded), name
+}
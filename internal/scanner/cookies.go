@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// sessionCookieRe matches names frameworks give to session and auth cookies,
+// whose missing protections matter most.
+var sessionCookieRe = regexp.MustCompile(`(?i)(sess|sid$|^sid|auth|token|jwt|login|remember|^connect\.sid$|^phpsessid$|^jsessionid$|^asp\.net_sessionid$|^laravel_session$)`)
+
+// --- Cookie Security ---
+
+// analyzeCookies reports each cookie set by resp with its Secure, HttpOnly
+// and SameSite attributes. Missing protections are rated medium on session
+// cookies and low otherwise; cookies with all three are info. With evidence
+// set, cookies missing a protection carry the exchange that set them.
+func analyzeCookies(scanID int64, resp *http.Response, evidence bool) []database.Result {
+	https := resp.Request != nil && resp.Request.URL.Scheme == "https"
+
+	var results []database.Result
+	for _, c := range resp.Cookies() {
+		session := sessionCookieRe.MatchString(c.Name)
+		d := cookieDetails{
+			Severity: "info",
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: cookieSameSite(c.SameSite),
+			Session:  session,
+		}
+
+		var missing []string
+		if !c.Secure && https {
+			missing = append(missing, "Secure")
+		}
+		if !c.HttpOnly {
+			missing = append(missing, "HttpOnly")
+		}
+		if d.SameSite == "" {
+			missing = append(missing, "SameSite")
+		} else if d.SameSite == "None" && !c.Secure && !https {
+			// browsers reject SameSite=None without Secure
+			missing = append(missing, "Secure (required by SameSite=None)")
+		}
+
+		var present []string
+		if c.Secure {
+			present = append(present, "Secure")
+		}
+		if c.HttpOnly {
+			present = append(present, "HttpOnly")
+		}
+		if d.SameSite != "" {
+			present = append(present, "SameSite="+d.SameSite)
+		}
+		value := strings.Join(present, "; ")
+		if len(missing) > 0 {
+			d.Missing = missing
+			d.Severity = "low"
+			if session && (!c.HttpOnly || !c.Secure && https) {
+				d.Severity = "medium"
+			}
+			value = "missing " + strings.Join(missing, ", ")
+			d.Exchange = captureExchange(evidence, resp, nil)
+		}
+
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "cookie",
+			Key:        c.Name,
+			Value:      value,
+			Details:    detailsJSON(d),
+		})
+	}
+	return results
+}
+
+func cookieSameSite(s http.SameSite) string {
+	switch s {
+	case http.SameSiteLaxMode:
+		return "Lax"
+	case http.SameSiteStrictMode:
+		return "Strict"
+	case http.SameSiteNoneMode:
+		return "None"
+	}
+	return ""
+}
@@ -0,0 +1,439 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const (
+	// defaultCTDays is the "recent issuance" window when the days parameter
+	// is unset.
+	defaultCTDays = 30
+	maxCTDays     = 3650
+
+	// maxCTSubdomains caps the subdomain results from one scan; busy domains
+	// have tens of thousands of logged names.
+	maxCTSubdomains = 5000
+	// maxCTResponse caps how much of a crt.sh answer is read.
+	maxCTResponse = 64 * 1024 * 1024
+)
+
+// crtshURL is the crt.sh search endpoint; q is an identity, % a wildcard.
+const crtshURL = "https://crt.sh/"
+
+// ctEntry is one certificate as crt.sh's JSON output lists it. A precertificate
+// and its final certificate are separate entries with the same serial.
+type ctEntry struct {
+	ID           int64  `json:"id"`
+	IssuerName   string `json:"issuer_name"`
+	CommonName   string `json:"common_name"`
+	NameValue    string `json:"name_value"`
+	SerialNumber string `json:"serial_number"`
+	NotBefore    string `json:"not_before"`
+	NotAfter     string `json:"not_after"`
+}
+
+// ctCert is a certificate after precertificate duplicates are merged.
+type ctCert struct {
+	id        int64
+	issuer    string
+	names     []string
+	notBefore time.Time
+	notAfter  time.Time
+}
+
+// ctDays reads ct_logs' days parameter, the window for recent issuance.
+func ctDays(param string) (int, error) {
+	if param == "" {
+		return defaultCTDays, nil
+	}
+	n, err := strconv.Atoi(param)
+	if err != nil || n < 1 || n > maxCTDays {
+		return 0, fmt.Errorf("days must be between 1 and %d", maxCTDays)
+	}
+	return n, nil
+}
+
+// ctResume reads ct_logs' resume parameter: "true" continues from the
+// previous scan's cursor, "" or "false" starts over.
+func ctResume(param string) (bool, error) {
+	switch param {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("resume must be true or false")
+}
+
+// ctCursor is where a resumed ct_logs scan picks up: the last subdomain the
+// previous scan listed when it hit maxCTSubdomains, and the names earlier
+// scans of the target already reported.
+type ctCursor struct {
+	after    string
+	reported map[string]bool
+}
+
+// loadCTCursor builds the cursor for a resumed scan from the project's earlier
+// ct_logs scans of the same target: the newest completed one's summary
+// holds where it stopped, and all of them their subdomains. Scans outside a
+// project have nothing to resume from.
+func (e *Executor) loadCTCursor(scan *database.Scan) (ctCursor, error) {
+	cur := ctCursor{reported: make(map[string]bool)}
+	if scan.ProjectID == 0 {
+		return cur, nil
+	}
+	scans, err := e.db.ListScansByProject(scan.ProjectID)
+	if err != nil {
+		return cur, err
+	}
+	haveCursor := false
+	for _, s := range scans { // newest first
+		if s.ID == scan.ID || s.Tool != "ct_logs" || s.Target != scan.Target || s.Status != "completed" {
+			continue
+		}
+		results, err := e.db.GetResultsByScan(s.ID)
+		if err != nil {
+			return cur, err
+		}
+		for _, r := range results {
+			switch {
+			case r.ResultType != "ct":
+			case r.Key == "subdomain":
+				cur.reported[r.Value] = true
+			case r.Key == "summary" && !haveCursor:
+				var d ctDetails
+				json.Unmarshal([]byte(r.Details), &d)
+				cur.after = d.Cursor
+				haveCursor = true
+			}
+		}
+	}
+	return cur, nil
+}
+
+// --- Certificate Transparency ---
+
+// analyzeCTLogs looks up the certificates logged for a domain and its
+// subdomains on crt.sh and reports the names they cover, the CAs that issued
+// them, the first and latest issuance, and every certificate issued in the
+// last days days, so unexpected issuance stands out. Subdomains already
+// reported are left out; listing resumes at cursor.after.
+func analyzeCTLogs(ctx context.Context, client *http.Client, scanID int64, target string, days int, cursor ctCursor, progress func(string)) ([]database.Result, error) {
+	domain := caaDomain(target)
+	if domain == "" {
+		return nil, fmt.Errorf("no domain given")
+	}
+
+	var entries []ctEntry
+	var lastErr error
+	for _, q := range []string{domain, "%." + domain} {
+		progress("Querying crt.sh for " + q)
+		got, err := queryCrtSh(ctx, client, q, progress)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			progress(fmt.Sprintf("crt.sh query %s: %v", q, err))
+			lastErr = err
+			continue
+		}
+		entries = append(entries, got...)
+	}
+	if len(entries) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("crt.sh: %w", lastErr)
+	}
+
+	certs := mergeCTEntries(entries)
+	progress(fmt.Sprintf("%d logged entries, %d distinct certificates", len(entries), len(certs)))
+	if cursor.after != "" || len(cursor.reported) > 0 {
+		progress(fmt.Sprintf("Resuming after %q, skipping %d subdomain(s) already reported", cursor.after, len(cursor.reported)))
+	}
+	return ctResults(scanID, domain, certs, days, cursor, time.Now()), nil
+}
+
+// queryCrtSh fetches crt.sh's JSON listing for one identity.
+func queryCrtSh(ctx context.Context, client *http.Client, q string, progress func(string)) ([]ctEntry, error) {
+	u := crtshURL + "?" + url.Values{"q": {q}, "output": {"json"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doWithRetry(ctx, client, req, progress)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxCTResponse))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var entries []ctEntry
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return entries, nil
+}
+
+// mergeCTEntries folds entries sharing an issuer and serial (precertificate
+// and certificate, or the same certificate found by both queries) into one.
+func mergeCTEntries(entries []ctEntry) []ctCert {
+	index := make(map[string]int)
+	var certs []ctCert
+	for _, e := range entries {
+		key := e.IssuerName + "|" + e.SerialNumber
+		if e.SerialNumber == "" {
+			key = strconv.FormatInt(e.ID, 10)
+		}
+		names := ctNames(e)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(certs)
+			certs = append(certs, ctCert{
+				id:        e.ID,
+				issuer:    ctIssuerName(e.IssuerName),
+				notBefore: parseCTTime(e.NotBefore),
+				notAfter:  parseCTTime(e.NotAfter),
+				names:     names,
+			})
+			continue
+		}
+		for _, n := range names {
+			if !slices.Contains(certs[i].names, n) {
+				certs[i].names = append(certs[i].names, n)
+			}
+		}
+	}
+	return certs
+}
+
+// ctNames lists the names a certificate covers: the newline-separated
+// name_value, plus the common name.
+func ctNames(e ctEntry) []string {
+	var names []string
+	for _, n := range append(strings.Split(e.NameValue, "\n"), e.CommonName) {
+		n = strings.ToLower(strings.TrimSpace(n))
+		if n != "" && !slices.Contains(names, n) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// parseCTTime reads crt.sh's timestamps ("2024-01-02T03:04:05", sometimes
+// with fractional seconds), which are UTC.
+func parseCTTime(s string) time.Time {
+	t, err := time.Parse("2006-01-02T15:04:05.999999999", s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// ctIssuerName shortens an issuer DN ("C=US, O=Let's Encrypt, CN=R3") to its
+// organization and common name ("Let's Encrypt (R3)").
+func ctIssuerName(dn string) string {
+	attrs := parseDN(dn)
+	org, cn := attrs["O"], attrs["CN"]
+	switch {
+	case org != "" && cn != "" && org != cn:
+		return org + " (" + cn + ")"
+	case org != "":
+		return org
+	case cn != "":
+		return cn
+	}
+	return dn
+}
+
+// parseDN splits a comma-separated distinguished name into attributes,
+// honoring double-quoted values such as O="DigiCert, Inc.".
+func parseDN(dn string) map[string]string {
+	attrs := make(map[string]string)
+	var part strings.Builder
+	quoted := false
+	flush := func() {
+		if k, v, ok := strings.Cut(part.String(), "="); ok {
+			attrs[strings.TrimSpace(k)] = strings.Trim(strings.TrimSpace(v), `"`)
+		}
+		part.Reset()
+	}
+	for _, c := range dn {
+		switch {
+		case c == '"':
+			quoted = !quoted
+			part.WriteRune(c)
+		case c == ',' && !quoted:
+			flush()
+		default:
+			part.WriteRune(c)
+		}
+	}
+	flush()
+	return attrs
+}
+
+// ctResults turns the certificates into "ct" results: one per name under the
+// domain, one per issuing CA, the first and latest issuance, each certificate
+// issued within the last days days, and a summary. Names already reported
+// are skipped and the rest listed in order from cursor.after, wrapping round
+// to new names that sort before it, up to maxCTSubdomains; the summary's
+// Cursor then holds the last one listed.
+func ctResults(scanID int64, domain string, certs []ctCert, days int, cursor ctCursor, now time.Time) []database.Result {
+	var results []database.Result
+	if len(certs) == 0 {
+		return []database.Result{{
+			ScanID:     scanID,
+			ResultType: "ct",
+			Key:        "summary",
+			Value:      "no certificates logged for " + domain,
+			Details:    detailsJSON(ctDetails{Severity: "info", Domain: domain}),
+		}}
+	}
+
+	// oldest first, so first/latest and the recent list read in order
+	sort.SliceStable(certs, func(i, j int) bool { return certs[i].notBefore.Before(certs[j].notBefore) })
+
+	// subdomains
+	seen := make(map[string]bool)
+	var names []string
+	for _, c := range certs {
+		for _, n := range c.names {
+			n = strings.TrimPrefix(n, "*.")
+			if (n == domain || strings.HasSuffix(n, "."+domain)) && !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+	sort.Strings(names)
+	names = slices.DeleteFunc(names, func(n string) bool { return cursor.reported[n] })
+	// carry on through the range the last run was cut short in, then list
+	// names that are new since, wherever they sort
+	if cursor.after != "" {
+		i := sort.Search(len(names), func(i int) bool { return names[i] > cursor.after })
+		names = append(slices.Clone(names[i:]), names[:i]...)
+	}
+	next, remaining := "", 0
+	if len(names) > maxCTSubdomains {
+		remaining = len(names) - maxCTSubdomains
+		names = names[:maxCTSubdomains]
+		next = names[len(names)-1]
+	}
+	for _, n := range names {
+		results = append(results, database.Result{ScanID: scanID, ResultType: "ct", Key: "subdomain", Value: n})
+	}
+
+	// issuing CAs, most certificates first
+	type issuerStats struct {
+		name        string
+		count       int
+		first, last time.Time
+	}
+	byIssuer := make(map[string]*issuerStats)
+	var issuers []*issuerStats
+	for _, c := range certs {
+		st := byIssuer[c.issuer]
+		if st == nil {
+			st = &issuerStats{name: c.issuer, first: c.notBefore}
+			byIssuer[c.issuer] = st
+			issuers = append(issuers, st)
+		}
+		st.count++
+		st.last = c.notBefore
+	}
+	sort.SliceStable(issuers, func(i, j int) bool { return issuers[i].count > issuers[j].count })
+	for _, st := range issuers {
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "ct",
+			Key:        "issuer",
+			Value:      fmt.Sprintf("%s: %d certificate(s), %s to %s", st.name, st.count, ctDate(st.first), ctDate(st.last)),
+			Details: detailsJSON(ctDetails{
+				Severity: "info", Domain: domain, Issuer: st.name, Count: st.count,
+				FirstSeen: ctDate(st.first), LastSeen: ctDate(st.last),
+			}),
+		})
+	}
+
+	first, latest := certs[0], certs[len(certs)-1]
+	results = append(results,
+		ctCertResult(scanID, domain, "first_certificate", first),
+		ctCertResult(scanID, domain, "latest_certificate", latest),
+	)
+
+	cutoff := now.AddDate(0, 0, -days)
+	recent := 0
+	for _, c := range certs {
+		if c.notBefore.After(cutoff) {
+			recent++
+			results = append(results, ctCertResult(scanID, domain, "recent_certificate", c))
+		}
+	}
+
+	summary := fmt.Sprintf("%d certificate(s) from %d CA(s) covering %d name(s); %d issued in the last %d days",
+		len(certs), len(issuers), len(seen), recent, days)
+	if remaining > 0 {
+		summary += fmt.Sprintf("; %d more subdomain(s) after %s, rescan with resume to continue", remaining, next)
+	}
+	results = append(results, database.Result{
+		ScanID:     scanID,
+		ResultType: "ct",
+		Key:        "summary",
+		Value:      summary,
+		Details: detailsJSON(ctDetails{
+			Severity: "info", Domain: domain, Count: len(certs),
+			FirstSeen: ctDate(first.notBefore), LastSeen: ctDate(latest.notBefore),
+			Cursor: next, Remaining: remaining,
+		}),
+	})
+	return results
+}
+
+// ctCertResult describes one certificate: its issue date, issuer and names.
+func ctCertResult(scanID int64, domain, key string, c ctCert) database.Result {
+	names := c.names
+	if len(names) > 5 {
+		names = append(names[:5:5], fmt.Sprintf("+%d more", len(c.names)-5))
+	}
+	return database.Result{
+		ScanID:     scanID,
+		ResultType: "ct",
+		Key:        key,
+		Value:      fmt.Sprintf("%s %s — %s", ctDate(c.notBefore), c.issuer, strings.Join(names, ", ")),
+		Details: detailsJSON(ctDetails{
+			Severity:  "info",
+			Domain:    domain,
+			Issuer:    c.issuer,
+			Names:     c.names,
+			NotBefore: ctDate(c.notBefore),
+			NotAfter:  ctDate(c.notAfter),
+			URL:       fmt.Sprintf("%s?id=%d", crtshURL, c.id),
+		}),
+	}
+}
+
+func ctDate(t time.Time) string {
+	if t.IsZero() {
+		return "unknown"
+	}
+	return t.Format("2006-01-02")
+}
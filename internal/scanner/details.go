@@ -0,0 +1,240 @@
+package scanner
+
+import "encoding/json"
+
+// Typed schemas for database.Result.Details, one per result_type that carries
+// structured context. Always serialize through detailsJSON so Details is valid
+// JSON regardless of what the values contain.
+
+// dnsDetails accompanies "dns" results.
+type dnsDetails struct {
+	Name  string `json:"name"`
+	TTL   string `json:"ttl"`
+	Class string `json:"class"`
+	// Category says what a TXT record is for: verification, spf, dkim,
+	// dmarc or misc. Service names the third party where known.
+	Category string `json:"category,omitempty"`
+	Service  string `json:"service,omitempty"`
+}
+
+// portDetails accompanies "port" results.
+type portDetails struct {
+	Host    string `json:"host"`
+	Service string `json:"service"`
+	Reason  string `json:"reason"`
+}
+
+// osDetails accompanies "os" results.
+type osDetails struct {
+	Accuracy string `json:"accuracy"`
+	Host     string `json:"host"`
+}
+
+// dorkDetails accompanies "google_dork" results.
+type dorkDetails struct {
+	Query string `json:"query"`
+}
+
+// jsLibraryDetails accompanies "js_library" results.
+type jsLibraryDetails struct {
+	Source   string   `json:"source"`
+	Outdated bool     `json:"outdated"`
+	CVEs     []string `json:"cves,omitempty"`
+}
+
+// technologyDetails accompanies "technology" results. Source is the header
+// ("header:server") or tool ("whatweb") the product was identified from.
+type technologyDetails struct {
+	Product string `json:"product"`
+	Version string `json:"version,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Source  string `json:"source"`
+}
+
+// takeoverDetails accompanies "takeover" results.
+type takeoverDetails struct {
+	Severity string        `json:"severity"`
+	Service  string        `json:"service"`
+	CNAME    string        `json:"cname"`
+	Evidence string        `json:"evidence"`
+	Exchange *httpExchange `json:"exchange,omitempty"`
+}
+
+// snmpDetails accompanies "snmp" results.
+type snmpDetails struct {
+	OID       string `json:"oid"`
+	Type      string `json:"type,omitempty"`
+	HighValue bool   `json:"high_value,omitempty"`
+}
+
+// authPortalDetails accompanies "auth_portal" results.
+type authPortalDetails struct {
+	Indicator string `json:"indicator"` // password_field, login_action, csrf_token or oauth_link
+	Method    string `json:"method,omitempty"`
+	CSRFField string `json:"csrf_field,omitempty"`
+	Form      string `json:"form,omitempty"`
+	Provider  string `json:"provider,omitempty"`
+}
+
+// dirListingDetails accompanies "dir_listing" results.
+type dirListingDetails struct {
+	Severity string        `json:"severity"`
+	Server   string        `json:"server"` // listing format: Apache, nginx, IIS or generic
+	Path     string        `json:"path"`
+	Entries  []string      `json:"entries"`
+	More     int           `json:"more,omitempty"` // entries listed beyond those kept
+	Exchange *httpExchange `json:"exchange,omitempty"`
+}
+
+// cookieDetails accompanies "cookie" results.
+type cookieDetails struct {
+	Severity string        `json:"severity"`
+	Secure   bool          `json:"secure"`
+	HttpOnly bool          `json:"http_only"`
+	SameSite string        `json:"same_site,omitempty"`
+	Session  bool          `json:"session"`
+	Missing  []string      `json:"missing,omitempty"`
+	Exchange *httpExchange `json:"exchange,omitempty"`
+}
+
+// clickjackingDetails accompanies "clickjacking" results. Scope is how widely
+// the page may be framed: none, same-origin, allowlist or any.
+type clickjackingDetails struct {
+	Severity       string        `json:"severity"`
+	Framable       bool          `json:"framable"`
+	Scope          string        `json:"scope"`
+	Protection     string        `json:"protection"`
+	XFrameOptions  string        `json:"x_frame_options,omitempty"`
+	FrameAncestors []string      `json:"frame_ancestors,omitempty"`
+	Notes          []string      `json:"notes,omitempty"`
+	Exchange       *httpExchange `json:"exchange,omitempty"`
+}
+
+// httpMethodDetails accompanies "http_method" results. Allowed is set on the
+// OPTIONS result; the others describe one probe. Responded means the server
+// answered with something other than 405 or 501, Enabled that it answered 2xx.
+type httpMethodDetails struct {
+	Severity   string        `json:"severity"`
+	Method     string        `json:"method"`
+	Status     int           `json:"status"`
+	Allowed    []string      `json:"allowed,omitempty"`
+	Advertised bool          `json:"advertised,omitempty"`
+	Responded  bool          `json:"responded,omitempty"`
+	Enabled    bool          `json:"enabled,omitempty"`
+	Reflected  bool          `json:"reflected,omitempty"`
+	Exchange   *httpExchange `json:"exchange,omitempty"`
+}
+
+// disallowedLiveDetails accompanies "disallowed_live" results.
+type disallowedLiveDetails struct {
+	Severity    string        `json:"severity"`
+	URL         string        `json:"url"`
+	Status      int           `json:"status"`
+	ContentType string        `json:"content_type,omitempty"`
+	Length      int           `json:"length"`
+	Title       string        `json:"title,omitempty"`
+	Exchange    *httpExchange `json:"exchange,omitempty"`
+}
+
+// wellKnownDetails accompanies "well_known" results for resources found.
+type wellKnownDetails struct {
+	Exchange *httpExchange `json:"exchange,omitempty"`
+}
+
+// sslStrengthDetails accompanies the "public_key" and "signature_algorithm"
+// "ssl" results. Note says why a weak choice was flagged.
+type sslStrengthDetails struct {
+	Severity string `json:"severity"`
+	Note     string `json:"note,omitempty"`
+}
+
+// caaDetails accompanies "caa" results. Domain is the name the governing
+// record set was found at, which may be a parent of the target.
+type caaDetails struct {
+	Severity string `json:"severity"`
+	Domain   string `json:"domain"`
+	Flags    uint8  `json:"flags,omitempty"`
+	Critical bool   `json:"critical,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// ctDetails accompanies "ct" results. Issuer and Count describe an issuing
+// CA or the whole set; Names through URL describe one certificate. Cursor and
+// Remaining, on the summary, say where a resumed scan continues when the
+// subdomain list was cut short.
+type ctDetails struct {
+	Severity  string   `json:"severity"`
+	Domain    string   `json:"domain"`
+	Issuer    string   `json:"issuer,omitempty"`
+	Count     int      `json:"count,omitempty"`
+	FirstSeen string   `json:"first_seen,omitempty"`
+	LastSeen  string   `json:"last_seen,omitempty"`
+	Names     []string `json:"names,omitempty"`
+	NotBefore string   `json:"not_before,omitempty"`
+	NotAfter  string   `json:"not_after,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Cursor    string   `json:"cursor,omitempty"`
+	Remaining int      `json:"remaining,omitempty"`
+}
+
+// timingDetails accompanies each hop's "timing" result. Phases that did not
+// happen (TLS on plain HTTP, DNS for an IP literal) are 0.
+type timingDetails struct {
+	Hop        int     `json:"hop"`
+	URL        string  `json:"url"`
+	Status     int     `json:"status"`
+	Protocol   string  `json:"protocol"`
+	RemoteAddr string  `json:"remote_addr,omitempty"`
+	TLSVersion string  `json:"tls_version,omitempty"`
+	Reused     bool    `json:"reused,omitempty"`
+	DNSMs      float64 `json:"dns_ms"`
+	ConnectMs  float64 `json:"connect_ms"`
+	TLSMs      float64 `json:"tls_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`
+	TotalMs    float64 `json:"total_ms"`
+	Bytes      int64   `json:"bytes"`
+	Location   string  `json:"location,omitempty"`
+	CDN        string  `json:"cdn,omitempty"`
+}
+
+// timingChainDetails accompanies the "timing" result summing up a chain.
+type timingChainDetails struct {
+	Hops     int      `json:"hops"`
+	TotalMs  float64  `json:"total_ms"`
+	FinalURL string   `json:"final_url"`
+	CDNs     []string `json:"cdns"`
+	Stopped  string   `json:"stopped,omitempty"`
+}
+
+// summaryDetails accompanies the "summary" result of a builtin scan that
+// found nothing.
+type summaryDetails struct {
+	Checked string `json:"checked"`
+}
+
+// resolutionDetails accompanies "resolution" results.
+type resolutionDetails struct {
+	Addresses []string `json:"addresses"`
+}
+
+// markPartial adds "partial": true to a Details document, for results parsed
+// from the output of a scan that was cancelled or timed out.
+func markPartial(details string) string {
+	m := map[string]any{}
+	if details != "" {
+		if err := json.Unmarshal([]byte(details), &m); err != nil {
+			m = map[string]any{"raw": details}
+		}
+	}
+	m["partial"] = true
+	return detailsJSON(m)
+}
+
+// detailsJSON marshals a details struct, returning "" if that somehow fails.
+func detailsJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
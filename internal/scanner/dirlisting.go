@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// maxListingEntries caps the names kept from one directory listing.
+const maxListingEntries = 200
+
+var (
+	// listingDateRe matches the modification dates Apache and nginx print
+	// beside each entry: "2024-01-31 12:00" or "31-Jan-2024 12:00".
+	listingDateRe = regexp.MustCompile(`\b(?:\d{4}-\d{2}-\d{2}|\d{2}-[A-Za-z]{3}-\d{4}) \d{2}:\d{2}\b`)
+
+	// iisListingRowRe matches an IIS listing line: date, time, then a size
+	// or <dir>, then the entry's link.
+	iisListingRowRe = regexp.MustCompile(`(?i)\d{1,2}/\d{1,2}/\d{4}\s+\d{1,2}:\d{2}\s*(?:AM|PM)?\s+(?:&lt;dir&gt;|\d+)\s*<a\b`)
+
+	// iisListingTitleRe matches IIS's "host - /path/" page title.
+	iisListingTitleRe = regexp.MustCompile(`^\S+ - (/.*)$`)
+)
+
+// --- Directory Listing Detection ---
+
+// detectDirListing reports a page that is a web server's auto-generated
+// directory index rather than content, in the formats of Apache ("Index of"
+// title, Parent Directory link, Name/Last modified/Size columns), nginx
+// ("Index of" title, ../ link and dated rows in a <pre>) and IIS ("host -
+// /path/" title, [To Parent Directory] link, <dir>/size rows). The names
+// listed are kept in Details.
+func detectDirListing(scanID int64, resp *http.Response, body []byte, evidence bool) []database.Result {
+	html := string(body)
+	title := strings.TrimSpace(extractHTMLTag(html, "title"))
+	lower := strings.ToLower(html)
+
+	var server, dir string
+	switch {
+	case strings.HasPrefix(title, "Index of "):
+		dir = strings.TrimPrefix(title, "Index of ")
+		switch {
+		case strings.Contains(lower, ">parent directory<") || strings.Contains(lower, "> parent directory<") ||
+			strings.Contains(lower, ">last modified<"):
+			server = "Apache"
+		case strings.Contains(lower, "<pre>") && strings.Contains(lower, `href="../"`):
+			server = "nginx"
+		case listingDateRe.MatchString(html):
+			server = "generic"
+		default:
+			return nil
+		}
+	case iisListingTitleRe.MatchString(title) &&
+		(strings.Contains(lower, "[to parent directory]") || iisListingRowRe.MatchString(html)):
+		server = "IIS"
+		dir = iisListingTitleRe.FindStringSubmatch(title)[1]
+	default:
+		return nil
+	}
+
+	page := resp.Request.URL
+	entries, more := listingEntries(page, html)
+	value := fmt.Sprintf("directory listing of %s (%s), %d entries", dir, server, len(entries)+more)
+	return []database.Result{{
+		ScanID:     scanID,
+		ResultType: "dir_listing",
+		Key:        page.String(),
+		Value:      value,
+		Details: detailsJSON(dirListingDetails{
+			Severity: "medium",
+			Server:   server,
+			Path:     dir,
+			Entries:  entries,
+			More:     more,
+			Exchange: captureExchange(evidence, resp, evidenceWindow(body, title)),
+		}),
+	}}
+}
+
+// listingEntries collects the names of the files and directories a listing
+// links to directly beneath page, skipping parent, sort and external links.
+// Directories keep their trailing slash. Names past maxListingEntries are
+// only counted.
+func listingEntries(page *url.URL, html string) (entries []string, more int) {
+	dir := page.Path
+	if !strings.HasSuffix(dir, "/") {
+		dir += "/"
+	}
+	seen := make(map[string]bool)
+	for _, a := range htmlAnchorRe.FindAllString(html, -1) {
+		href := htmlAttr(a, "href")
+		if href == "" || strings.HasPrefix(href, "?") || strings.HasPrefix(href, "#") {
+			continue
+		}
+		u, err := page.Parse(href)
+		if err != nil || u.Host != page.Host {
+			continue
+		}
+		name, ok := strings.CutPrefix(u.Path, dir)
+		if !ok || name == "" || strings.Contains(strings.TrimSuffix(name, "/"), "/") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if len(entries) == maxListingEntries {
+			more++
+			continue
+		}
+		entries = append(entries, name)
+	}
+	return entries, more
+}
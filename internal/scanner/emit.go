@@ -0,0 +1,115 @@
+package scanner
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+	"github.com/jamesruggles/reconsuite/internal/tools"
+)
+
+const (
+	// resultBatchSize and resultFlushInterval bound how long an emitted
+	// result waits before it is written: whichever comes first.
+	resultBatchSize     = 25
+	resultFlushInterval = time.Second
+)
+
+// resultSink receives a builtin's results as they are found. Each result is
+// broadcast immediately and persisted in small batches, so the scan's results
+// fill in while it runs and a cancellation keeps what was already found.
+// Builtins may emit from several goroutines.
+type resultSink struct {
+	e      *Executor
+	scanID int64
+
+	mu      sync.Mutex
+	pending []database.Result
+	stored  []database.Result
+	timer   *time.Timer
+	closed  bool
+}
+
+func (e *Executor) newResultSink(scanID int64) *resultSink {
+	return &resultSink{e: e, scanID: scanID}
+}
+
+// emitResult broadcasts r and queues it for storage.
+func (s *resultSink) emitResult(r database.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.e.broadcaster.Broadcast(s.scanID, tools.OutputLine{
+		Timestamp: time.Now(), Stream: "stdout", Line: r.Key + ": " + r.Value,
+	})
+	s.pending = append(s.pending, r)
+	switch {
+	case len(s.pending) >= resultBatchSize:
+		s.flushLocked()
+	case s.timer == nil:
+		s.timer = time.AfterFunc(resultFlushInterval, s.flush)
+	}
+}
+
+// count returns how many results have been emitted so far, stored or not.
+func (s *resultSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.stored) + len(s.pending)
+}
+
+// emitResults emits each of results in order.
+func (s *resultSink) emitResults(results []database.Result) {
+	for _, r := range results {
+		s.emitResult(r)
+	}
+}
+
+func (s *resultSink) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+}
+
+// flushLocked writes the pending batch and tells subscribers how many results
+// are now stored, so open result views can refresh.
+func (s *resultSink) flushLocked() {
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	if len(s.pending) == 0 {
+		return
+	}
+	batch := s.pending
+	s.pending = nil
+	if err := s.e.db.CreateResults(batch); err != nil {
+		slog.Error("store results failed", "scan_id", s.scanID, "error", err)
+		return
+	}
+	s.stored = append(s.stored, batch...)
+	s.e.broadcaster.Broadcast(s.scanID, tools.OutputLine{Timestamp: time.Now(), Results: len(s.stored)})
+}
+
+// close writes anything still pending and stops accepting results. With
+// partial set, every result the scan stored is tagged "partial": true, as
+// storePartialResults does for tool scans. It returns the number stored.
+func (s *resultSink) close(partial bool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.flushLocked()
+	s.closed = true
+
+	if partial && len(s.stored) > 0 {
+		for i := range s.stored {
+			s.stored[i].Details = markPartial(s.stored[i].Details)
+		}
+		if err := s.e.db.UpdateResultDetails(s.stored); err != nil {
+			slog.Error("mark partial results failed", "scan_id", s.scanID, "error", err)
+		}
+	}
+	return len(s.stored)
+}
@@ -0,0 +1,241 @@
+package scanner
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// Entity is one domain or IP address with what every scan of a project
+// learned about it.
+type Entity struct {
+	Kind         string             `json:"kind"` // "domain" or "ip"
+	Name         string             `json:"name"`
+	Addresses    []string           `json:"addresses,omitempty"` // IPs a domain resolved to
+	Hostnames    []string           `json:"hostnames,omitempty"` // names seen pointing at an IP
+	Ports        []EntityPort       `json:"ports,omitempty"`
+	Technologies []EntityTechnology `json:"technologies,omitempty"`
+	TLS          map[string]string  `json:"tls,omitempty"` // ssl_check results by key
+	OS           []string           `json:"os,omitempty"`
+	DNS          []EntityRecord     `json:"dns,omitempty"`
+	Scans        []int64            `json:"scans"`
+	ResultCounts map[string]int     `json:"result_counts"`
+}
+
+// EntityPort is a port nmap reported on an entity; the latest scan wins.
+type EntityPort struct {
+	Port    string `json:"port"` // "443/tcp"
+	State   string `json:"state"`
+	Service string `json:"service,omitempty"`
+	ScanID  int64  `json:"scan_id"`
+}
+
+// EntityTechnology is a product identified on an entity.
+type EntityTechnology struct {
+	Product string `json:"product"`
+	Version string `json:"version,omitempty"`
+}
+
+// EntityRecord is a DNS record published for an entity.
+type EntityRecord struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// correlator builds entities keyed by lower-cased name.
+type correlator struct {
+	byName map[string]*Entity
+}
+
+func (c *correlator) entity(name string) *Entity {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return nil
+	}
+	e := c.byName[name]
+	if e == nil {
+		kind := "domain"
+		if net.ParseIP(name) != nil {
+			kind = "ip"
+		}
+		e = &Entity{Kind: kind, Name: name, Scans: []int64{}, ResultCounts: make(map[string]int)}
+		c.byName[name] = e
+	}
+	return e
+}
+
+// link records that domain resolved to ip, on both entities.
+func (c *correlator) link(domain, ip *Entity) {
+	if domain == nil || ip == nil || domain == ip || domain.Kind != "domain" || ip.Kind != "ip" {
+		return
+	}
+	domain.Addresses = appendUnique(domain.Addresses, ip.Name)
+	ip.Hostnames = appendUnique(ip.Hostnames, domain.Name)
+}
+
+// CorrelateEntities folds a project's results into one record per domain and
+// IP. Results are attributed to the host they name where they carry one (a
+// port's address, a resolution's hostname, a DNS record's owner) and to their
+// scan's target otherwise; resolutions and A/AAAA records link domains to
+// IPs. Results whose scan target isn't a single host, such as a CIDR range,
+// only count where they name one.
+func CorrelateEntities(scans []database.Scan, results []database.Result) []Entity {
+	c := &correlator{byName: make(map[string]*Entity)}
+	targets := make(map[int64]string, len(scans))
+	for _, s := range scans {
+		targets[s.ID] = entityName(s.Target)
+	}
+
+	for _, r := range results {
+		target := c.entity(targets[r.ScanID])
+		e := target
+
+		switch r.ResultType {
+		case "resolution":
+			var d resolutionDetails
+			json.Unmarshal([]byte(r.Details), &d)
+			e = c.entity(r.Key)
+			for _, addr := range d.Addresses {
+				c.link(e, c.entity(addr))
+			}
+
+		case "port", "os":
+			var d struct {
+				Host    string `json:"host"`
+				Service string `json:"service"`
+			}
+			json.Unmarshal([]byte(r.Details), &d)
+			if host := c.entity(d.Host); host != nil {
+				e = host
+				c.link(target, host)
+			}
+			if e == nil {
+				continue
+			}
+			if r.ResultType == "os" {
+				e.OS = appendUnique(e.OS, r.Value)
+				break
+			}
+			port := EntityPort{Port: r.Key, State: r.Value, Service: d.Service, ScanID: r.ScanID}
+			if i := slices.IndexFunc(e.Ports, func(p EntityPort) bool { return p.Port == r.Key }); i >= 0 {
+				e.Ports[i] = port
+			} else {
+				e.Ports = append(e.Ports, port)
+			}
+
+		case "dns":
+			var d dnsDetails
+			json.Unmarshal([]byte(r.Details), &d)
+			if owner := c.entity(d.Name); owner != nil {
+				e = owner
+			}
+			if e == nil {
+				continue
+			}
+			rec := EntityRecord{Type: r.Key, Value: r.Value}
+			if !slices.Contains(e.DNS, rec) {
+				e.DNS = append(e.DNS, rec)
+			}
+			if r.Key == "A" || r.Key == "AAAA" {
+				c.link(e, c.entity(r.Value))
+			}
+
+		case "technology":
+			if e == nil {
+				continue
+			}
+			var d technologyDetails
+			json.Unmarshal([]byte(r.Details), &d)
+			if d.Product == "" {
+				d.Product = r.Key
+			}
+			tech := EntityTechnology{Product: d.Product, Version: d.Version}
+			if !slices.Contains(e.Technologies, tech) {
+				e.Technologies = append(e.Technologies, tech)
+			}
+
+		case "ssl":
+			if e == nil {
+				continue
+			}
+			if e.TLS == nil {
+				e.TLS = make(map[string]string)
+			}
+			e.TLS[r.Key] = r.Value
+
+		case "ct":
+			// every logged subdomain is part of the attack surface
+			if r.Key == "subdomain" {
+				e = c.entity(r.Value)
+			}
+		}
+
+		if e == nil {
+			continue
+		}
+		e.ResultCounts[r.ResultType]++
+		if !slices.Contains(e.Scans, r.ScanID) {
+			e.Scans = append(e.Scans, r.ScanID)
+		}
+	}
+
+	entities := make([]Entity, 0, len(c.byName))
+	for _, e := range c.byName {
+		sort.Strings(e.Addresses)
+		sort.Strings(e.Hostnames)
+		sort.Slice(e.Ports, func(i, j int) bool { return portLess(e.Ports[i].Port, e.Ports[j].Port) })
+		slices.Sort(e.Scans)
+		entities = append(entities, *e)
+	}
+	// domains first, then IPs, each by name
+	sort.Slice(entities, func(i, j int) bool {
+		if entities[i].Kind != entities[j].Kind {
+			return entities[i].Kind == "domain"
+		}
+		return entities[i].Name < entities[j].Name
+	})
+	return entities
+}
+
+// entityName is the domain or IP a scan target refers to, or "" when it
+// names neither (a CIDR range or a host list).
+func entityName(target string) string {
+	if host := targetHostname(target); host != "" {
+		return host
+	}
+	host := strings.TrimSpace(target)
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(strings.Trim(host, "[]")); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// portLess orders "port/proto" keys numerically, then by protocol.
+func portLess(a, b string) bool {
+	an, ap, _ := strings.Cut(a, "/")
+	bn, bp, _ := strings.Cut(b, "/")
+	if len(an) != len(bn) {
+		return len(an) < len(bn)
+	}
+	if an != bn {
+		return an < bn
+	}
+	return ap < bp
+}
+
+func appendUnique(list []string, s string) []string {
+	if s == "" || slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
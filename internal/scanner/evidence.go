@@ -0,0 +1,95 @@
+package scanner
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxEvidenceSize bounds each side of a captured HTTP exchange, so evidence
+// stays a snippet rather than a copy of the page.
+const maxEvidenceSize = 4 * 1024
+
+// httpExchange is the request and response behind a finding, kept in the
+// result's Details under "exchange" for the report's evidence appendix.
+type httpExchange struct {
+	Request   string `json:"request"`
+	Response  string `json:"response"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// captureExchange renders the final request of resp and the response's
+// status line and headers, followed by body when given. It returns nil when
+// capture is disabled.
+func captureExchange(enabled bool, resp *http.Response, body []byte) *httpExchange {
+	if !enabled || resp == nil {
+		return nil
+	}
+
+	var req strings.Builder
+	if r := resp.Request; r != nil {
+		fmt.Fprintf(&req, "%s %s %s\n", r.Method, r.URL.RequestURI(), r.Proto)
+		fmt.Fprintf(&req, "Host: %s\n", r.URL.Host)
+		writeHeaders(&req, r.Header)
+	}
+
+	var res strings.Builder
+	fmt.Fprintf(&res, "%s %s\n", resp.Proto, resp.Status)
+	writeHeaders(&res, resp.Header)
+	if len(body) > 0 {
+		res.WriteString("\n")
+		res.Write(body)
+	}
+
+	x := &httpExchange{}
+	var cut1, cut2 bool
+	x.Request, cut1 = truncateEvidence(req.String())
+	x.Response, cut2 = truncateEvidence(res.String())
+	x.Truncated = cut1 || cut2
+	return x
+}
+
+// writeHeaders writes headers in sorted order, one "Name: value" per line,
+// masking credentials.
+func writeHeaders(b *strings.Builder, h http.Header) {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, v := range h[name] {
+			if name == "Authorization" || name == "Proxy-Authorization" {
+				v = redactedSecret
+			}
+			fmt.Fprintf(b, "%s: %s\n", name, v)
+		}
+	}
+}
+
+// truncateEvidence cuts s to maxEvidenceSize without splitting a UTF-8
+// sequence, reporting whether anything was dropped.
+func truncateEvidence(s string) (string, bool) {
+	if len(s) <= maxEvidenceSize {
+		return s, false
+	}
+	cut := maxEvidenceSize
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
+
+// evidenceWindow returns up to maxEvidenceSize/2 bytes of body around the
+// first occurrence of marker, so the snippet shows what matched.
+func evidenceWindow(body []byte, marker string) []byte {
+	i := strings.Index(string(body), marker)
+	if i < 0 {
+		i = 0
+	}
+	start := max(0, i-256)
+	end := min(len(body), start+maxEvidenceSize/2)
+	return body[start:end]
+}
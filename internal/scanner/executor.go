@@ -3,12 +3,19 @@ package scanner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/jamesruggles/reconsuite/internal/config"
 	"github.com/jamesruggles/reconsuite/internal/database"
 	"github.com/jamesruggles/reconsuite/internal/tools"
 )
@@ -22,45 +29,335 @@ type Broadcaster interface {
 type Executor struct {
 	db          *database.DB
 	broadcaster Broadcaster
-	mu          sync.Mutex
-	cancels     map[int64]context.CancelFunc
+	cfg         *config.Config
+	resolver    *net.Resolver
+	dialer      *net.Dialer
+	transport   *http.Transport
+
+	mu               sync.Mutex
+	cancels          map[int64]context.CancelFunc
+	queue            []queuedScan
+	running          int
+	runningByProject map[int64]int
+}
+
+// queuedScan is a scan waiting for a free slot under the concurrency limits.
+type queuedScan struct {
+	ctx  context.Context
+	scan *database.Scan
+	auth *basicAuth
 }
 
-func NewExecutor(db *database.DB, broadcaster Broadcaster) *Executor {
+func NewExecutor(db *database.DB, broadcaster Broadcaster, cfg *config.Config) *Executor {
+	resolver := NewResolver(cfg.Network.DNSResolver)
+	dialer := newDialer(resolver, cfg.Network.SourceIP)
 	return &Executor{
 		db:          db,
 		broadcaster: broadcaster,
-		cancels:     make(map[int64]context.CancelFunc),
+		cfg:         cfg,
+		resolver:    resolver,
+		dialer:      dialer,
+		transport:   NewHTTPTransport(dialer),
+
+		cancels:          make(map[int64]context.CancelFunc),
+		runningByProject: make(map[int64]int),
 	}
 }
 
+// ErrUnknownTool is wrapped by the rejection of a scan naming a tool the
+// executor doesn't know.
+var ErrUnknownTool = errors.New("unknown tool")
+
+// RejectedError reports a scan that cannot start because of the request
+// itself (invalid target, unknown tool, missing binary) rather than an
+// internal failure.
+type RejectedError struct {
+	Err error
+}
+
+func (e *RejectedError) Error() string { return e.Err.Error() }
+func (e *RejectedError) Unwrap() error { return e.Err }
+
+// ScanPlan describes what a scan would do without running it.
+type ScanPlan struct {
+	Tool       string   `json:"tool"`
+	Name       string   `json:"name"`
+	Builtin    bool     `json:"builtin"`
+	Target     string   `json:"target"`
+	Binary     string   `json:"binary,omitempty"`
+	BinaryPath string   `json:"binary_path,omitempty"`
+	Args       []string `json:"args,omitempty"`
+	Command    string   `json:"command,omitempty"`
+	TimeoutSec int      `json:"timeout_sec,omitempty"`
+
+	Concurrency int `json:"concurrency,omitempty"` // worker count, for concurrent builtins
+}
+
+// PlanScan normalizes and validates a scan and resolves the command it would
+// run. Beyond normalizing the scan's target it has no side effects, so it
+// backs both dry runs and StartScan's pre-flight check.
+func (e *Executor) PlanScan(scan *database.Scan) (*ScanPlan, error) {
+	// normalized first, so validation and the planned command see the
+	// target as it will be stored and run
+	normalizeScanTarget(scan)
+	auth, err := scanBasicAuth(scan)
+	if err != nil {
+		return nil, &RejectedError{Err: err}
+	}
+	spec, err := e.buildToolSpec(scan, auth)
+	if err != nil {
+		return nil, &RejectedError{Err: err}
+	}
+	plan := &ScanPlan{
+		Tool:       scan.Tool,
+		Name:       spec.Name,
+		Builtin:    builtinTools[scan.Tool],
+		Target:     scan.Target,
+		TimeoutSec: int(spec.Timeout.Seconds()),
+	}
+	if plan.Builtin {
+		if concurrentBuiltins[scan.Tool] {
+			if plan.Concurrency, err = e.builtinConcurrency(scan); err != nil {
+				return nil, &RejectedError{Err: err}
+			}
+		}
+		return plan, nil
+	}
+
+	path, err := tools.CheckInstalled(spec.BinaryName)
+	if err != nil {
+		return nil, &RejectedError{Err: err}
+	}
+	plan.Binary = spec.BinaryName
+	plan.BinaryPath = path
+	plan.Args = auth.redactArgs(spec.Args)
+	plan.Command = shellJoin(append([]string{spec.BinaryName}, plan.Args...))
+	return plan, nil
+}
+
+// shellJoin renders argv as a copy-pasteable shell command line.
+func shellJoin(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, arg := range argv {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`&|;<>()*?[]{}!#~") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
 // StartScan creates a scan record and begins execution in a goroutine.
 func (e *Executor) StartScan(scan *database.Scan) error {
+	// Pre-flight so a bad target or missing binary fails the request up front
+	// instead of producing a scan that instantly fails.
+	if _, err := e.PlanScan(scan); err != nil {
+		return err
+	}
+	auth, _ := scanBasicAuth(scan) // validated by PlanScan
+
 	scan.Status = "pending"
 	if scan.Parameters == "" {
 		scan.Parameters = "{}"
 	}
+	stripBasicAuthPass(scan)
 	if err := e.db.CreateScan(scan); err != nil {
 		return fmt.Errorf("create scan: %w", err)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.cancels[scan.ID] = cancel
-	e.mu.Unlock()
 
-	go e.runScan(ctx, scan)
+	q := queuedScan{ctx: ctx, scan: scan, auth: auth}
+	if e.canStartLocked(scan.ProjectID) {
+		e.launchLocked(q)
+		return nil
+	}
+
+	// At a concurrency limit: wait for a slot rather than run now
+	e.queue = append(e.queue, q)
+	scan.Status = "queued"
+	scan.QueuePosition = len(e.queue)
+	ahead := len(e.queue) - 1
+	scan.QueuedAhead = &ahead
+	e.db.UpdateScanStatus(scan.ID, "queued")
 	return nil
 }
 
-// CancelScan cancels a running scan.
+// originalTargetParam is the parameter keeping a scan's target as submitted.
+const originalTargetParam = "original_target"
+
+// normalizeScanTarget stores the scan's target in canonical form, so scans of
+// the same host or URL correlate however it was typed. When that changes
+// more than surrounding whitespace, the target as submitted is kept in the
+// original_target parameter.
+func normalizeScanTarget(scan *database.Scan) {
+	submitted := strings.TrimSpace(scan.Target)
+	scan.Target = tools.NormalizeTarget(submitted)
+	if scan.Target == submitted {
+		return
+	}
+	var params map[string]any
+	if json.Unmarshal([]byte(scan.Parameters), &params) != nil || params == nil {
+		params = make(map[string]any)
+	}
+	params[originalTargetParam] = submitted
+	if data, err := json.Marshal(params); err == nil {
+		scan.Parameters = string(data)
+	}
+}
+
+// CancelScan cancels a running scan or removes it from the queue.
 func (e *Executor) CancelScan(scanID int64) {
 	e.mu.Lock()
 	cancel, ok := e.cancels[scanID]
+	dequeued := e.removeQueuedLocked(scanID)
+	if dequeued {
+		delete(e.cancels, scanID)
+	}
 	e.mu.Unlock()
+
 	if ok {
 		cancel()
 	}
+	if dequeued {
+		e.db.UpdateScanStatus(scanID, "cancelled")
+		e.broadcaster.Broadcast(scanID, tools.OutputLine{
+			Timestamp: time.Now(), Stream: "stderr", Line: "Scan cancelled",
+		})
+		e.broadcaster.Broadcast(scanID, tools.OutputLine{Done: true, Timestamp: time.Now()})
+	}
+}
+
+// QueuePosition returns a queued scan's 1-based position, or 0 if the scan is
+// not waiting in the queue.
+func (e *Executor) QueuePosition(scanID int64) int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, q := range e.queue {
+		if q.scan.ID == scanID {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// AnnotateScan fills in the live status of a scan read from the database:
+// its place in the queue while queued, and how long it has been running.
+func (e *Executor) AnnotateScan(scan *database.Scan) {
+	if pos := e.QueuePosition(scan.ID); pos > 0 {
+		ahead := pos - 1
+		scan.QueuePosition, scan.QueuedAhead = pos, &ahead
+	}
+	if scan.Status == "running" && scan.StartedAt != nil {
+		scan.ElapsedSeconds = int64(time.Since(*scan.StartedAt).Seconds())
+	}
+}
+
+// canStartLocked reports whether a scan for projectID fits under both the
+// global and per-project concurrency limits. Quick scans (no project) are only
+// subject to the global limit.
+func (e *Executor) canStartLocked(projectID int64) bool {
+	if max := e.cfg.Scans.MaxConcurrent; max > 0 && e.running >= max {
+		return false
+	}
+	if max := e.cfg.Scans.MaxConcurrentPerProject; max > 0 && projectID != 0 && e.runningByProject[projectID] >= max {
+		return false
+	}
+	return true
+}
+
+func (e *Executor) launchLocked(q queuedScan) {
+	e.running++
+	e.runningByProject[q.scan.ProjectID]++
+	go e.runScan(q.ctx, q.scan, q.auth)
+}
+
+// dispatchLocked starts every queued scan that now fits, in FIFO order. A
+// project at its limit does not hold up other projects queued behind it.
+func (e *Executor) dispatchLocked() {
+	remaining := e.queue[:0]
+	for _, q := range e.queue {
+		if e.canStartLocked(q.scan.ProjectID) {
+			e.launchLocked(q)
+		} else {
+			remaining = append(remaining, q)
+		}
+	}
+	e.queue = remaining
+}
+
+func (e *Executor) removeQueuedLocked(scanID int64) bool {
+	for i, q := range e.queue {
+		if q.scan.ID == scanID {
+			e.queue = append(e.queue[:i], e.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// finishScan releases a scan's concurrency slot and starts queued work.
+func (e *Executor) finishScan(scan *database.Scan) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.cancels, scan.ID)
+	e.running--
+	e.runningByProject[scan.ProjectID]--
+	if e.runningByProject[scan.ProjectID] <= 0 {
+		delete(e.runningByProject, scan.ProjectID)
+	}
+	e.dispatchLocked()
+}
+
+// pruneHistory applies scans.history_limit once scan has completed, so
+// targets scanned over and over keep only their most recent runs.
+func (e *Executor) pruneHistory(scan *database.Scan) {
+	keep := e.cfg.Scans.HistoryLimit
+	if keep <= 0 {
+		return
+	}
+	s, err := e.db.GetScan(scan.ID)
+	if err != nil || s == nil || s.Status != "completed" {
+		return
+	}
+	n, err := e.db.PruneScanHistory(scan.ProjectID, scan.Tool, scan.Target, keep)
+	if err != nil {
+		slog.Error("prune scan history failed", "scan_id", scan.ID, "error", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("pruned scan history", "scan_id", scan.ID, "tool", scan.Tool, "target", scan.Target, "removed", n)
+	}
+}
+
+// storeRawOutput saves a scan's raw output in the database, or in a file
+// under scans.raw_output_dir when it is larger than
+// scans.raw_output_file_threshold. If the file can't be written the output is
+// kept inline.
+func (e *Executor) storeRawOutput(scanID int64, output string) {
+	limit := e.cfg.Scans.RawOutputFileThreshold
+	if limit <= 0 || int64(len(output)) <= limit {
+		e.db.UpdateScanRawOutput(scanID, output)
+		return
+	}
+	dir := e.cfg.Scans.RawOutputDir
+	path := filepath.Join(dir, fmt.Sprintf("scan-%d.log", scanID))
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		err = os.WriteFile(path, []byte(output), 0644)
+	}
+	if err != nil {
+		slog.Error("write raw output file failed, storing inline", "scan_id", scanID, "error", err)
+		e.db.UpdateScanRawOutput(scanID, output)
+		return
+	}
+	if err := e.db.UpdateScanRawOutputFile(scanID, path); err != nil {
+		slog.Error("record raw output file failed", "scan_id", scanID, "error", err)
+	}
 }
 
 var builtinTools = map[string]bool{
@@ -69,22 +366,62 @@ var builtinTools = map[string]bool{
 	"ssl_check":        true,
 	"robots_sitemap":   true,
 	"metadata_extract": true,
+	"js_fingerprint":   true,
+	"well_known":       true,
+	"takeover_check":   true,
+	"framing_check":    true,
+	"http_methods":     true,
+	"http_trace":       true,
+	"caa_check":        true,
+	"ct_logs":          true,
 }
 
-func (e *Executor) runScan(ctx context.Context, scan *database.Scan) {
-	defer func() {
-		e.mu.Lock()
-		delete(e.cancels, scan.ID)
-		e.mu.Unlock()
-	}()
+// concurrentBuiltins probe several hosts in parallel and honor
+// tools.builtin_concurrency and the per-scan "concurrency" parameter.
+var concurrentBuiltins = map[string]bool{
+	"takeover_check": true,
+	"robots_sitemap": true,
+}
+
+// maxBuiltinConcurrency caps the per-scan "concurrency" parameter.
+const maxBuiltinConcurrency = 64
+
+// builtinConcurrency returns the worker count for a concurrent builtin: the
+// scan's "concurrency" parameter if set, otherwise the configured value.
+func (e *Executor) builtinConcurrency(scan *database.Scan) (int, error) {
+	v := scanParam(scan, "concurrency")
+	if v == "" {
+		return e.cfg.BuiltinConcurrency(scan.Tool), nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxBuiltinConcurrency {
+		return 0, fmt.Errorf("concurrency must be between 1 and %d", maxBuiltinConcurrency)
+	}
+	return n, nil
+}
+
+// scanParam returns one of the scan's string parameters, or "" if unset.
+func scanParam(scan *database.Scan, name string) string {
+	var params map[string]string
+	if scan.Parameters != "" && scan.Parameters != "{}" {
+		json.Unmarshal([]byte(scan.Parameters), &params)
+	}
+	return params[name]
+}
+
+func (e *Executor) runScan(ctx context.Context, scan *database.Scan, auth *basicAuth) {
+	defer e.finishScan(scan)
+	defer e.pruneHistory(scan)
+
+	e.recordResolution(ctx, scan)
 
 	// Route built-in tools to their own handler
 	if builtinTools[scan.Tool] {
-		e.runBuiltinScan(ctx, scan)
+		e.runBuiltinScan(ctx, scan, auth)
 		return
 	}
 
-	spec, err := e.buildToolSpec(scan)
+	spec, err := e.buildToolSpec(scan, auth)
 	if err != nil {
 		slog.Error("build tool spec failed", "scan_id", scan.ID, "error", err)
 		e.db.UpdateScanStatus(scan.ID, "failed")
@@ -118,15 +455,27 @@ func (e *Executor) runScan(ctx context.Context, scan *database.Scan) {
 	wg.Wait()
 
 	// Store raw output
-	e.db.UpdateScanRawOutput(scan.ID, rawOutput.String())
+	e.storeRawOutput(scan.ID, rawOutput.String())
 
 	if result.Error != nil && ctx.Err() != nil {
-		e.db.UpdateScanStatus(scan.ID, "failed")
+		e.storePartialResults(scan.ID, e.parseResults(scan, result))
+		e.db.UpdateScanStatus(scan.ID, "cancelled")
 		e.broadcaster.Broadcast(scan.ID, tools.OutputLine{
 			Timestamp: time.Now(), Stream: "stderr", Line: "Scan cancelled",
 		})
+	} else if errors.Is(result.Error, context.DeadlineExceeded) {
+		e.storePartialResults(scan.ID, e.parseResults(scan, result))
+		e.db.UpdateScanStatus(scan.ID, "timed_out")
+		e.broadcaster.Broadcast(scan.ID, tools.OutputLine{
+			Timestamp: time.Now(), Stream: "stderr", Line: fmt.Sprintf("Scan timed out after %s", spec.Timeout),
+		})
 	} else if result.Error != nil {
 		e.db.UpdateScanStatus(scan.ID, "failed")
+		if hint := privilegeHint(result.Stderr); hint != "" {
+			e.broadcaster.Broadcast(scan.ID, tools.OutputLine{
+				Timestamp: time.Now(), Stream: "stderr", Line: hint,
+			})
+		}
 	} else {
 		// Parse results
 		results := e.parseResults(scan, result)
@@ -141,7 +490,63 @@ func (e *Executor) runScan(ctx context.Context, scan *database.Scan) {
 	e.broadcaster.Broadcast(scan.ID, tools.OutputLine{Done: true, Timestamp: time.Now()})
 }
 
-func (e *Executor) buildToolSpec(scan *database.Scan) (tools.ToolSpec, error) {
+// storePartialResults keeps what could be parsed from a scan that was stopped
+// early, tagging each result with "partial": true in Details. The catch-all
+// "raw" result is dropped since the scan's raw output is already saved.
+func (e *Executor) storePartialResults(scanID int64, parsed []database.Result) {
+	var results []database.Result
+	for _, r := range parsed {
+		if r.ResultType == "raw" {
+			continue
+		}
+		r.Details = markPartial(r.Details)
+		results = append(results, r)
+	}
+	if len(results) == 0 {
+		return
+	}
+	if err := e.db.CreateResults(results); err != nil {
+		slog.Error("store partial results failed", "scan_id", scanID, "error", err)
+		return
+	}
+	e.broadcastLines(scanID, fmt.Sprintf("Kept %d partial result(s) parsed before the scan stopped", len(results)))
+}
+
+// recordResolution stores what the scan target resolved to before any tool
+// touches it.
+func (e *Executor) recordResolution(ctx context.Context, scan *database.Scan) {
+	r := resolveTarget(ctx, e.resolver, scan.ID, scan.Target)
+	if r == nil {
+		return
+	}
+	if err := e.db.CreateResult(r); err != nil {
+		slog.Error("store resolution failed", "scan_id", scan.ID, "error", err)
+		return
+	}
+	e.broadcastLines(scan.ID, "Resolved "+r.Key+" to "+r.Value)
+}
+
+// privilegeHint turns a tool's "needs root" failure into an actionable message.
+func privilegeHint(stderr string) string {
+	lower := strings.ToLower(stderr)
+	if strings.Contains(lower, "requires root privileges") || strings.Contains(lower, "operation not permitted") {
+		return "Hint: this scan needs raw-socket privileges. Run as root, grant the tool CAP_NET_RAW, or pick a scan type that doesn't need them (e.g. TCP connect)."
+	}
+	return ""
+}
+
+// buildToolSpec builds the spec for a scan's tool and gives external tools
+// the environment configured for them in tools.env.
+func (e *Executor) buildToolSpec(scan *database.Scan, auth *basicAuth) (tools.ToolSpec, error) {
+	spec, err := e.toolSpec(scan, auth)
+	if err != nil || spec.BinaryName == "__builtin__" {
+		return spec, err
+	}
+	spec.Env = e.cfg.ToolEnv(scan.Tool)
+	return spec, nil
+}
+
+func (e *Executor) toolSpec(scan *database.Scan, auth *basicAuth) (tools.ToolSpec, error) {
 	var params map[string]string
 	if scan.Parameters != "" && scan.Parameters != "{}" {
 		json.Unmarshal([]byte(scan.Parameters), &params)
@@ -149,6 +554,10 @@ func (e *Executor) buildToolSpec(scan *database.Scan) (tools.ToolSpec, error) {
 	if params == nil {
 		params = make(map[string]string)
 	}
+	extra, err := tools.ParseExtraArgs(scan.Tool, params["extra_args"])
+	if err != nil {
+		return tools.ToolSpec{}, err
+	}
 
 	switch scan.Tool {
 	case "whois":
@@ -160,7 +569,7 @@ func (e *Executor) buildToolSpec(scan *database.Scan) (tools.ToolSpec, error) {
 	case "dnsrecon":
 		return buildDnsReconSpec(scan.Target, params["scan_mode"])
 	case "nmap":
-		return buildNmapSpec(scan.Target, params)
+		return buildNmapSpec(scan.Target, params, e.cfg.NmapPrivileged(), extra)
 	case "traceroute":
 		return buildTracerouteSpec(scan.Target)
 	case "snmpwalk":
@@ -168,23 +577,56 @@ func (e *Executor) buildToolSpec(scan *database.Scan) (tools.ToolSpec, error) {
 	case "netcat":
 		return buildNetcatSpec(scan.Target, params["port"])
 	case "curl":
-		return buildCurlSpec(scan.Target)
+		return buildCurlSpec(scan.Target, auth, extra)
 	case "whatweb":
-		return buildWhatWebSpec(scan.Target, params["aggression"])
+		return buildWhatWebSpec(scan.Target, params["aggression"], auth, extra)
 	case "gobuster":
-		return buildGobusterSpec(scan.Target, params["wordlist"], params["extensions"])
+		return buildGobusterSpec(scan.Target, params["wordlist"], params["extensions"], auth, extra)
 	case "google_dorking":
 		return tools.ToolSpec{Name: "Google Dorking", BinaryName: "__builtin__"}, nil
 	case "osint_aggregator":
 		return tools.ToolSpec{Name: "OSINT Aggregator", BinaryName: "__builtin__"}, nil
 	case "ssl_check":
+		if err := validateStartTLS(params["starttls"]); err != nil {
+			return tools.ToolSpec{}, err
+		}
+		if _, port := sslTarget(scan.Target); port != "" {
+			if err := tools.ValidatePort(port); err != nil {
+				return tools.ToolSpec{}, err
+			}
+		}
 		return tools.ToolSpec{Name: "SSL/TLS Check", BinaryName: "__builtin__"}, nil
 	case "robots_sitemap":
+		if _, err := probeDisallowedParam(params["probe_disallowed"]); err != nil {
+			return tools.ToolSpec{}, err
+		}
 		return tools.ToolSpec{Name: "Robots/Sitemap", BinaryName: "__builtin__"}, nil
 	case "metadata_extract":
 		return tools.ToolSpec{Name: "Metadata Extractor", BinaryName: "__builtin__"}, nil
+	case "js_fingerprint":
+		return tools.ToolSpec{Name: "JS Library Fingerprint", BinaryName: "__builtin__"}, nil
+	case "well_known":
+		return tools.ToolSpec{Name: ".well-known Probe", BinaryName: "__builtin__"}, nil
+	case "takeover_check":
+		return tools.ToolSpec{Name: "Subdomain Takeover Check", BinaryName: "__builtin__"}, nil
+	case "framing_check":
+		return tools.ToolSpec{Name: "Clickjacking Check", BinaryName: "__builtin__"}, nil
+	case "http_methods":
+		return tools.ToolSpec{Name: "HTTP Method Enumeration", BinaryName: "__builtin__"}, nil
+	case "http_trace":
+		return tools.ToolSpec{Name: "HTTP Trace", BinaryName: "__builtin__"}, nil
+	case "caa_check":
+		return tools.ToolSpec{Name: "CAA Record Check", BinaryName: "__builtin__"}, nil
+	case "ct_logs":
+		if _, err := ctDays(params["days"]); err != nil {
+			return tools.ToolSpec{}, err
+		}
+		if _, err := ctResume(params["resume"]); err != nil {
+			return tools.ToolSpec{}, err
+		}
+		return tools.ToolSpec{Name: "Certificate Transparency", BinaryName: "__builtin__"}, nil
 	default:
-		return tools.ToolSpec{}, fmt.Errorf("unknown tool: %s", scan.Tool)
+		return tools.ToolSpec{}, fmt.Errorf("%w: %s", ErrUnknownTool, scan.Tool)
 	}
 }
 
@@ -198,16 +640,28 @@ func (e *Executor) parseResults(scan *database.Scan, result *tools.ToolResult) [
 		return parseNmapResults(scan.ID, result.Stdout)
 	case "curl":
 		return parseCurlResults(scan.ID, result.Stdout)
+	case "snmpwalk":
+		return parseSnmpWalkResults(scan.ID, result.Stdout)
+	case "whatweb":
+		if results := parseWhatWebResults(scan.ID, result.Stdout); len(results) > 0 {
+			return results
+		}
+		return rawResults(scan, result.Stdout)
 	default:
 		// For tools without a dedicated parser, store raw output as a single result
-		if result.Stdout != "" {
-			return []database.Result{{
-				ScanID:     scan.ID,
-				ResultType: "raw",
-				Key:        scan.Tool,
-				Value:      result.Stdout,
-			}}
-		}
+		return rawResults(scan, result.Stdout)
+	}
+}
+
+// rawResults stores a tool's output as a single "raw" result.
+func rawResults(scan *database.Scan, stdout string) []database.Result {
+	if stdout == "" {
 		return nil
 	}
+	return []database.Result{{
+		ScanID:     scan.ID,
+		ResultType: "raw",
+		Key:        scan.Tool,
+		Value:      stdout,
+	}}
 }
@@ -2,14 +2,23 @@ package scanner
 
 import (
 	"bytes"
+	"compress/zlib"
 	"encoding/binary"
 	"fmt"
+	"hash/crc32"
 	"image"
 	_ "image/jpeg"
 	_ "image/png"
+	"io"
 	"math"
 	"net/http"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
 // FileMetaResult holds a single extracted metadata key-value pair.
@@ -18,6 +27,15 @@ type FileMetaResult struct {
 	Value string `json:"value"`
 }
 
+// MetadataFileTypes are the detected types ExtractFileMetadata has an
+// extractor for: JPEG, PNG, TIFF and TIFF-based RAW, PDF, and ISO-BMFF
+// movies. Other files only get the generic filename/size/type fields.
+var MetadataFileTypes = []string{
+	"image/jpeg", "image/png", "image/tiff", "image/x-canon-cr2", "image/x-adobe-dng",
+	"application/pdf",
+	"video/mp4", "video/quicktime", "audio/mp4", "video/3gpp", "video/3gpp2",
+}
+
 // ExtractFileMetadata detects the file type and extracts metadata.
 func ExtractFileMetadata(filename string, data []byte) ([]FileMetaResult, error) {
 	if len(data) == 0 {
@@ -25,19 +43,26 @@ func ExtractFileMetadata(filename string, data []byte) ([]FileMetaResult, error)
 	}
 
 	mimeType := http.DetectContentType(data)
+	detectedType := DetectFileType(data)
 	results := []FileMetaResult{
 		{Key: "filename", Value: filename},
 		{Key: "file_size", Value: formatFileSize(len(data))},
 		{Key: "mime_type", Value: mimeType},
+		{Key: "detected_type", Value: detectedType},
 	}
 
 	switch {
-	case strings.HasPrefix(mimeType, "image/jpeg"):
+	case strings.HasPrefix(detectedType, "image/jpeg"):
 		results = append(results, extractJPEGMetadata(data)...)
-	case strings.HasPrefix(mimeType, "image/png"):
+	case strings.HasPrefix(detectedType, "image/png"):
 		results = append(results, extractPNGMetadata(data)...)
-	case mimeType == "application/pdf":
+	case detectedType == "application/pdf":
 		results = append(results, extractPDFMetadata(data)...)
+	case isMP4Type(detectedType):
+		results = append(results, extractMP4Metadata(bytes.NewReader(data), int64(len(data)))...)
+	case isTIFF(data):
+		// TIFF and TIFF-based camera RAW (DNG, NEF, CR2) are EXIF-structured already
+		results = append(results, parseEXIF(data)...)
 	default:
 		// Try to decode as generic image for dimensions
 		if img, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
@@ -51,6 +76,118 @@ func ExtractFileMetadata(filename string, data []byte) ([]FileMetaResult, error)
 	return results, nil
 }
 
+const (
+	// RangedReadThreshold is the file size above which ExtractFileMetadataAt
+	// reads only the ranges a format's metadata lives in.
+	RangedReadThreshold = 8 * 1024 * 1024
+
+	rangedHeadSize = 256 * 1024
+	pdfRangeSize   = 1024 * 1024
+)
+
+// ExtractFileMetadataAt is ExtractFileMetadata over an io.ReaderAt such as a
+// multipart temp file. Small files are buffered whole; for large JPEG, PDF and
+// MP4/QuickTime files only the header, trailer or atoms holding metadata are
+// read. Other large formats fall back to buffering.
+func ExtractFileMetadataAt(filename string, r io.ReaderAt, size int64) ([]FileMetaResult, error) {
+	if size == 0 {
+		return nil, fmt.Errorf("empty file")
+	}
+	if size <= RangedReadThreshold {
+		data, err := readRange(r, 0, size)
+		if err != nil {
+			return nil, err
+		}
+		return ExtractFileMetadata(filename, data)
+	}
+
+	head, err := readRange(r, 0, min(size, rangedHeadSize))
+	if err != nil {
+		return nil, err
+	}
+	detectedType := detectFileType(head, r, size)
+	results := []FileMetaResult{
+		{Key: "filename", Value: filename},
+		{Key: "file_size", Value: formatFileSize(int(size))},
+		{Key: "mime_type", Value: http.DetectContentType(head)},
+		{Key: "detected_type", Value: detectedType},
+	}
+
+	switch {
+	case strings.HasPrefix(detectedType, "image/jpeg"):
+		// EXIF lives in APP1, which must sit near the start of the file
+		results = append(results, extractJPEGMetadata(head)...)
+	case detectedType == "application/pdf":
+		results = append(results, extractPartialPDFMetadata(r, size)...)
+	case isMP4Type(detectedType):
+		results = append(results, extractMP4Metadata(r, size)...)
+	default:
+		data, err := readRange(r, 0, size)
+		if err != nil {
+			return nil, err
+		}
+		return ExtractFileMetadata(filename, data)
+	}
+
+	return results, nil
+}
+
+func readRange(r io.ReaderAt, off, n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	read, err := r.ReadAt(buf, off)
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return buf[:read], nil
+}
+
+// extractPartialPDFMetadata reads the head (header, often the linearization
+// dictionary) and tail (trailer, /Info, usually the page tree root) of a large
+// PDF. A page count from individual page objects would be an undercount on a
+// partial read, so it is reported as unknown instead.
+func extractPartialPDFMetadata(r io.ReaderAt, size int64) []FileMetaResult {
+	head, err := readRange(r, 0, pdfRangeSize)
+	if err != nil {
+		return nil
+	}
+	tail, err := readRange(r, size-pdfRangeSize, pdfRangeSize)
+	if err != nil {
+		return nil
+	}
+
+	results := extractPDFMetadata(append(append(head, '\n'), tail...))
+	estimated := false
+	for _, res := range results {
+		if res.Key == "page_count_source" && res.Value == "page objects" {
+			estimated = true
+		}
+	}
+	if !estimated {
+		return results
+	}
+
+	filtered := results[:0]
+	for _, res := range results {
+		switch res.Key {
+		case "page_count":
+			filtered = append(filtered, FileMetaResult{Key: "page_count", Value: "unknown (only the start and end of a large file were read)"})
+		case "page_count_source":
+		default:
+			filtered = append(filtered, res)
+		}
+	}
+	return filtered
+}
+
+// isMP4Type reports whether a detected type is an ISO-BMFF movie container.
+func isMP4Type(mimeType string) bool {
+	switch mimeType {
+	case "video/mp4", "video/quicktime", "audio/mp4", "video/3gpp", "video/3gpp2":
+		return true
+	}
+	return false
+}
+
 func formatFileSize(n int) string {
 	if n < 1024 {
 		return fmt.Sprintf("%d bytes", n)
@@ -63,6 +200,12 @@ func formatFileSize(n int) string {
 
 // --- JPEG / EXIF ---
 
+// isTIFF reports whether data starts with a little- or big-endian TIFF header.
+func isTIFF(data []byte) bool {
+	return len(data) >= 4 &&
+		(bytes.Equal(data[:4], []byte("II*\x00")) || bytes.Equal(data[:4], []byte("MM\x00*")))
+}
+
 func extractJPEGMetadata(data []byte) []FileMetaResult {
 	var results []FileMetaResult
 
@@ -122,6 +265,9 @@ func findEXIFSegment(data []byte) []byte {
 
 // EXIF tag IDs we care about
 var exifTagNames = map[uint16]string{
+	0x0100: "image_width",
+	0x0101: "image_height",
+	0x010E: "image_description",
 	0x010F: "camera_make",
 	0x0110: "camera_model",
 	0x0112: "orientation",
@@ -130,18 +276,109 @@ var exifTagNames = map[uint16]string{
 	0x0131: "software",
 	0x0132: "date_modified",
 	0x0213: "ycbcr_positioning",
-	0x8769: "_exif_ifd",    // Pointer to Exif sub-IFD
-	0x8825: "_gps_ifd",     // Pointer to GPS IFD
+	0x8769: "_exif_ifd", // Pointer to Exif sub-IFD
+	0x8825: "_gps_ifd",  // Pointer to GPS IFD
 	0x9003: "date_original",
 	0x9004: "date_digitized",
 	0x829A: "exposure_time",
 	0x829D: "f_number",
+	0x8822: "exposure_program",
 	0x8827: "iso_speed",
 	0x920A: "focal_length",
 	0xA001: "color_space",
 	0xA002: "exif_width",
 	0xA003: "exif_height",
 	0xA405: "focal_length_35mm",
+	0x9207: "metering_mode",
+	0x9209: "flash",
+	0xA403: "white_balance",
+	0xA431: "body_serial_number",
+	0xA433: "lens_make",
+	0xA434: "lens_model",
+	0xA435: "lens_serial_number",
+	0x9010: "offset_time",
+	0x9011: "offset_time_original",
+	0x9012: "offset_time_digitized",
+	0x9286: "user_comment",
+}
+
+// exifDateOffsets pairs each EXIF date tag with the tag holding its UTC
+// offset ("+02:00"), which cameras following EXIF 2.31 record separately.
+var exifDateOffsets = map[string]string{
+	"date_modified":  "offset_time",
+	"date_original":  "offset_time_original",
+	"date_digitized": "offset_time_digitized",
+}
+
+// normalizeEXIFDate converts an EXIF "2006:01:02 15:04:05" date to RFC3339
+// when its offset is known, or to ISO 8601 local time without one. ok is
+// false for values that don't parse, such as blank "0000:00:00" dates.
+func normalizeEXIFDate(value, offset string) (string, bool) {
+	t, err := time.Parse("2006:01:02 15:04:05", strings.TrimSpace(value))
+	if err != nil {
+		return "", false
+	}
+	if offset != "" {
+		if zoned, err := time.Parse("2006:01:02 15:04:05-07:00", strings.TrimSpace(value)+strings.TrimSpace(offset)); err == nil {
+			return zoned.Format(time.RFC3339), true
+		}
+	}
+	return t.Format("2006-01-02T15:04:05"), true
+}
+
+// exifEnumDecoders turn enumerated EXIF values into readable text. parseEXIF
+// keeps the raw number alongside, e.g. "Rotate 90 CW (6)".
+var exifEnumDecoders = map[string]func(uint64) string{
+	"orientation": func(v uint64) string {
+		return map[uint64]string{1: "Normal", 2: "Mirror horizontal", 3: "Rotate 180", 4: "Mirror vertical",
+			5: "Mirror horizontal and rotate 270 CW", 6: "Rotate 90 CW", 7: "Mirror horizontal and rotate 90 CW", 8: "Rotate 270 CW"}[v]
+	},
+	"exposure_program": func(v uint64) string {
+		return map[uint64]string{0: "Not defined", 1: "Manual", 2: "Normal", 3: "Aperture priority", 4: "Shutter priority",
+			5: "Creative (slow speed)", 6: "Action (high speed)", 7: "Portrait", 8: "Landscape"}[v]
+	},
+	"color_space": func(v uint64) string {
+		return map[uint64]string{1: "sRGB", 2: "Adobe RGB", 0xFFFF: "Uncalibrated"}[v]
+	},
+	"metering_mode": func(v uint64) string {
+		modes := map[uint64]string{0: "Unknown", 1: "Average", 2: "Center-weighted average", 3: "Spot",
+			4: "Multi-spot", 5: "Pattern", 6: "Partial", 255: "Other"}
+		return modes[v]
+	},
+	"white_balance": func(v uint64) string {
+		return map[uint64]string{0: "Auto", 1: "Manual"}[v]
+	},
+	"flash": decodeEXIFFlash,
+}
+
+// decodeEXIFFlash describes the EXIF Flash bitmask: bit 0 fired, bits 1-2
+// strobe return, bits 3-4 mode, bit 5 no flash function, bit 6 red-eye.
+func decodeEXIFFlash(v uint64) string {
+	if v&0x20 != 0 {
+		return "No flash function"
+	}
+	parts := []string{"Did not fire"}
+	if v&0x01 != 0 {
+		parts[0] = "Fired"
+	}
+	switch (v >> 3) & 0x03 {
+	case 1:
+		parts = append(parts, "compulsory")
+	case 2:
+		parts = append(parts, "suppressed")
+	case 3:
+		parts = append(parts, "auto")
+	}
+	switch (v >> 1) & 0x03 {
+	case 2:
+		parts = append(parts, "return not detected")
+	case 3:
+		parts = append(parts, "return detected")
+	}
+	if v&0x40 != 0 {
+		parts = append(parts, "red-eye reduction")
+	}
+	return strings.Join(parts, ", ")
 }
 
 // GPS tag IDs
@@ -198,6 +435,25 @@ func parseEXIF(tiffData []byte) []FileMetaResult {
 
 	filtered = append(filtered, exifSubResults...)
 
+	offsets := make(map[string]string)
+	for _, r := range filtered {
+		offsets[r.Key] = r.Value
+	}
+	for i, r := range filtered {
+		if offsetKey, ok := exifDateOffsets[r.Key]; ok {
+			if date, ok := normalizeEXIFDate(r.Value, offsets[offsetKey]); ok {
+				filtered[i].Value = date
+			}
+		}
+		if decode, ok := exifEnumDecoders[r.Key]; ok {
+			if n, err := strconv.ParseUint(r.Value, 10, 32); err == nil {
+				if text := decode(n); text != "" {
+					filtered[i].Value = text + " (" + r.Value + ")"
+				}
+			}
+		}
+	}
+
 	// Convert GPS data to human-readable coordinates
 	filtered = append(filtered, convertGPSResults(gpsResults)...)
 
@@ -231,7 +487,12 @@ func parseIFD(data []byte, bo binary.ByteOrder, offset int, tagNames map[uint16]
 			continue
 		}
 
-		value := readEXIFValue(data, bo, dataType, count, valueBytes)
+		var value string
+		if name == "user_comment" {
+			value = decodeUserComment(exifValueBytes(data, bo, dataType, count, valueBytes), bo)
+		} else {
+			value = readEXIFValue(data, bo, dataType, count, valueBytes)
+		}
 		if value != "" {
 			results = append(results, FileMetaResult{Key: name, Value: value})
 		}
@@ -240,33 +501,114 @@ func parseIFD(data []byte, bo binary.ByteOrder, offset int, tagNames map[uint16]
 	return results
 }
 
-func readEXIFValue(data []byte, bo binary.ByteOrder, dataType uint16, count int, valueBytes []byte) string {
+// exifASCII decodes an ASCII (type 2) value. Some writers pack several
+// NUL-terminated strings into one field, so interior NULs split it into
+// values joined with "; ". Cameras also write UTF-8 or Latin-1 despite the
+// spec: valid UTF-8 is kept and anything else is read as Latin-1, so stray
+// bytes never reach the JSON output as garbage. The result is capped at 200
+// characters.
+func exifASCII(b []byte) string {
+	var parts []string
+	for _, part := range bytes.Split(b, []byte{0}) {
+		s := strings.TrimSpace(exifText(part))
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	s := strings.Join(parts, "; ")
+	if r := []rune(s); len(r) > 200 {
+		s = string(r[:200])
+	}
+	return s
+}
+
+// exifText converts EXIF string bytes to valid UTF-8, treating invalid input
+// as Latin-1 and dropping control characters.
+func exifText(b []byte) string {
+	var s string
+	if utf8.Valid(b) {
+		s = string(b)
+	} else {
+		r := make([]rune, len(b))
+		for i, c := range b {
+			r[i] = rune(c)
+		}
+		s = string(r)
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// exifValueBytes returns an IFD entry's value: inline in the entry when it
+// fits in 4 bytes, otherwise at the offset the entry holds.
+func exifValueBytes(data []byte, bo binary.ByteOrder, dataType uint16, count int, valueBytes []byte) []byte {
 	// Calculate total size
 	typeSize := map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
 	size := typeSize[dataType] * count
-	if size == 0 {
-		return ""
+	if size <= 0 {
+		return nil
 	}
 
-	var valData []byte
 	if size <= 4 {
-		valData = valueBytes[:size]
-	} else {
-		offset := int(bo.Uint32(valueBytes))
-		if offset+size > len(data) || offset < 0 {
-			return ""
+		return valueBytes[:size]
+	}
+	offset := int(bo.Uint32(valueBytes))
+	if offset+size > len(data) || offset < 0 {
+		return nil
+	}
+	return data[offset : offset+size]
+}
+
+// decodeUserComment decodes the UserComment tag, whose first 8 bytes name
+// the character code of the rest: ASCII, UNICODE (UTF-16, in the file's byte
+// order unless a BOM says otherwise), JIS or undefined. Writers pad the text
+// with NULs or spaces, which are trimmed; a comment that is only padding
+// yields "". JIS (X 0208) text isn't decoded.
+func decodeUserComment(b []byte, bo binary.ByteOrder) string {
+	if len(b) < 8 {
+		return exifASCII(b)
+	}
+	code, text := string(b[:8]), b[8:]
+	switch code {
+	case "UNICODE\x00":
+		if len(text) >= 2 {
+			switch {
+			case text[0] == 0xFE && text[1] == 0xFF:
+				bo, text = binary.BigEndian, text[2:]
+			case text[0] == 0xFF && text[1] == 0xFE:
+				bo, text = binary.LittleEndian, text[2:]
+			}
+		}
+		units := make([]uint16, len(text)/2)
+		for i := range units {
+			units[i] = bo.Uint16(text[i*2:])
+		}
+		return exifASCII([]byte(string(utf16.Decode(units))))
+	case "JIS\x00\x00\x00\x00\x00":
+		if n := len(bytes.TrimRight(text, "\x00 ")); n > 0 {
+			return fmt.Sprintf("(JIS-encoded comment, %d bytes)", n)
 		}
-		valData = data[offset : offset+size]
+		return ""
+	case "ASCII\x00\x00\x00", "\x00\x00\x00\x00\x00\x00\x00\x00":
+		return exifASCII(text)
+	}
+	// no recognized prefix: some writers store bare text
+	return exifASCII(b)
+}
+
+func readEXIFValue(data []byte, bo binary.ByteOrder, dataType uint16, count int, valueBytes []byte) string {
+	valData := exifValueBytes(data, bo, dataType, count, valueBytes)
+	if valData == nil {
+		return ""
 	}
 
 	switch dataType {
 	case 2: // ASCII
-		s := string(valData)
-		s = strings.TrimRight(s, "\x00 ")
-		if len(s) > 200 {
-			s = s[:200]
-		}
-		return s
+		return exifASCII(valData)
 	case 3: // SHORT (uint16)
 		if len(valData) >= 2 {
 			return fmt.Sprintf("%d", bo.Uint16(valData))
@@ -346,6 +688,9 @@ func convertGPSResults(gpsResults []FileMetaResult) []FileMetaResult {
 			Key:   "gps_coordinates",
 			Value: fmt.Sprintf("%.6f, %.6f", lat, lon),
 		})
+		if math.Abs(lat) <= 90 && math.Abs(lon) <= 180 {
+			results = append(results, gpsMapLinks(lat, lon)...)
+		}
 	}
 	if altVal != "" {
 		alt := altVal
@@ -357,6 +702,21 @@ func convertGPSResults(gpsResults []FileMetaResult) []FileMetaResult {
 	return results
 }
 
+// gpsMapLinks returns OpenStreetMap and Google Maps links centered on a
+// coordinate, so a geotag can be opened straight from the results.
+func gpsMapLinks(lat, lon float64) []FileMetaResult {
+	return []FileMetaResult{
+		{
+			Key:   "gps_map_osm",
+			Value: fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.6f&mlon=%.6f#map=16/%.6f/%.6f", lat, lon, lat, lon),
+		},
+		{
+			Key:   "gps_map_google",
+			Value: fmt.Sprintf("https://www.google.com/maps?q=%.6f,%.6f", lat, lon),
+		},
+	}
+}
+
 func dmsToDecimal(dms string, ref string) float64 {
 	// dms is comma-separated rationals: "degrees, minutes, seconds"
 	parts := strings.Split(dms, ", ")
@@ -367,8 +727,10 @@ func dmsToDecimal(dms string, ref string) float64 {
 	for i, p := range parts[:3] {
 		fmt.Sscanf(strings.TrimSpace(p), "%f", &vals[i])
 	}
-	dec := vals[0] + vals[1]/60 + vals[2]/3600
-	if ref == "S" || ref == "W" {
+	// Some writers store southern/western values already negated, so the
+	// ref alone decides the hemisphere.
+	dec := math.Abs(vals[0]) + math.Abs(vals[1])/60 + math.Abs(vals[2])/3600
+	if ref = strings.ToUpper(strings.TrimSpace(ref)); ref == "S" || ref == "W" {
 		dec = -dec
 	}
 	// Round to 6 decimal places
@@ -402,13 +764,39 @@ func extractPNGMetadata(data []byte) []FileMetaResult {
 	}
 
 	offset := 8 // Skip PNG header
-	for offset+8 < len(data) {
-		chunkLen := int(binary.BigEndian.Uint32(data[offset : offset+4]))
-		chunkType := string(data[offset+4 : offset+8])
+	var crcErrors []string
+	chunks := 0
+walk:
+	for {
+		if offset == len(data) {
+			results = append(results, FileMetaResult{Key: "png_truncated", Value: "Security note: file ends without an IEND chunk"})
+			break
+		}
+		if len(data)-offset < 12 {
+			results = append(results, FileMetaResult{Key: "png_truncated", Value: fmt.Sprintf("Security note: %d stray byte(s) at offset %d, too short for a chunk", len(data)-offset, offset)})
+			break
+		}
+		if chunks++; chunks > maxPNGChunks {
+			results = append(results, FileMetaResult{Key: "png_chunk_limit", Value: fmt.Sprintf("Security note: stopped after %d chunks", maxPNGChunks)})
+			break
+		}
 
-		if chunkLen < 0 || offset+12+chunkLen > len(data) {
+		length := binary.BigEndian.Uint32(data[offset : offset+4])
+		chunkType := string(data[offset+4 : offset+8])
+		if !validPNGChunkType(data[offset+4 : offset+8]) {
+			results = append(results, FileMetaResult{Key: "png_malformed", Value: fmt.Sprintf("Security note: invalid chunk type %q at offset %d", chunkType, offset)})
 			break
 		}
+		if length > math.MaxInt32 || int64(length) > int64(len(data)-offset-12) {
+			results = append(results, FileMetaResult{Key: "png_truncated", Value: fmt.Sprintf("Security note: %s chunk at offset %d claims %d bytes but only %d remain", chunkType, offset, length, len(data)-offset-12)})
+			break
+		}
+		chunkLen := int(length)
+
+		// the CRC covers the type and data
+		if crc32.ChecksumIEEE(data[offset+4:offset+8+chunkLen]) != binary.BigEndian.Uint32(data[offset+8+chunkLen:]) {
+			crcErrors = append(crcErrors, fmt.Sprintf("%s@%d", chunkType, offset))
+		}
 
 		chunkData := data[offset+8 : offset+8+chunkLen]
 
@@ -449,16 +837,69 @@ func extractPNGMetadata(data []byte) []FileMetaResult {
 					results = append(results, FileMetaResult{Key: pngKeyName(key), Value: val})
 				}
 			}
+		case "zTXt":
+			// keyword \0 compression_method zlib_data
+			if idx := bytes.IndexByte(chunkData, 0); idx >= 0 && idx+1 < len(chunkData) && chunkData[idx+1] == 0 {
+				if val, ok := inflatePNGText(chunkData[idx+2:]); ok {
+					if len(val) > 500 {
+						val = val[:500]
+					}
+					results = append(results, FileMetaResult{Key: pngKeyName(string(chunkData[:idx])), Value: val})
+				}
+			}
+		case "eXIf":
+			// Raw TIFF-structured EXIF; some writers keep the JPEG APP1 prefix
+			results = append(results, parseEXIF(bytes.TrimPrefix(chunkData, []byte("Exif\x00\x00")))...)
 		case "IEND":
-			break
+			break walk
 		}
 
 		offset += 12 + chunkLen // length + type + data + CRC
 	}
 
+	if len(crcErrors) > 0 {
+		value := fmt.Sprintf("Security note: %d chunk(s) failed CRC check: %s", len(crcErrors), strings.Join(crcErrors[:min(len(crcErrors), 10)], ", "))
+		if len(crcErrors) > 10 {
+			value += ", ..."
+		}
+		results = append(results, FileMetaResult{Key: "png_crc_error", Value: value})
+	}
 	return results
 }
 
+// maxPNGChunks caps how many chunks are walked. Real images have a few dozen
+// (IDAT split included); a crafted file of empty chunks could have millions.
+const maxPNGChunks = 10000
+
+// validPNGChunkType reports whether t is four ASCII letters, as every chunk
+// type is. Anything else means the walk has left the chunk structure.
+func validPNGChunkType(t []byte) bool {
+	for _, c := range t {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// maxPNGTextInflate caps how much of a zTXt chunk is decompressed, so a small
+// chunk can't expand into gigabytes.
+const maxPNGTextInflate = 64 * 1024
+
+// inflatePNGText decompresses zTXt data, stopping at maxPNGTextInflate bytes.
+func inflatePNGText(data []byte) (string, bool) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", false
+	}
+	defer zr.Close()
+	text, err := io.ReadAll(io.LimitReader(zr, maxPNGTextInflate))
+	if err != nil && len(text) == 0 {
+		return "", false
+	}
+	return string(text), true
+}
+
 func pngKeyName(key string) string {
 	lower := strings.ToLower(key)
 	switch lower {
@@ -485,44 +926,14 @@ func extractPDFMetadata(data []byte) []FileMetaResult {
 	var results []FileMetaResult
 	content := string(data)
 
-	// Count pages: /Type /Page (but not /Type /Pages)
-	pageCount := 0
-	idx := 0
-	for {
-		pos := strings.Index(content[idx:], "/Type /Page")
-		if pos == -1 {
-			break
-		}
-		absPos := idx + pos
-		after := absPos + len("/Type /Page")
-		if after < len(content) {
-			nextChar := content[after]
-			// Only count if NOT followed by 's' (which would be /Type /Pages)
-			if nextChar != 's' && nextChar != 'S' {
-				pageCount++
-			}
-		}
-		idx = absPos + 1
-	}
-	// Also try /Type/Page (without space)
-	idx = 0
-	for {
-		pos := strings.Index(content[idx:], "/Type/Page")
-		if pos == -1 {
-			break
-		}
-		absPos := idx + pos
-		after := absPos + len("/Type/Page")
-		if after < len(content) {
-			nextChar := content[after]
-			if nextChar != 's' && nextChar != 'S' {
-				pageCount++
-			}
-		}
-		idx = absPos + 1
-	}
-	if pageCount > 0 {
+	if pageCount, method, approximate := countPDFPages(content); pageCount > 0 {
 		results = append(results, FileMetaResult{Key: "page_count", Value: fmt.Sprintf("%d", pageCount)})
+		results = append(results, FileMetaResult{Key: "page_count_source", Value: method})
+		if approximate {
+			results = append(results, FileMetaResult{Key: "page_count_warning", Value: "may be too low: some objects are in compressed object streams"})
+		}
+	} else if pdfUsesObjectStreams(content) {
+		results = append(results, FileMetaResult{Key: "page_count", Value: "unknown (page tree is in compressed object streams)"})
 	}
 
 	// Parse /Info dictionary entries
@@ -540,12 +951,18 @@ func extractPDFMetadata(data []byte) []FileMetaResult {
 		{"/Keywords", "keywords"},
 	}
 
+	// /Info wins; the XMP packet fills fields it leaves empty
+	xmp := parseXMP(findXMPPacket(content))
 	for _, f := range pdfFields {
 		if val := extractPDFString(content, f.tag); val != "" {
 			results = append(results, FileMetaResult{Key: f.name, Value: val})
+		} else if val := xmp[f.name]; val != "" {
+			results = append(results, FileMetaResult{Key: f.name, Value: val})
 		}
 	}
 
+	results = append(results, pdfSecurityFindings(content)...)
+
 	// Get PDF version from header
 	if len(content) > 8 && strings.HasPrefix(content, "%PDF-") {
 		endIdx := strings.Index(content[:20], "\n")
@@ -561,6 +978,132 @@ func extractPDFMetadata(data []byte) []FileMetaResult {
 	return results
 }
 
+// pdfActiveContent lists PDF name tokens that indicate active or embedded
+// content commonly abused by malicious documents.
+var pdfActiveContent = []struct {
+	names []string
+	key   string
+	note  string
+}{
+	{[]string{"/JavaScript", "/JS"}, "pdf_javascript", "document contains JavaScript"},
+	{[]string{"/OpenAction", "/AA"}, "pdf_auto_action", "document runs an action automatically when opened"},
+	{[]string{"/Launch"}, "pdf_launch_action", "document can launch external programs"},
+	{[]string{"/EmbeddedFile"}, "pdf_embedded_file", "document carries embedded files"},
+}
+
+// pdfSecurityFindings reports the presence of active/embedded content tokens
+// so suspicious documents stand out during triage.
+func pdfSecurityFindings(content string) []FileMetaResult {
+	var results []FileMetaResult
+	for _, ac := range pdfActiveContent {
+		count := 0
+		for _, name := range ac.names {
+			count += countPDFName(content, name)
+		}
+		if count > 0 {
+			results = append(results, FileMetaResult{
+				Key:   ac.key,
+				Value: fmt.Sprintf("Security note: %s (%d occurrence(s))", ac.note, count),
+			})
+		}
+	}
+	return results
+}
+
+// countPDFName counts occurrences of a PDF name token, ignoring longer names
+// that merely share the prefix (e.g. /JS vs /JSON).
+func countPDFName(content, name string) int {
+	count := 0
+	idx := 0
+	for {
+		pos := strings.Index(content[idx:], name)
+		if pos == -1 {
+			break
+		}
+		after := idx + pos + len(name)
+		if after >= len(content) || !isPDFRegularChar(content[after]) {
+			count++
+		}
+		idx = after
+	}
+	return count
+}
+
+func isPDFRegularChar(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '/', '(', ')', '<', '>', '[', ']', '{', '}', '%':
+		return false
+	}
+	return true
+}
+
+var (
+	pdfObjectRe     = regexp.MustCompile(`(?s)\d+\s+\d+\s+obj\b(.*?)endobj`)
+	pdfPagesTypeRe  = regexp.MustCompile(`/Type\s*/Pages(?:[^A-Za-z0-9]|$)`)
+	pdfCountRe      = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfLinearizedRe = regexp.MustCompile(`(?s)/Linearized\s+[\d.]+.*?/N\s+(\d+)`)
+	pdfObjStmRe     = regexp.MustCompile(`/Type\s*/(?:ObjStm|XRef)\b`)
+)
+
+// countPDFPages determines the page count, preferring the page tree root's
+// /Count, then the linearization dictionary, and finally counting individual
+// /Type /Page objects. The second return value names the method used. The
+// last is approximate when the file has object streams, since pages inside
+// them are compressed and not seen.
+func countPDFPages(content string) (count int, method string, approximate bool) {
+	// The root of the page tree carries the total; intermediate nodes carry
+	// subtotals, so the largest /Count on a /Pages node is the document total.
+	rootCount := 0
+	for _, m := range pdfObjectRe.FindAllStringSubmatch(content, -1) {
+		body := m[1]
+		if !pdfPagesTypeRe.MatchString(body) {
+			continue
+		}
+		if c := pdfCountRe.FindStringSubmatch(body); c != nil {
+			if n, err := strconv.Atoi(c[1]); err == nil && n > rootCount {
+				rootCount = n
+			}
+		}
+	}
+	if rootCount > 0 {
+		return rootCount, "page tree /Count", false
+	}
+
+	if m := pdfLinearizedRe.FindStringSubmatch(content); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			return n, "linearization dictionary", false
+		}
+	}
+
+	// Count /Type /Page objects (but not /Type /Pages)
+	pageCount := 0
+	for _, marker := range []string{"/Type /Page", "/Type/Page"} {
+		idx := 0
+		for {
+			pos := strings.Index(content[idx:], marker)
+			if pos == -1 {
+				break
+			}
+			absPos := idx + pos
+			after := absPos + len(marker)
+			if after < len(content) {
+				nextChar := content[after]
+				if nextChar != 's' && nextChar != 'S' {
+					pageCount++
+				}
+			}
+			idx = absPos + 1
+		}
+	}
+	return pageCount, "page objects", pdfUsesObjectStreams(content)
+}
+
+// pdfUsesObjectStreams reports whether objects are stored in compressed object
+// or cross-reference streams, where raw byte searches cannot see them.
+func pdfUsesObjectStreams(content string) bool {
+	return pdfObjStmRe.MatchString(content)
+}
+
 func extractPDFString(content, tag string) string {
 	idx := strings.Index(content, tag)
 	if idx == -1 {
@@ -0,0 +1,182 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCountPDFPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		pdf         string
+		count       int
+		method      string
+		approximate bool
+	}{
+		{
+			name: "page tree",
+			pdf: "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+				"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >> endobj\n" +
+				"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n4 0 obj << /Type /Page /Parent 2 0 R >> endobj\n",
+			count:  2,
+			method: "page tree /Count",
+		},
+		{
+			name: "linearized, page tree compressed",
+			pdf: "%PDF-1.5\n1 0 obj << /Linearized 1 /L 81234 /H [ 600 150 ] /O 4 /E 12345 /N 12 /T 80987 >> endobj\n" +
+				"5 0 obj << /Type /ObjStm /N 20 /First 140 /Filter /FlateDecode /Length 900 >> stream\nx\x9c...\nendstream endobj\n",
+			count:  12,
+			method: "linearization dictionary",
+		},
+		{
+			name: "page objects",
+			pdf: "%PDF-1.4\n3 0 obj << /Type /Page >> endobj\n4 0 obj << /Type/Page >> endobj\n" +
+				"5 0 obj << /Type /Page >> endobj\n",
+			count:  3,
+			method: "page objects",
+		},
+		{
+			name: "page objects beside object streams",
+			pdf: "%PDF-1.5\n3 0 obj << /Type /Page >> endobj\n" +
+				"7 0 obj << /Type /ObjStm /N 40 /First 300 /Filter /FlateDecode /Length 2048 >> stream\nx\x9c...\nendstream endobj\n" +
+				"8 0 obj << /Type /XRef /W [1 2 1] /Size 9 >> stream\n...\nendstream endobj\n",
+			count:       1,
+			method:      "page objects",
+			approximate: true,
+		},
+		{
+			name:   "object streams only",
+			pdf:    "%PDF-1.5\n7 0 obj << /Type /ObjStm /N 40 /First 300 >> stream\n...\nendstream endobj\n",
+			count:  0,
+			method: "page objects",
+			// nothing visible to count; extractPDFMetadata reports unknown
+			approximate: true,
+		},
+	}
+	for _, tt := range tests {
+		count, method, approximate := countPDFPages(tt.pdf)
+		if count != tt.count || method != tt.method || approximate != tt.approximate {
+			t.Errorf("%s: countPDFPages = %d, %q, %v; want %d, %q, %v",
+				tt.name, count, method, approximate, tt.count, tt.method, tt.approximate)
+		}
+	}
+}
+
+func TestExtractPDFMetadataPageCountWarning(t *testing.T) {
+	pdf := "%PDF-1.5\n3 0 obj << /Type /Page >> endobj\n" +
+		"7 0 obj << /Type /ObjStm /N 40 /First 300 >> stream\n...\nendstream endobj\n"
+	fields := make(map[string]string)
+	for _, r := range extractPDFMetadata([]byte(pdf)) {
+		fields[r.Key] = r.Value
+	}
+	if fields["page_count"] != "1" || fields["page_count_warning"] == "" {
+		t.Errorf("page_count = %q, page_count_warning = %q; want 1 with a warning", fields["page_count"], fields["page_count_warning"])
+	}
+
+	fields = make(map[string]string)
+	for _, r := range extractPDFMetadata([]byte("%PDF-1.5\n7 0 obj << /Type /ObjStm /N 4 >> stream\n...\nendstream endobj\n")) {
+		fields[r.Key] = r.Value
+	}
+	if !strings.HasPrefix(fields["page_count"], "unknown") {
+		t.Errorf("page_count = %q; want unknown", fields["page_count"])
+	}
+}
+
+func TestExifASCII(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Canon", "Canon"},
+		{"trailing NUL", "Canon\x00", "Canon"},
+		{"NUL padding", "NIKON CORPORATION\x00\x00\x00\x00", "NIKON CORPORATION"},
+		{"embedded NUL", "Canon\x00EOS 5D", "Canon; EOS 5D"},
+		{"runs of NULs and spaces", "\x00 Apple \x00\x00 iPhone\x00", "Apple; iPhone"},
+		{"only NULs", "\x00\x00\x00", ""},
+		{"UTF-8", "Café Noir", "Café Noir"},
+		{"Latin-1", "Caf\xe9 Noir", "Café Noir"},
+		{"Latin-1 beside NUL", "SONY\x00\xc5ngstr\xf6m", "SONY; Ångström"},
+		{"control characters", "Ca\x01no\x1bn\x7f", "Canon"},
+		{"C1 controls from Latin-1", "Ca\x85non\x9f", "Canon"},
+		{"truncated", strings.Repeat("é", 250), strings.Repeat("é", 200)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := exifASCII([]byte(tt.in))
+			if got != tt.want {
+				t.Errorf("exifASCII(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("exifASCII(%q) = %q is not valid UTF-8", tt.in, got)
+			}
+		})
+	}
+}
+
+// pngChunk encodes one PNG chunk with a correct CRC.
+func pngChunk(typ string, data []byte) []byte {
+	b := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	b = append(b, typ...)
+	b = append(b, data...)
+	return binary.BigEndian.AppendUint32(b, crc32.ChecksumIEEE(b[4:]))
+}
+
+func TestExtractPNGMetadataMalformed(t *testing.T) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	ihdr := pngChunk("IHDR", []byte{0, 0, 0, 1, 0, 0, 0, 1, 8, 0, 0, 0, 0})
+	text := pngChunk("tEXt", []byte("Author\x00alice"))
+	iend := pngChunk("IEND", nil)
+	png := func(chunks ...[]byte) []byte {
+		return append([]byte(signature), bytes.Join(chunks, nil)...)
+	}
+
+	badCRC := bytes.Clone(text)
+	badCRC[len(badCRC)-1] ^= 0xff
+
+	overlong := bytes.Clone(text)
+	binary.BigEndian.PutUint32(overlong, 1000)
+
+	// IHDR and IEND count towards the limit too
+	empty := pngChunk("teSt", nil)
+	atLimit := bytes.Repeat(empty, maxPNGChunks-2)
+	overLimit := bytes.Repeat(empty, maxPNGChunks-1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string // security note key that must be present
+		notWant []string
+	}{
+		{"well formed", png(ihdr, text, iend), "", []string{"png_truncated", "png_crc_error", "png_chunk_limit", "png_malformed"}},
+		{"length past end of file", png(ihdr, overlong, iend), "png_truncated", []string{"png_crc_error"}},
+		{"chunk cut short", png(ihdr, text, iend[:8]), "png_truncated", nil},
+		{"stray bytes", append(png(ihdr, text), 0, 0, 0), "png_truncated", nil},
+		{"missing IEND", png(ihdr, text), "png_truncated", nil},
+		{"bad CRC", png(ihdr, badCRC, iend), "png_crc_error", []string{"png_truncated"}},
+		{"chunk limit", png(ihdr, overLimit, iend), "png_chunk_limit", []string{"png_truncated"}},
+		{"at chunk limit", png(ihdr, atLimit, iend), "", []string{"png_chunk_limit", "png_truncated"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]string{}
+			for _, r := range extractPNGMetadata(tt.data) {
+				got[r.Key] = r.Value
+			}
+			if tt.want != "" {
+				if _, ok := got[tt.want]; !ok {
+					t.Errorf("missing %s in %v", tt.want, got)
+				}
+			}
+			for _, k := range tt.notWant {
+				if v, ok := got[k]; ok {
+					t.Errorf("unexpected %s = %q", k, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// frameScope is how widely a page may be framed, narrowest first.
+type frameScope int
+
+const (
+	frameNone      frameScope = iota // no framing at all
+	frameSelf                        // same origin only
+	frameAllowlist                   // listed origins only
+	frameAny                         // any site
+)
+
+func (s frameScope) String() string {
+	switch s {
+	case frameNone:
+		return "none"
+	case frameSelf:
+		return "same-origin"
+	case frameAllowlist:
+		return "allowlist"
+	}
+	return "any"
+}
+
+// --- Clickjacking / Framing Check ---
+
+// checkFraming fetches the target and reports whether browsers would let
+// another site frame it. With evidence set, the exchange is kept in Details.
+func checkFraming(ctx context.Context, client *http.Client, scanID int64, target string, evidence bool, progress func(string)) ([]database.Result, error) {
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+
+	resp, err := doWithRetry(ctx, client, req, progress)
+	if err != nil {
+		return nil, fmt.Errorf("fetch URL: %w", err)
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	d := evaluateFraming(resp.Header)
+	d.Exchange = captureExchange(evidence, resp, nil)
+	if resp.StatusCode >= 300 {
+		d.Notes = append(d.Notes, "evaluated on a "+resp.Status+" response")
+	}
+
+	value := "framable by any site"
+	switch d.Scope {
+	case frameNone.String():
+		value = "not framable"
+	case frameSelf.String():
+		value = "framable by same origin only"
+	case frameAllowlist.String():
+		value = "framable by listed origins only"
+	}
+	value += " (" + d.Protection + ")"
+
+	return []database.Result{{
+		ScanID:     scanID,
+		ResultType: "clickjacking",
+		Key:        resp.Request.URL.String(),
+		Value:      value,
+		Details:    detailsJSON(d),
+	}}, nil
+}
+
+// evaluateFraming models how browsers combine the two framing controls: an
+// enforced CSP frame-ancestors directive replaces X-Frame-Options entirely,
+// and only then does X-Frame-Options apply.
+func evaluateFraming(h http.Header) clickjackingDetails {
+	d := clickjackingDetails{XFrameOptions: strings.Join(h.Values("X-Frame-Options"), ", ")}
+
+	xfoScope, xfoNote := xfoFrameScope(h.Values("X-Frame-Options"))
+	cspScope, ancestors, hasCSP := cspFrameScope(h.Values("Content-Security-Policy"))
+	d.FrameAncestors = ancestors
+
+	var scope frameScope
+	switch {
+	case hasCSP:
+		scope = cspScope
+		d.Protection = "csp frame-ancestors"
+		if d.XFrameOptions != "" {
+			d.Notes = append(d.Notes, "X-Frame-Options is ignored because CSP frame-ancestors is set")
+		}
+	case d.XFrameOptions != "":
+		scope = xfoScope
+		d.Protection = "x-frame-options"
+		if xfoNote != "" {
+			d.Notes = append(d.Notes, xfoNote)
+		}
+	default:
+		scope = frameAny
+		d.Protection = "none"
+	}
+
+	if _, _, reportOnly := cspFrameScope(h.Values("Content-Security-Policy-Report-Only")); reportOnly {
+		d.Notes = append(d.Notes, "frame-ancestors in Content-Security-Policy-Report-Only is not enforced")
+	}
+
+	d.Scope = scope.String()
+	d.Framable = scope == frameAny
+	switch scope {
+	case frameAny:
+		d.Severity = "medium"
+	case frameAllowlist:
+		d.Severity = "low"
+	default:
+		d.Severity = "info"
+	}
+	return d
+}
+
+// xfoFrameScope applies the HTML spec's X-Frame-Options processing: values
+// are split on commas and deduplicated, conflicting recognized values block
+// framing, and anything other than DENY or SAMEORIGIN (including the
+// obsolete ALLOW-FROM) offers no protection.
+func xfoFrameScope(headers []string) (frameScope, string) {
+	values := make(map[string]bool)
+	for _, h := range headers {
+		for _, v := range strings.Split(h, ",") {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				values[v] = true
+			}
+		}
+	}
+
+	if len(values) > 1 {
+		if values["deny"] || values["sameorigin"] || values["allowall"] {
+			return frameNone, "conflicting X-Frame-Options values block all framing"
+		}
+		return frameAny, "unrecognized X-Frame-Options values"
+	}
+	switch {
+	case values["deny"]:
+		return frameNone, ""
+	case values["sameorigin"]:
+		return frameSelf, ""
+	}
+	for v := range values {
+		if strings.HasPrefix(v, "allow-from") {
+			return frameAny, "ALLOW-FROM is not supported by current browsers"
+		}
+		return frameAny, "unrecognized X-Frame-Options value " + v
+	}
+	return frameAny, ""
+}
+
+// cspFrameScope finds frame-ancestors in each enforced policy. Every policy
+// must allow a frame, so the narrowest one wins. ok is false when no policy
+// sets the directive.
+func cspFrameScope(policies []string) (scope frameScope, sources []string, ok bool) {
+	scope = frameAny
+	for _, policy := range policies {
+		// a header may carry several comma-separated policies
+		for _, p := range strings.Split(policy, ",") {
+			for _, directive := range strings.Split(p, ";") {
+				fields := strings.Fields(directive)
+				if len(fields) == 0 || !strings.EqualFold(fields[0], "frame-ancestors") {
+					continue
+				}
+				ok = true
+				sources = append(sources, fields[1:]...)
+				if s := frameAncestorsScope(fields[1:]); s < scope {
+					scope = s
+				}
+				break // browsers use the first occurrence in a policy
+			}
+		}
+	}
+	return scope, sources, ok
+}
+
+// frameAncestorsScope classifies one frame-ancestors source list.
+func frameAncestorsScope(sources []string) frameScope {
+	scope := frameNone
+	for _, src := range sources {
+		src = strings.ToLower(src)
+		switch {
+		case src == "'none'":
+			// only meaningful on its own; otherwise ignored
+		case src == "'self'":
+			if scope < frameSelf {
+				scope = frameSelf
+			}
+		case src == "*" || src == "https:" || src == "http:" || src == "https://*" || src == "http://*":
+			return frameAny
+		default:
+			scope = frameAllowlist
+		}
+	}
+	return scope
+}
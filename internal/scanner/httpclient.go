@@ -0,0 +1,156 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// NewResolver returns a resolver that sends every query to addr (host or
+// host:port, port 53 by default). An empty addr yields the system resolver.
+func NewResolver(addr string) *net.Resolver {
+	if addr == "" {
+		return net.DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			d := net.Dialer{Timeout: 5 * time.Second}
+			return d.DialContext(ctx, network, addr)
+		},
+	}
+}
+
+// newDialer returns the dialer shared by builtins that open raw connections
+// and by the HTTP transport. A non-empty sourceIP pins the local address so
+// traffic egresses through the intended interface.
+func newDialer(resolver *net.Resolver, sourceIP string) *net.Dialer {
+	d := &net.Dialer{
+		Timeout:   10 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Resolver:  resolver,
+	}
+	if ip := net.ParseIP(sourceIP); ip != nil {
+		d.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return d
+}
+
+// NewHTTPTransport returns a tuned transport meant to be shared by every
+// HTTP-based builtin so connections are pooled across requests and network
+// policy (proxy, TLS, timeouts, DNS) lives in one place.
+func NewHTTPTransport(dialer *net.Dialer) *http.Transport {
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       &tls.Config{},
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// httpClient returns a client with the given timeout backed by the executor's
+// shared transport.
+func (e *Executor) httpClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: e.transport, Timeout: timeout}
+}
+
+const (
+	maxHTTPRetries = 3
+	baseRetryDelay = time.Second
+	// maxRetryWait caps how long after its first attempt a request may still
+	// be retried, so slow or rate-limiting servers can't stall a scan.
+	maxRetryWait = 30 * time.Second
+)
+
+// doWithRetry sends a bodiless request, retrying when the server rate-limits
+// (429) or is briefly unavailable (503) and when an attempt times out. A
+// Retry-After header sets the wait; otherwise delays double from one second.
+// When the next attempt would start more than maxRetryWait after the first,
+// the last response or error is returned as is. progress, if non-nil, is told
+// about each retry.
+func doWithRetry(ctx context.Context, client *http.Client, req *http.Request, progress func(string)) (*http.Response, error) {
+	giveUp := time.Now().Add(maxRetryWait)
+	delay := baseRetryDelay
+	for attempt := 0; ; attempt++ {
+		resp, err := client.Do(req)
+		reason := retryReason(ctx, resp, err)
+		if reason == "" || attempt == maxHTTPRetries {
+			return resp, err
+		}
+
+		wait := delay
+		if resp != nil {
+			if d, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				wait = d
+			}
+		}
+		if time.Now().Add(wait).After(giveUp) {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		if progress != nil {
+			progress(fmt.Sprintf("%s: %s, retrying in %s", req.URL.Host, reason, wait))
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		delay *= 2
+	}
+}
+
+// retryReason says why an attempt is worth repeating, or "" if it isn't.
+func retryReason(ctx context.Context, resp *http.Response, err error) string {
+	if err != nil {
+		var netErr net.Error
+		if ctx.Err() == nil && errors.As(err, &netErr) && netErr.Timeout() {
+			return "timed out"
+		}
+		return ""
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return "rate limited (429)"
+	case http.StatusServiceUnavailable:
+		return "unavailable (503)"
+	}
+	return ""
+}
+
+// parseRetryAfter reads a Retry-After value given in seconds or as an HTTP
+// date.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0), true
+	}
+	return 0, false
+}
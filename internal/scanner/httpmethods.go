@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// traceMarkerHeader is sent with the TRACE probe; a server that echoes it
+// back in the body really does support TRACE.
+const traceMarkerHeader = "X-Raccoon-Trace"
+
+// riskyMethods are the methods probed individually, in order. PUT and DELETE
+// go to a random path that doesn't exist, so a server that honors them
+// creates and then removes a throwaway file rather than touching real content.
+var riskyMethods = []string{"TRACE", "CONNECT", "PUT", "DELETE"}
+
+// --- HTTP Method Enumeration ---
+
+// probeHTTPMethods asks the target which methods it allows with OPTIONS, then
+// sends each of riskyMethods and records how the server answered. A 2xx to
+// TRACE or PUT is flagged as a finding. Redirects are not followed, since the
+// client would turn the method into a GET.
+func probeHTTPMethods(ctx context.Context, client *http.Client, scanID int64, target string, evidence bool, emit func(database.Result), progress func(string)) error {
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+	target = strings.TrimRight(target, "/")
+
+	noFollow := *client
+	noFollow.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+
+	marker := make([]byte, 8)
+	rand.Read(marker)
+	token := hex.EncodeToString(marker)
+	probePath := target + "/raccoon-recon-" + token + ".txt"
+
+	resp, _, err := sendMethod(ctx, &noFollow, "OPTIONS", target+"/", nil, progress)
+	if err != nil {
+		return fmt.Errorf("fetch URL: %w", err)
+	}
+	allowed := parseAllowHeader(resp.Header.Values("Allow"))
+	d := httpMethodDetails{Severity: "info", Method: "OPTIONS", Status: resp.StatusCode, Allowed: allowed}
+	value := "no Allow header (" + resp.Status + ")"
+	if len(allowed) > 0 {
+		value = "Allow: " + strings.Join(allowed, ", ")
+	}
+	emit(database.Result{ScanID: scanID, ResultType: "http_method", Key: "OPTIONS", Value: value, Details: detailsJSON(d)})
+
+	for _, method := range riskyMethods {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		url, header := target+"/", http.Header{}
+		switch method {
+		case "TRACE":
+			header.Set(traceMarkerHeader, token)
+		case "PUT", "DELETE":
+			url = probePath
+		}
+
+		resp, body, err := sendMethod(ctx, &noFollow, method, url, header, progress)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			progress(fmt.Sprintf("%s: %v", method, err))
+			continue
+		}
+
+		d := evaluateMethodResponse(method, resp.StatusCode, slices.Contains(allowed, method))
+		if method == "TRACE" {
+			d.Reflected = strings.Contains(string(body), token)
+		}
+		if d.Severity != "info" {
+			d.Exchange = captureExchange(evidence, resp, body)
+		}
+
+		value := resp.Status
+		switch {
+		case d.Enabled:
+			value += " (enabled)"
+		case d.Responded:
+			value += " (not rejected)"
+		}
+		emit(database.Result{ScanID: scanID, ResultType: "http_method", Key: method, Value: value, Details: detailsJSON(d)})
+	}
+	return nil
+}
+
+// sendMethod sends one bodiless request and returns the response with up to
+// 4 KB of its body. A CONNECT response's body is never read: after a 2xx it
+// is the tunnel itself and would block until the server hangs up.
+func sendMethod(ctx context.Context, client *http.Client, method, url string, header http.Header, progress func(string)) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+
+	resp, err := doWithRetry(ctx, client, req, progress)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if method == "CONNECT" {
+		return resp, nil, nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*1024))
+	return resp, body, nil
+}
+
+// evaluateMethodResponse classifies the server's answer to one probe. 405 and
+// 501 mean the method is refused; any other status means the server did not
+// reject it outright, and a 2xx means it was accepted.
+func evaluateMethodResponse(method string, status int, advertised bool) httpMethodDetails {
+	d := httpMethodDetails{
+		Severity:   "info",
+		Method:     method,
+		Status:     status,
+		Advertised: advertised,
+		Responded:  status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented,
+		Enabled:    status >= 200 && status < 300,
+	}
+	if d.Enabled {
+		switch method {
+		case "PUT":
+			d.Severity = "high"
+		case "TRACE":
+			d.Severity = "medium"
+		}
+	}
+	return d
+}
+
+// parseAllowHeader splits Allow header values into upper-cased methods,
+// without duplicates.
+func parseAllowHeader(values []string) []string {
+	var methods []string
+	for _, v := range values {
+		for _, m := range strings.Split(v, ",") {
+			if m = strings.ToUpper(strings.TrimSpace(m)); m != "" && !slices.Contains(methods, m) {
+				methods = append(methods, m)
+			}
+		}
+	}
+	return methods
+}
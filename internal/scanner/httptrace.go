@@ -0,0 +1,253 @@
+package scanner
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const (
+	// maxTraceHops caps how many redirects http_trace follows.
+	maxTraceHops = 10
+	// maxTraceBody bounds how much of each response is read for its timing.
+	maxTraceBody = 1 << 20
+)
+
+// cdnHeaders names the CDN or edge cache that sets each response header.
+var cdnHeaders = []struct{ header, cdn string }{
+	{"Cf-Ray", "Cloudflare"},
+	{"X-Amz-Cf-Id", "Amazon CloudFront"},
+	{"X-Akamai-Transformed", "Akamai"},
+	{"Akamai-Grn", "Akamai"},
+	{"X-Fastly-Request-Id", "Fastly"},
+	{"X-Served-By", "Fastly"},
+	{"X-Azure-Ref", "Azure Front Door"},
+	{"X-Vercel-Id", "Vercel"},
+	{"X-Nf-Request-Id", "Netlify"},
+	{"X-Cdn", ""}, // value names the CDN
+}
+
+// traceClient returns a client for http_trace on its own copy of the shared
+// transport with keep-alives off, so every hop opens a new connection and
+// its DNS, connect and TLS phases are measured rather than skipped.
+func (e *Executor) traceClient(timeout time.Duration) *http.Client {
+	t := e.transport.Clone()
+	t.DisableKeepAlives = true
+	return &http.Client{
+		Transport:     t,
+		Timeout:       timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// --- HTTP Trace ---
+
+// traceHTTP requests the target and follows its redirects one hop at a time,
+// timing each with httptrace: DNS lookup, TCP connect, TLS handshake, time
+// to first byte and total. Each hop becomes a "timing" result, followed by a
+// summary of the chain. Requests are not retried, so a slow answer shows as
+// slow. Behind a proxy the connect timings are to the proxy.
+func traceHTTP(ctx context.Context, client *http.Client, scanID int64, target string, progress func(string)) ([]database.Result, error) {
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+
+	var results []database.Result
+	var totalMs float64
+	final := ""
+	cdns := []string{}
+	seen := make(map[string]bool)
+	next := target
+	stopped := ""
+	hop := 0
+	for ; next != "" && hop < maxTraceHops; hop++ {
+		if seen[next] {
+			stopped = "redirect loop at " + next
+			break
+		}
+		seen[next] = true
+
+		d, resp, err := traceHop(ctx, client, next)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			if hop == 0 {
+				return nil, fmt.Errorf("fetch URL: %w", err)
+			}
+			stopped = fmt.Sprintf("hop %d failed: %v", hop+1, err)
+			progress(stopped)
+			break
+		}
+		d.Hop = hop + 1
+		totalMs += d.TotalMs
+		final = d.URL
+		if d.CDN != "" && !slices.Contains(cdns, d.CDN) {
+			cdns = append(cdns, d.CDN)
+		}
+
+		next = ""
+		if loc := resp.Header.Get("Location"); loc != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			if u, err := resp.Request.URL.Parse(loc); err == nil {
+				next = u.String()
+				d.Location = next
+			}
+		}
+
+		value := fmt.Sprintf("%s in %s (dns %s, connect %s, tls %s, ttfb %s)", resp.Status,
+			fmtMs(d.TotalMs), fmtMs(d.DNSMs), fmtMs(d.ConnectMs), fmtMs(d.TLSMs), fmtMs(d.TTFBMs))
+		progress(fmt.Sprintf("Hop %d: %s %s", d.Hop, d.URL, value))
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "timing",
+			Key:        fmt.Sprintf("hop %d: %s", d.Hop, d.URL),
+			Value:      value,
+			Details:    detailsJSON(d),
+		})
+	}
+	if next != "" && stopped == "" {
+		stopped = fmt.Sprintf("stopped after %d redirects", maxTraceHops)
+	}
+
+	totalMs = math.Round(totalMs*10) / 10
+	value := fmt.Sprintf("%d hop(s), %s to %s", len(results), fmtMs(totalMs), final)
+	if len(cdns) > 0 {
+		value += " via " + strings.Join(cdns, ", ")
+	}
+	if stopped != "" {
+		value += "; " + stopped
+	}
+	results = append(results, database.Result{
+		ScanID:     scanID,
+		ResultType: "timing",
+		Key:        "chain",
+		Value:      value,
+		Details: detailsJSON(timingChainDetails{
+			Hops:     len(results),
+			TotalMs:  totalMs,
+			FinalURL: final,
+			CDNs:     cdns,
+			Stopped:  stopped,
+		}),
+	})
+	return results, nil
+}
+
+// traceHop sends one GET to u, reads the body, and returns the timings.
+func traceHop(ctx context.Context, client *http.Client, u string) (timingDetails, *http.Response, error) {
+	var (
+		start                    time.Time
+		dnsStart, dnsDone        time.Time
+		connectStart, connectEnd time.Time
+		tlsStart, tlsDone        time.Time
+		firstByte                time.Time
+		remote                   string
+		reused                   bool
+		tlsState                 *tls.ConnectionState
+	)
+	trace := &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { dnsStart = time.Now() },
+		DNSDone:      func(httptrace.DNSDoneInfo) { dnsDone = time.Now() },
+		ConnectStart: func(string, string) { connectStart = time.Now() },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				connectEnd = time.Now()
+			}
+		},
+		TLSHandshakeStart: func() { tlsStart = time.Now() },
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			if err == nil {
+				tlsDone, tlsState = time.Now(), &cs
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			reused = info.Reused
+			if info.Conn != nil {
+				remote = info.Conn.RemoteAddr().String()
+			}
+		},
+		GotFirstResponseByte: func() { firstByte = time.Now() },
+	}
+
+	req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodGet, u, nil)
+	if err != nil {
+		return timingDetails{}, nil, err
+	}
+	req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+	start = time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return timingDetails{}, nil, err
+	}
+	defer resp.Body.Close()
+	n, _ := io.Copy(io.Discard, io.LimitReader(resp.Body, maxTraceBody))
+	end := time.Now()
+
+	d := timingDetails{
+		URL:        u,
+		Status:     resp.StatusCode,
+		Protocol:   resp.Proto,
+		RemoteAddr: remote,
+		Reused:     reused,
+		DNSMs:      msBetween(dnsStart, dnsDone),
+		ConnectMs:  msBetween(connectStart, connectEnd),
+		TLSMs:      msBetween(tlsStart, tlsDone),
+		TTFBMs:     msBetween(start, firstByte),
+		TotalMs:    msBetween(start, end),
+		Bytes:      n,
+		CDN:        cdnFromHeaders(resp.Header),
+	}
+	if tlsState != nil {
+		d.TLSVersion = tls.VersionName(tlsState.Version)
+	}
+	return d, resp, nil
+}
+
+// cdnFromHeaders names the CDN whose headers a response carries, or "".
+func cdnFromHeaders(h http.Header) string {
+	for _, c := range cdnHeaders {
+		if v := h.Get(c.header); v != "" {
+			if c.cdn == "" {
+				return v
+			}
+			return c.cdn
+		}
+	}
+	server := strings.ToLower(h.Get("Server"))
+	switch {
+	case strings.Contains(server, "cloudflare"):
+		return "Cloudflare"
+	case strings.Contains(server, "akamai"):
+		return "Akamai"
+	case strings.Contains(server, "cloudfront"):
+		return "Amazon CloudFront"
+	}
+	return ""
+}
+
+// msBetween is the time from a to b in milliseconds, or 0 when either phase
+// did not happen (a reused connection, plain HTTP, an IP literal).
+func msBetween(a, b time.Time) float64 {
+	if a.IsZero() || b.IsZero() || b.Before(a) {
+		return 0
+	}
+	return msOf(b.Sub(a))
+}
+
+// msOf rounds d to a tenth of a millisecond.
+func msOf(d time.Duration) float64 {
+	return math.Round(float64(d.Microseconds())/100) / 10
+}
+
+func fmtMs(ms float64) string {
+	return fmt.Sprintf("%.1fms", ms)
+}
@@ -0,0 +1,307 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// jsSignature describes how to recognise a JavaScript library and pull its
+// version out of either the script URL or the script body.
+type jsSignature struct {
+	name     string
+	filename *regexp.Regexp
+	content  []*regexp.Regexp
+}
+
+var jsSignatures = []jsSignature{
+	{
+		name:     "jQuery",
+		filename: regexp.MustCompile(`(?i)jquery[.-](\d+\.\d+\.\d+)(?:\.min)?\.js`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`jQuery (?:JavaScript Library )?v(\d+\.\d+\.\d+)`),
+			regexp.MustCompile(`jquery:\s*"(\d+\.\d+\.\d+)"`),
+		},
+	},
+	{
+		name:     "React",
+		filename: regexp.MustCompile(`(?i)react(?:-dom)?@(\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`@license React v(\d+\.\d+\.\d+)`),
+		},
+	},
+	{
+		name:     "AngularJS",
+		filename: regexp.MustCompile(`(?i)angular(?:js)?[@/.-](1\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`AngularJS v(\d+\.\d+\.\d+)`),
+		},
+	},
+	{
+		name:     "Angular",
+		filename: regexp.MustCompile(`(?i)@angular/core@(\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`ng-version="(\d+\.\d+\.\d+)"`),
+		},
+	},
+	{
+		name:     "Vue.js",
+		filename: regexp.MustCompile(`(?i)vue@(\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`Vue\.js v(\d+\.\d+\.\d+)`),
+		},
+	},
+	{
+		name:     "Lodash",
+		filename: regexp.MustCompile(`(?i)lodash[@/.-](\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`@license\s+Lodash[^\n]*\n[^\n]*?(\d+\.\d+\.\d+)`),
+			regexp.MustCompile(`var VERSION\s*=\s*'(\d+\.\d+\.\d+)'`),
+		},
+	},
+	{
+		name:     "Underscore.js",
+		filename: regexp.MustCompile(`(?i)underscore[@/.-](\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`Underscore\.js (\d+\.\d+\.\d+)`),
+		},
+	},
+	{
+		name:     "Bootstrap",
+		filename: regexp.MustCompile(`(?i)bootstrap[@/.-](\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`Bootstrap v(\d+\.\d+\.\d+)`),
+		},
+	},
+	{
+		name:     "Moment.js",
+		filename: regexp.MustCompile(`(?i)moment[@/.-](\d+\.\d+\.\d+)`),
+		content: []*regexp.Regexp{
+			regexp.MustCompile(`moment\.js\s*\n//! version : (\d+\.\d+\.\d+)`),
+		},
+	},
+}
+
+// jsVulnerability marks every version of a library below fixedIn as affected.
+type jsVulnerability struct {
+	fixedIn string
+	cve     string
+}
+
+// jsKnownVulnerabilities is a small bundled mapping of well-known CVEs in the
+// libraries above. It is intentionally conservative rather than exhaustive.
+var jsKnownVulnerabilities = map[string][]jsVulnerability{
+	"jQuery": {
+		{"1.9.0", "CVE-2012-6708"},
+		{"3.0.0", "CVE-2015-9251"},
+		{"3.4.0", "CVE-2019-11358"},
+		{"3.5.0", "CVE-2020-11022"},
+		{"3.5.0", "CVE-2020-11023"},
+	},
+	"AngularJS": {
+		{"1.8.0", "CVE-2020-7676"},
+		{"1.9.0", "CVE-2022-25844"},
+	},
+	"Lodash": {
+		{"4.17.12", "CVE-2019-10744"},
+		{"4.17.21", "CVE-2021-23337"},
+	},
+	"Underscore.js": {
+		{"1.12.1", "CVE-2021-23358"},
+	},
+	"Bootstrap": {
+		{"3.4.1", "CVE-2019-8331"},
+	},
+	"Moment.js": {
+		{"2.29.2", "CVE-2022-24785"},
+		{"2.29.4", "CVE-2022-31129"},
+	},
+}
+
+const (
+	maxJSScripts    = 25
+	maxJSScriptSize = 1024 * 1024
+)
+
+// --- JavaScript Library Fingerprinting ---
+
+func fingerprintJSLibraries(ctx context.Context, client *http.Client, scanID int64, target string, emit func(database.Result), progress func(string)) error {
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+
+	page, base, err := fetchBody(ctx, client, target, 2*1024*1024, progress)
+	if err != nil {
+		return fmt.Errorf("fetch page: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	record := func(name, version, source string) {
+		dedup := name + "@" + version
+		if seen[dedup] {
+			return
+		}
+		seen[dedup] = true
+
+		details := jsLibraryDetails{Source: source}
+		if version != "" {
+			details.CVEs = jsLibraryCVEs(name, version)
+			details.Outdated = len(details.CVEs) > 0
+		} else {
+			version = "unknown"
+		}
+		emit(database.Result{
+			ScanID:     scanID,
+			ResultType: "js_library",
+			Key:        name,
+			Value:      version,
+			Details:    detailsJSON(details),
+		})
+	}
+
+	// Inline scripts and markup hints on the page itself
+	for _, sig := range jsSignatures {
+		if version := matchFirst(sig.content, page); version != "" {
+			record(sig.name, version, base.String())
+		}
+	}
+
+	for i, src := range extractScriptSources(page) {
+		if i >= maxJSScripts {
+			break
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ref, err := base.Parse(src)
+		if err != nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+			continue
+		}
+		scriptURL := ref.String()
+
+		// A versioned filename is often enough on its own
+		matched := false
+		for _, sig := range jsSignatures {
+			if m := sig.filename.FindStringSubmatch(scriptURL); m != nil {
+				record(sig.name, m[1], scriptURL)
+				matched = true
+			}
+		}
+		if matched {
+			continue
+		}
+
+		body, _, err := fetchBody(ctx, client, scriptURL, maxJSScriptSize, progress)
+		if err != nil {
+			continue
+		}
+		for _, sig := range jsSignatures {
+			if version := matchFirst(sig.content, body); version != "" {
+				record(sig.name, version, scriptURL)
+			}
+		}
+	}
+
+	return nil
+}
+
+// fetchBody GETs a URL and returns at most limit bytes of its body along with
+// the final URL after redirects. Rate-limited requests are retried.
+func fetchBody(ctx context.Context, client *http.Client, target string, limit int64, progress func(string)) (string, *url.URL, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return "", nil, err
+	}
+	req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+
+	resp, err := doWithRetry(ctx, client, req, progress)
+	if err != nil {
+		return "", nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", nil, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit))
+	if err != nil {
+		return "", nil, err
+	}
+	return string(body), resp.Request.URL, nil
+}
+
+// extractScriptSources returns the src attribute of every <script> tag.
+func extractScriptSources(html string) []string {
+	var sources []string
+	lower := strings.ToLower(html)
+	idx := 0
+
+	for {
+		pos := strings.Index(lower[idx:], "<script")
+		if pos == -1 {
+			break
+		}
+		pos += idx
+		end := strings.Index(lower[pos:], ">")
+		if end == -1 {
+			break
+		}
+		tag := html[pos : pos+end+1]
+		if src := extractAttr(tag, "src"); src != "" {
+			sources = append(sources, src)
+		}
+		idx = pos + end + 1
+	}
+
+	return sources
+}
+
+func matchFirst(patterns []*regexp.Regexp, s string) string {
+	for _, re := range patterns {
+		if m := re.FindStringSubmatch(s); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// jsLibraryCVEs returns the bundled CVEs affecting the given library version.
+func jsLibraryCVEs(name, version string) []string {
+	var cves []string
+	for _, v := range jsKnownVulnerabilities[name] {
+		if compareVersions(version, v.fixedIn) < 0 {
+			cves = append(cves, v.cve)
+		}
+	}
+	return cves
+}
+
+// compareVersions compares dotted numeric versions, returning -1, 0, or 1.
+func compareVersions(a, b string) int {
+	pa := strings.Split(a, ".")
+	pb := strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na < nb {
+			return -1
+		}
+		if na > nb {
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,197 @@
+package scanner
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DetectFileType refines http.DetectContentType with magic-byte checks for
+// formats it reports as application/octet-stream or plain ZIP: ISO-BMFF
+// containers (HEIC/AVIF/MP4/MOV/CR3), camera RAW, OOXML/ODF documents and
+// legacy OLE2 Office files.
+func DetectFileType(data []byte) string {
+	return detectFileType(data, bytes.NewReader(data), int64(len(data)))
+}
+
+// DetectFileTypeAt is DetectFileType over an io.ReaderAt, reading only the
+// head of the file (and a ZIP's central directory), so an upload can be
+// checked before any extractor runs.
+func DetectFileTypeAt(r io.ReaderAt, size int64) (string, error) {
+	head, err := readRange(r, 0, min(size, rangedHeadSize))
+	if err != nil {
+		return "", err
+	}
+	return detectFileType(head, r, size), nil
+}
+
+// detectFileType sniffs the leading bytes in data, consulting r only for
+// formats identified by structure elsewhere in the file (ZIP central directory).
+func detectFileType(data []byte, r io.ReaderAt, size int64) string {
+	if t := sniffISOBMFF(data); t != "" {
+		return t
+	}
+	if t := sniffRAW(data); t != "" {
+		return t
+	}
+
+	switch {
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return "image/webp"
+	case bytes.HasPrefix(data, []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")):
+		return "application/x-ole-storage"
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		if t := sniffZIP(r, size); t != "" {
+			return t
+		}
+		return "application/zip"
+	}
+
+	return http.DetectContentType(data)
+}
+
+// isoBrands maps ISO-BMFF major/compatible brands to MIME types, in the order
+// they should win when a file lists several.
+var isoBrands = []struct {
+	brand string
+	mime  string
+}{
+	{"crx ", "image/x-canon-cr3"},
+	{"avif", "image/avif"},
+	{"avis", "image/avif"},
+	{"heic", "image/heic"},
+	{"heix", "image/heic"},
+	{"heim", "image/heic"},
+	{"heis", "image/heic"},
+	{"hevc", "image/heic-sequence"},
+	{"hevx", "image/heic-sequence"},
+	{"mif1", "image/heif"},
+	{"msf1", "image/heif-sequence"},
+	{"qt  ", "video/quicktime"},
+	{"M4A ", "audio/mp4"},
+	{"M4V ", "video/mp4"},
+	{"3gp4", "video/3gpp"},
+	{"3gp5", "video/3gpp"},
+	{"3g2a", "video/3gpp2"},
+	{"isom", "video/mp4"},
+	{"iso2", "video/mp4"},
+	{"mp41", "video/mp4"},
+	{"mp42", "video/mp4"},
+	{"avc1", "video/mp4"},
+	{"dash", "video/mp4"},
+}
+
+// sniffISOBMFF inspects the leading ftyp box of an ISO base media file.
+func sniffISOBMFF(data []byte) string {
+	if len(data) < 16 || string(data[4:8]) != "ftyp" {
+		return ""
+	}
+	size := int(binary.BigEndian.Uint32(data[:4]))
+	if size < 16 || size > len(data) {
+		size = len(data)
+	}
+
+	brands := map[string]bool{string(data[8:12]): true}
+	for off := 16; off+4 <= size; off += 4 {
+		brands[string(data[off:off+4])] = true
+	}
+	for _, b := range isoBrands {
+		if brands[b.brand] {
+			return b.mime
+		}
+	}
+	return "video/mp4"
+}
+
+// sniffRAW recognises camera RAW formats by their vendor headers. Plain TIFF
+// and TIFF-based RAW without a distinctive header (NEF, ARW) report as TIFF.
+func sniffRAW(data []byte) string {
+	switch {
+	case len(data) >= 16 && string(data[:15]) == "FUJIFILMCCD-RAW":
+		return "image/x-fuji-raf"
+	case len(data) >= 4 && (string(data[:4]) == "IIRO" || string(data[:4]) == "IIRS" || string(data[:4]) == "MMOR"):
+		return "image/x-olympus-orf"
+	case len(data) >= 4 && string(data[:4]) == "IIU\x00":
+		return "image/x-panasonic-rw2"
+	case !isTIFF(data):
+		return ""
+	case len(data) >= 10 && string(data[8:10]) == "CR":
+		return "image/x-canon-cr2"
+	case tiffHasTag(data, 0xC612): // DNGVersion
+		return "image/x-adobe-dng"
+	}
+	return "image/tiff"
+}
+
+// tiffHasTag reports whether IFD0 of a TIFF stream contains the given tag.
+func tiffHasTag(data []byte, tag uint16) bool {
+	var bo binary.ByteOrder = binary.LittleEndian
+	if data[0] == 'M' {
+		bo = binary.BigEndian
+	}
+	offset := int(bo.Uint32(data[4:8]))
+	if offset+2 > len(data) {
+		return false
+	}
+	count := int(bo.Uint16(data[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(data) {
+			return false
+		}
+		if bo.Uint16(data[entry:]) == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// ooxmlParts maps the main part of each Office Open XML document type to its
+// MIME type.
+var ooxmlParts = []struct {
+	part string
+	mime string
+}{
+	{"word/document.xml", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+	{"xl/workbook.xml", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+	{"ppt/presentation.xml", "application/vnd.openxmlformats-officedocument.presentationml.presentation"},
+}
+
+// sniffZIP tells OOXML, ODF/EPUB and JAR archives apart from plain ZIP.
+func sniffZIP(r io.ReaderAt, size int64) string {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return ""
+	}
+
+	names := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		names[f.Name] = f
+	}
+
+	// ODF and EPUB declare their type in a stored "mimetype" entry
+	if f, ok := names["mimetype"]; ok {
+		if rc, err := f.Open(); err == nil {
+			mt, _ := io.ReadAll(io.LimitReader(rc, 128))
+			rc.Close()
+			if s := strings.TrimSpace(string(mt)); strings.Contains(s, "/") {
+				return s
+			}
+		}
+	}
+
+	if _, ok := names["[Content_Types].xml"]; ok {
+		for _, p := range ooxmlParts {
+			if _, ok := names[p.part]; ok {
+				return p.mime
+			}
+		}
+	}
+	if _, ok := names["META-INF/MANIFEST.MF"]; ok {
+		return "application/java-archive"
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package scanner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxMoovSize caps how much of the moov atom is read; it holds per-sample
+// tables that grow with duration, but the headers we want come first.
+const maxMoovSize = 16 * 1024 * 1024
+
+// mp4Epoch is the ISO-BMFF time origin (seconds since 1904-01-01 UTC).
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// --- MP4 / QuickTime ---
+
+// extractMP4Metadata walks the top-level atoms of an ISO-BMFF file via
+// ReadAt, so only the ftyp and moov atoms are read regardless of file size.
+func extractMP4Metadata(r io.ReaderAt, size int64) []FileMetaResult {
+	var results []FileMetaResult
+
+	for off := int64(0); off+8 <= size; {
+		boxType, boxSize, hdr, ok := readBoxHeader(r, off, size)
+		if !ok {
+			break
+		}
+
+		switch boxType {
+		case "ftyp":
+			buf := make([]byte, 4)
+			if _, err := r.ReadAt(buf, off+hdr); err == nil {
+				results = append(results, FileMetaResult{Key: "major_brand", Value: strings.TrimSpace(string(buf))})
+			}
+		case "moov":
+			n := boxSize - hdr
+			if n > maxMoovSize {
+				n = maxMoovSize
+			}
+			moov := make([]byte, n)
+			if _, err := r.ReadAt(moov, off+hdr); err != nil && err != io.EOF {
+				return results
+			}
+			results = append(results, parseMoov(moov)...)
+			return results
+		}
+		off += boxSize
+	}
+
+	return results
+}
+
+// readBoxHeader returns the type, total size and header length of the box at
+// off, handling 64-bit and to-end-of-file sizes.
+func readBoxHeader(r io.ReaderAt, off, fileSize int64) (string, int64, int64, bool) {
+	var hdr [16]byte
+	if _, err := r.ReadAt(hdr[:8], off); err != nil {
+		return "", 0, 0, false
+	}
+	boxSize := int64(binary.BigEndian.Uint32(hdr[:4]))
+	boxType := string(hdr[4:8])
+	hdrLen := int64(8)
+
+	switch boxSize {
+	case 0:
+		boxSize = fileSize - off
+	case 1:
+		if _, err := r.ReadAt(hdr[8:16], off+8); err != nil {
+			return "", 0, 0, false
+		}
+		boxSize = int64(binary.BigEndian.Uint64(hdr[8:16]))
+		hdrLen = 16
+	}
+	if boxSize < hdrLen || off+boxSize > fileSize {
+		return "", 0, 0, false
+	}
+	return boxType, boxSize, hdrLen, true
+}
+
+// parseMoov extracts movie header, track and Apple location metadata from the
+// body of a moov atom.
+func parseMoov(moov []byte) []FileMetaResult {
+	var results []FileMetaResult
+	tracks := 0
+
+	walkBoxes(moov, func(boxType string, body []byte) {
+		switch boxType {
+		case "mvhd":
+			results = append(results, parseMvhd(body)...)
+		case "trak":
+			tracks++
+			walkBoxes(body, func(t string, b []byte) {
+				if t == "tkhd" {
+					if w, h := parseTkhdDimensions(b); w > 0 && h > 0 {
+						results = append(results,
+							FileMetaResult{Key: "width", Value: fmt.Sprintf("%d px", w)},
+							FileMetaResult{Key: "height", Value: fmt.Sprintf("%d px", h)},
+						)
+					}
+				}
+			})
+		case "udta":
+			walkBoxes(body, func(t string, b []byte) {
+				// ©xyz: 16-bit length, 16-bit language, ISO 6709 string
+				if t == "\xa9xyz" && len(b) > 4 {
+					n := int(binary.BigEndian.Uint16(b[:2]))
+					if 4+n <= len(b) {
+						results = append(results, FileMetaResult{Key: "gps_location", Value: string(b[4 : 4+n])})
+					}
+				}
+			})
+		}
+	})
+
+	if tracks > 0 {
+		results = append(results, FileMetaResult{Key: "track_count", Value: fmt.Sprintf("%d", tracks)})
+	}
+	return results
+}
+
+// walkBoxes calls fn for each child box contained in data.
+func walkBoxes(data []byte, fn func(boxType string, body []byte)) {
+	for off := 0; off+8 <= len(data); {
+		size := int(binary.BigEndian.Uint32(data[off:]))
+		if size < 8 || off+size > len(data) {
+			return
+		}
+		fn(string(data[off+4:off+8]), data[off+8:off+size])
+		off += size
+	}
+}
+
+func parseMvhd(b []byte) []FileMetaResult {
+	if len(b) < 4 {
+		return nil
+	}
+	var created, modified, duration uint64
+	var timescale uint32
+	switch b[0] {
+	case 0:
+		if len(b) < 20 {
+			return nil
+		}
+		created = uint64(binary.BigEndian.Uint32(b[4:]))
+		modified = uint64(binary.BigEndian.Uint32(b[8:]))
+		timescale = binary.BigEndian.Uint32(b[12:])
+		duration = uint64(binary.BigEndian.Uint32(b[16:]))
+	case 1:
+		if len(b) < 32 {
+			return nil
+		}
+		created = binary.BigEndian.Uint64(b[4:])
+		modified = binary.BigEndian.Uint64(b[12:])
+		timescale = binary.BigEndian.Uint32(b[20:])
+		duration = binary.BigEndian.Uint64(b[24:])
+	default:
+		return nil
+	}
+
+	var results []FileMetaResult
+	if created > 0 {
+		results = append(results, FileMetaResult{Key: "creation_date", Value: mp4Epoch.Add(time.Duration(created) * time.Second).Format(time.RFC3339)})
+	}
+	if modified > 0 {
+		results = append(results, FileMetaResult{Key: "modification_date", Value: mp4Epoch.Add(time.Duration(modified) * time.Second).Format(time.RFC3339)})
+	}
+	if timescale > 0 && duration > 0 {
+		d := time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+		results = append(results, FileMetaResult{Key: "duration", Value: d.Round(time.Millisecond).String()})
+	}
+	return results
+}
+
+// parseTkhdDimensions returns a track's presentation size; audio tracks are 0x0.
+func parseTkhdDimensions(b []byte) (int, int) {
+	// width and height are the last two 16.16 fixed-point fields
+	var end int
+	switch {
+	case len(b) >= 84 && b[0] == 0:
+		end = 84
+	case len(b) >= 96 && b[0] == 1:
+		end = 96
+	default:
+		return 0, 0
+	}
+	w := int(binary.BigEndian.Uint32(b[end-8:]) >> 16)
+	h := int(binary.BigEndian.Uint32(b[end-4:]) >> 16)
+	return w, h
+}
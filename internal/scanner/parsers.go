@@ -2,7 +2,6 @@ package scanner
 
 import (
 	"encoding/xml"
-	"fmt"
 	"strings"
 
 	"github.com/jamesruggles/reconsuite/internal/database"
@@ -59,12 +58,18 @@ func parseDigResults(scanID int64, raw string) []database.Result {
 		}
 		fields := strings.Fields(line)
 		if len(fields) >= 5 {
+			d := dnsDetails{Name: fields[0], TTL: fields[1], Class: fields[2]}
+			value := strings.Join(fields[4:], " ")
+			if fields[3] == "TXT" {
+				value = txtValue(digRData(line))
+				d.Category, d.Service = classifyTXT(fields[0], value)
+			}
 			results = append(results, database.Result{
 				ScanID:     scanID,
 				ResultType: "dns",
 				Key:        fields[3], // record type (A, MX, NS, etc.)
-				Value:      strings.Join(fields[4:], " "),
-				Details:    fmt.Sprintf(`{"name":"%s","ttl":"%s","class":"%s"}`, fields[0], fields[1], fields[2]),
+				Value:      value,
+				Details:    detailsJSON(d),
 			})
 		}
 	}
@@ -159,7 +164,7 @@ func parseNmapResults(scanID int64, raw string) []database.Result {
 				ResultType: "port",
 				Key:        port.PortID + "/" + port.Protocol,
 				Value:      port.State.State,
-				Details:    fmt.Sprintf(`{"host":"%s","service":"%s","reason":"%s"}`, addr, svcInfo, port.State.Reason),
+				Details:    detailsJSON(portDetails{Host: addr, Service: svcInfo, Reason: port.State.Reason}),
 			})
 		}
 
@@ -169,7 +174,7 @@ func parseNmapResults(scanID int64, raw string) []database.Result {
 				ResultType: "os",
 				Key:        "os_match",
 				Value:      osMatch.Name,
-				Details:    fmt.Sprintf(`{"accuracy":"%s","host":"%s"}`, osMatch.Accuracy, addr),
+				Details:    detailsJSON(osDetails{Accuracy: osMatch.Accuracy, Host: addr}),
 			})
 		}
 	}
@@ -213,3 +218,226 @@ func parseCurlResults(scanID int64, raw string) []database.Result {
 
 	return results
 }
+
+// --- WhatWeb Parser ---
+
+// whatwebInfoPlugins report facts about a page rather than software on it;
+// they become "metadata" results instead of "technology" ones.
+var whatwebInfoPlugins = map[string]bool{
+	"Access-Control-Allow-Methods": true, "Allow": true, "Content-Language": true,
+	"Content-Security-Policy": true, "Cookies": true, "Country": true, "Email": true,
+	"Frame": true, "HTML5": true, "HttpOnly": true, "IP": true, "Meta-Author": true,
+	"Meta-Refresh-Redirect": true, "Object": true, "Open-Graph-Protocol": true,
+	"PasswordField": true, "RedirectLocation": true, "Script": true,
+	"Strict-Transport-Security": true, "Title": true, "UncommonHeaders": true,
+	"Via-Proxy": true, "X-Frame-Options": true, "X-UA-Compatible": true,
+	"X-XSS-Protection": true,
+}
+
+// parseWhatWebResults parses WhatWeb's brief output, one line per URL
+// fetched: "https://example.com [200 OK] Apache[2.4.52], Country[US],
+// HTTPServer[Ubuntu Linux][Apache/2.4.52 (Ubuntu)], PHP[8.1.2]". Software
+// plugins become "technology" results in the same shape as those
+// metadata_extract derives from headers.
+func parseWhatWebResults(scanID int64, raw string) []database.Result {
+	var techs technologySet
+	var info []database.Result
+	seenInfo := make(map[string]bool)
+
+	for _, line := range strings.Split(raw, "\n") {
+		_, rest, ok := strings.Cut(strings.TrimSpace(line), " [")
+		if !ok {
+			continue
+		}
+		_, plugins, ok := strings.Cut(rest, "]") // skip the status
+		if !ok {
+			continue
+		}
+
+		for _, plugin := range splitWhatWebPlugins(plugins) {
+			name, values := parseWhatWebPlugin(plugin)
+			switch {
+			case name == "":
+			case whatwebInfoPlugins[name]:
+				key := "whatweb:" + strings.ToLower(name)
+				value := strings.Join(values, " ")
+				if !seenInfo[key+"\x00"+value] {
+					seenInfo[key+"\x00"+value] = true
+					info = append(info, database.Result{
+						ScanID: scanID, ResultType: "metadata", Key: key, Value: value,
+					})
+				}
+			case name == "HTTPServer":
+				// HTTPServer[os][server header], or just [server header]
+				var os string
+				if len(values) > 1 {
+					os, values = values[0], values[1:]
+				}
+				for i, t := range parseProductTokens(strings.Join(values, " ")) {
+					if i == 0 && t.OS == "" {
+						t.OS = os
+					}
+					techs.add(t, "whatweb")
+				}
+			case name == "X-Powered-By" || name == "PoweredBy" || name == "MetaGenerator":
+				for _, t := range parseProductTokens(strings.Join(values, ", ")) {
+					techs.add(t, "whatweb")
+				}
+			default:
+				t := technology{Product: name}
+				for _, v := range values {
+					if v != "" && v[0] >= '0' && v[0] <= '9' {
+						t.Version = v
+						break
+					}
+				}
+				techs.add(t, "whatweb")
+			}
+		}
+	}
+
+	return append(techs.results(scanID), info...)
+}
+
+// splitWhatWebPlugins splits a plugin list on the commas outside brackets,
+// since values such as titles may contain commas of their own.
+func splitWhatWebPlugins(s string) []string {
+	var plugins []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				plugins = append(plugins, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(plugins, strings.TrimSpace(s[start:]))
+}
+
+// parseWhatWebPlugin splits "Name[value][value]" into its name and values.
+func parseWhatWebPlugin(p string) (string, []string) {
+	name, rest, _ := strings.Cut(p, "[")
+	var values []string
+	for rest != "" {
+		v, after, _ := strings.Cut(rest, "]")
+		values = append(values, v)
+		rest = strings.TrimPrefix(after, "[")
+	}
+	return strings.TrimSpace(name), values
+}
+
+// --- SNMP Walk Parser ---
+
+// snmpOIDNames maps common OID prefixes (without instance suffix) to their MIB
+// object names.
+var snmpOIDNames = map[string]string{
+	"1.3.6.1.2.1.1.1":           "sysDescr",
+	"1.3.6.1.2.1.1.2":           "sysObjectID",
+	"1.3.6.1.2.1.1.3":           "sysUpTime",
+	"1.3.6.1.2.1.1.4":           "sysContact",
+	"1.3.6.1.2.1.1.5":           "sysName",
+	"1.3.6.1.2.1.1.6":           "sysLocation",
+	"1.3.6.1.2.1.1.7":           "sysServices",
+	"1.3.6.1.2.1.2.2.1.2":       "ifDescr",
+	"1.3.6.1.2.1.2.2.1.6":       "ifPhysAddress",
+	"1.3.6.1.2.1.4.20.1.1":      "ipAdEntAddr",
+	"1.3.6.1.2.1.25.1.1":        "hrSystemUptime",
+	"1.3.6.1.2.1.25.2.3.1.3":    "hrStorageDescr",
+	"1.3.6.1.2.1.25.4.2.1.2":    "hrSWRunName",
+	"1.3.6.1.2.1.25.4.2.1.4":    "hrSWRunPath",
+	"1.3.6.1.2.1.25.6.3.1.2":    "hrSWInstalledName",
+	"1.3.6.1.2.1.6.13.1.1":      "tcpConnState",
+	"1.3.6.1.4.1.77.1.2.25.1.1": "svUserName",
+	"1.3.6.1.4.1.77.1.4.1":      "domPrimaryDomain",
+}
+
+// snmpHighValue are the objects that identify the host, its owner, or its
+// accounts — the first things worth reading in a walk.
+var snmpHighValue = map[string]bool{
+	"sysDescr": true, "sysName": true, "sysContact": true, "sysLocation": true,
+	"svUserName": true, "domPrimaryDomain": true,
+}
+
+// parseSnmpWalkResults parses net-snmp output lines of the form
+// "iso.3.6.1.2.1.1.1.0 = STRING: \"Linux host\"". Continuation lines of
+// multi-line strings are folded into the preceding value.
+func parseSnmpWalkResults(scanID int64, raw string) []database.Result {
+	var results []database.Result
+
+	for _, line := range strings.Split(raw, "\n") {
+		line = strings.TrimRight(line, "\r")
+		oid, rest, found := strings.Cut(line, " = ")
+		if !found || strings.ContainsAny(oid, " \t") {
+			if len(results) > 0 && strings.TrimSpace(line) != "" {
+				last := &results[len(results)-1]
+				last.Value += "\n" + strings.TrimSuffix(line, `"`)
+			}
+			continue
+		}
+
+		valueType, value := "", rest
+		if t, v, ok := strings.Cut(rest, ": "); ok && !strings.HasPrefix(rest, `"`) {
+			valueType, value = t, v
+		}
+		switch valueType {
+		case "STRING":
+			value = strings.TrimPrefix(value, `"`)
+			if strings.HasSuffix(value, `"`) {
+				value = strings.TrimSuffix(value, `"`)
+			}
+		case "Timeticks":
+			// "(12345) 0:02:03.45" — keep the human-readable part
+			if i := strings.Index(value, ") "); i != -1 {
+				value = value[i+2:]
+			}
+		case "":
+			value = strings.Trim(value, `"`)
+		}
+
+		name, numericOID := snmpObjectName(oid)
+		high := snmpHighValue[strings.SplitN(name, ".", 2)[0]]
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "snmp",
+			Key:        name,
+			Value:      value,
+			Details:    detailsJSON(snmpDetails{OID: numericOID, Type: valueType, HighValue: high}),
+		})
+	}
+
+	return results
+}
+
+// snmpObjectName returns a friendly "name.instance" key and the numeric OID.
+// Output already translated by MIBs ("SNMPv2-MIB::sysName.0") is kept as is.
+func snmpObjectName(oid string) (string, string) {
+	if _, name, ok := strings.Cut(oid, "::"); ok {
+		return name, oid
+	}
+
+	numeric := strings.TrimPrefix(oid, ".")
+	if strings.HasPrefix(numeric, "iso.") {
+		numeric = "1." + strings.TrimPrefix(numeric, "iso.")
+	}
+
+	// Longest known prefix wins; the remainder is the instance index
+	for prefix := numeric; prefix != ""; {
+		if name, ok := snmpOIDNames[prefix]; ok {
+			return name + strings.TrimPrefix(numeric, prefix), numeric
+		}
+		i := strings.LastIndex(prefix, ".")
+		if i == -1 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return numeric, numeric
+}
@@ -0,0 +1,44 @@
+package scanner
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseNmapResultsDetailsJSON(t *testing.T) {
+	tests := []struct {
+		name, product, version, service string
+	}{
+		{"plain", "OpenSSH", "8.9", "ssh (OpenSSH 8.9)"},
+		{"quotes", `Acme "Secure" Server`, `1.0"`, `ssh (Acme "Secure" Server 1.0")`},
+		{"backslashes", `C:\Program Files\httpd`, `2.4\`, `ssh (C:\Program Files\httpd 2.4\)`},
+		{"mixed", `a\"b`, "", `ssh (a\"b)`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := `<nmaprun><host><address addr="192.0.2.1" addrtype="ipv4"/><ports>` +
+				`<port protocol="tcp" portid="22"><state state="open" reason="syn-ack"/>` +
+				`<service name="ssh" product="` + xmlAttr(tt.product) + `" version="` + xmlAttr(tt.version) + `"/>` +
+				`</port></ports></host></nmaprun>`
+			results := parseNmapResults(1, raw)
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			details := results[0].Details
+			if !json.Valid([]byte(details)) {
+				t.Fatalf("Details is not valid JSON: %s", details)
+			}
+			var d portDetails
+			if err := json.Unmarshal([]byte(details), &d); err != nil {
+				t.Fatalf("unmarshal Details: %v", err)
+			}
+			if d.Service != tt.service {
+				t.Errorf("service = %q, want %q", d.Service, tt.service)
+			}
+		})
+	}
+}
+
+// xmlAttr escapes s for use inside a double-quoted XML attribute.
+var xmlAttr = strings.NewReplacer(`&`, "&amp;", `<`, "&lt;", `"`, "&quot;").Replace
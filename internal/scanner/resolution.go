@@ -0,0 +1,64 @@
+package scanner
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const resolutionTimeout = 5 * time.Second
+
+// --- Target Resolution ---
+
+// resolveTarget records the addresses target's hostname pointed to when the
+// scan ran, so findings can be correlated after DNS changes. It returns nil
+// for IP literals, CIDR ranges, multi-host targets and lookups that fail.
+func resolveTarget(ctx context.Context, resolver *net.Resolver, scanID int64, target string) *database.Result {
+	host := targetHostname(target)
+	if host == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolutionTimeout)
+	defer cancel()
+	addrs, err := resolver.LookupHost(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return nil
+	}
+	sort.Strings(addrs)
+
+	return &database.Result{
+		ScanID:     scanID,
+		ResultType: "resolution",
+		Key:        host,
+		Value:      strings.Join(addrs, ", "),
+		Details:    detailsJSON(resolutionDetails{Addresses: addrs}),
+	}
+}
+
+// targetHostname extracts the hostname from a URL, host:port or bare host
+// target, or returns "" when there is nothing to resolve.
+func targetHostname(target string) string {
+	target = strings.TrimSpace(target)
+	if target == "" || strings.ContainsAny(target, " ,\t\n/@") && !strings.Contains(target, "://") {
+		return ""
+	}
+
+	host := target
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		host = u.Hostname()
+	} else if h, _, err := net.SplitHostPort(target); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	if host == "" || net.ParseIP(strings.Trim(host, "[]")) != nil || !strings.Contains(host, ".") {
+		return ""
+	}
+	return host
+}
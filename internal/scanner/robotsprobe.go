@@ -0,0 +1,186 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const (
+	// maxDisallowedProbes caps how many Disallow paths one scan requests.
+	maxDisallowedProbes = 100
+	// maxDisallowedBody bounds how much of each response is read.
+	maxDisallowedBody = 64 * 1024
+)
+
+// probeDisallowedParam reads robots_sitemap's probe_disallowed parameter.
+func probeDisallowedParam(param string) (bool, error) {
+	switch param {
+	case "", "false":
+		return false, nil
+	case "true":
+		return true, nil
+	}
+	return false, fmt.Errorf("probe_disallowed must be true or false")
+}
+
+// --- Disallowed Path Probe ---
+
+// probeDisallowed requests each path robots.txt disallows, as found in the
+// results of fetchRobotsSitemap, and reports those answering 200 with content
+// as "disallowed_live" results. Up to workers paths are fetched at once,
+// unless robots.txt sets a Crawl-delay for all agents, in which case they
+// are fetched one at a time that far apart. A 200 that matches what a path
+// that cannot exist returns (a catch-all page or a redirect to the same
+// place) is not a hit.
+func probeDisallowed(ctx context.Context, client *http.Client, scanID int64, target string, robots []database.Result, workers int, evidence bool, emit func(database.Result), progress func(string)) error {
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+	target = strings.TrimRight(target, "/")
+
+	var paths []string
+	var delay time.Duration
+	seen := make(map[string]bool)
+	for _, r := range robots {
+		switch r.ResultType {
+		case "robots":
+			delay = robotsCrawlDelay(r.Value)
+		case "disallowed_path":
+			if p := disallowedProbePath(r.Key); p != "" && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		progress("No disallowed paths to probe")
+		return nil
+	}
+	if len(paths) > maxDisallowedProbes {
+		progress(fmt.Sprintf("Probing the first %d of %d disallowed paths", maxDisallowedProbes, len(paths)))
+		paths = paths[:maxDisallowedProbes]
+	}
+
+	baseResp, baseBody := fetchDisallowed(ctx, client, target+"/raccoonrecon-"+strconv.FormatInt(time.Now().UnixNano(), 36), progress)
+
+	check := func(path string) {
+		resp, body := fetchDisallowed(ctx, client, target+path, progress)
+		if resp == nil {
+			return
+		}
+		if resp.StatusCode != http.StatusOK || len(bytes.TrimSpace(body)) == 0 {
+			progress(fmt.Sprintf("%s: %s", path, resp.Status))
+			return
+		}
+		if baseResp != nil && baseResp.StatusCode == http.StatusOK &&
+			(bytes.Equal(body, baseBody) || resp.Request.URL.String() == baseResp.Request.URL.String()) {
+			progress(path + ": same response as a nonexistent path")
+			return
+		}
+		title := extractHTMLTag(string(body), "title")
+		value := fmt.Sprintf("%s, %d bytes", resp.Status, len(body))
+		if title != "" {
+			value += " — " + title
+		}
+		emit(database.Result{
+			ScanID:     scanID,
+			ResultType: "disallowed_live",
+			Key:        path,
+			Value:      value,
+			Details: detailsJSON(disallowedLiveDetails{
+				Severity:    "low",
+				URL:         resp.Request.URL.String(),
+				Status:      resp.StatusCode,
+				ContentType: resp.Header.Get("Content-Type"),
+				Length:      len(body),
+				Title:       title,
+				Exchange:    captureExchange(evidence, resp, evidenceWindow(body, "")),
+			}),
+		})
+	}
+
+	if delay > 0 {
+		progress(fmt.Sprintf("robots.txt asks for a %s crawl delay; probing %d path(s) one at a time", delay, len(paths)))
+		for _, p := range paths {
+			// the baseline request counts too
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			check(p)
+		}
+		return ctx.Err()
+	}
+	forEachConcurrent(ctx, len(paths), workers, func(i int) { check(paths[i]) })
+	return ctx.Err()
+}
+
+// fetchDisallowed GETs u and reads the start of the body, returning a nil
+// response when the request fails.
+func fetchDisallowed(ctx context.Context, client *http.Client, u string, progress func(string)) (*http.Response, []byte) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil
+	}
+	req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+	resp, err := doWithRetry(ctx, client, req, progress)
+	if err != nil {
+		return nil, nil
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxDisallowedBody))
+	return resp, body
+}
+
+// disallowedProbePath turns a Disallow value into a path to request: the
+// part before any * wildcard, without a $ end anchor. The site root, which
+// disallows everything, is skipped.
+func disallowedProbePath(rule string) string {
+	p, _, _ := strings.Cut(rule, "*")
+	p = strings.TrimSuffix(p, "$")
+	if !strings.HasPrefix(p, "/") || p == "/" {
+		return ""
+	}
+	return p
+}
+
+// robotsCrawlDelay returns the Crawl-delay robots.txt sets for all agents
+// (the "User-agent: *" group), or 0 when there is none.
+func robotsCrawlDelay(robots string) time.Duration {
+	inGroup, wildcard := false, false
+	for _, line := range strings.Split(robots, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "user-agent":
+			// consecutive User-agent lines share one group
+			if !inGroup {
+				inGroup, wildcard = true, false
+			}
+			if value == "*" {
+				wildcard = true
+			}
+		case "crawl-delay":
+			inGroup = false
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && wildcard && secs > 0 {
+				return time.Duration(secs * float64(time.Second))
+			}
+		default:
+			inGroup = false
+		}
+	}
+	return 0
+}
@@ -2,6 +2,7 @@ package scanner
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -73,7 +74,10 @@ func buildDnsReconSpec(target, scanMode string) (tools.ToolSpec, error) {
 	}, nil
 }
 
-func buildNmapSpec(target string, params map[string]string) (tools.ToolSpec, error) {
+// buildNmapSpec builds an nmap invocation. Without privileges nmap is told
+// so via --unprivileged, and scan types that need raw sockets are refused up
+// front instead of failing with nmap's own error.
+func buildNmapSpec(target string, params map[string]string, privileged bool, extra []string) (tools.ToolSpec, error) {
 	if err := tools.ValidateTarget(target); err != nil {
 		return tools.ToolSpec{}, err
 	}
@@ -81,6 +85,14 @@ func buildNmapSpec(target string, params map[string]string) (tools.ToolSpec, err
 	args := []string{"-T4"}
 	scanType := params["scan_type"]
 
+	if !privileged {
+		if scanType == "os" {
+			return tools.ToolSpec{}, fmt.Errorf("OS fingerprinting needs raw sockets (root or CAP_NET_RAW); run as root or set tools.nmap.privileged: true")
+		}
+		args = append(args, "--unprivileged")
+	}
+
+	scripts := strings.ReplaceAll(params["scripts"], " ", "")
 	switch scanType {
 	case "service":
 		args = append(args, "-sV")
@@ -89,18 +101,56 @@ func buildNmapSpec(target string, params map[string]string) (tools.ToolSpec, err
 	case "ping":
 		args = append(args, "-sn")
 	case "banner":
-		args = append(args, "--script=banner")
+		if scripts == "" {
+			scripts = "banner"
+		} else {
+			scripts = "banner," + scripts
+		}
 	default:
 		// Default port scan
 		args = append(args, "-sT")
 	}
+	if scripts != "" {
+		args = append(args, "--script="+scripts)
+	}
+
+	versionArgs, err := nmapVersionArgs(scanType, params)
+	if err != nil {
+		return tools.ToolSpec{}, err
+	}
+	args = append(args, versionArgs...)
+
+	var scriptArgs string
+	if raw := strings.TrimSpace(params["script_args"]); raw != "" {
+		if scripts == "" {
+			return tools.ToolSpec{}, fmt.Errorf("script_args needs scripts to pass them to")
+		}
+		var err error
+		if scriptArgs, err = tools.ValidateNmapScriptArgs(raw); err != nil {
+			return tools.ToolSpec{}, err
+		}
+	}
 
 	if ports := params["ports"]; ports != "" {
-		args = append(args, "-p", tools.SanitizeArg(ports))
+		if err := tools.ValidatePortSpec(ports); err != nil {
+			return tools.ToolSpec{}, err
+		}
+		args = append(args, "-p", strings.ReplaceAll(ports, " ", ""))
 	}
 
+	args = append(args, extra...)
+
 	// Use XML output for parsing
-	args = append(args, "-oX", "-", target)
+	args = append(args, "-oX", "-")
+	if err := tools.ValidateNmapArgs(args); err != nil {
+		return tools.ToolSpec{}, err
+	}
+	// added after ValidateNmapArgs, which refuses --script-args from
+	// extra_args
+	if scriptArgs != "" {
+		args = append(args, "--script-args", scriptArgs)
+	}
+	args = append(args, target)
 
 	return tools.ToolSpec{
 		Name:       "Nmap",
@@ -110,6 +160,36 @@ func buildNmapSpec(target string, params map[string]string) (tools.ToolSpec, err
 	}, nil
 }
 
+// nmapVersionArgs maps the version_intensity (0-9) and version_all
+// parameters of a service scan to --version-intensity and --version-all.
+// Lower intensities try fewer probes per port, so finish sooner and send
+// less; --version-all tries every probe, the same as intensity 9.
+func nmapVersionArgs(scanType string, params map[string]string) ([]string, error) {
+	intensity := strings.TrimSpace(params["version_intensity"])
+	all := params["version_all"]
+	if intensity == "" && (all == "" || all == "false") {
+		return nil, nil
+	}
+	if scanType != "service" {
+		return nil, fmt.Errorf("version_intensity and version_all only apply to service detection scans")
+	}
+	switch all {
+	case "", "false":
+	case "true":
+		if intensity != "" {
+			return nil, fmt.Errorf("give version_intensity or version_all, not both; version_all is intensity 9")
+		}
+		return []string{"--version-all"}, nil
+	default:
+		return nil, fmt.Errorf("version_all must be true or false")
+	}
+	n, err := strconv.Atoi(intensity)
+	if err != nil || n < 0 || n > 9 {
+		return nil, fmt.Errorf("version_intensity must be a number from 0 to 9")
+	}
+	return []string{"--version-intensity", strconv.Itoa(n)}, nil
+}
+
 func buildTracerouteSpec(target string) (tools.ToolSpec, error) {
 	if err := tools.ValidateTarget(target); err != nil {
 		return tools.ToolSpec{}, err
@@ -147,42 +227,57 @@ func buildNetcatSpec(target, port string) (tools.ToolSpec, error) {
 	if port == "" {
 		return tools.ToolSpec{}, fmt.Errorf("port is required for banner grab")
 	}
+	if err := tools.ValidatePort(port); err != nil {
+		return tools.ToolSpec{}, err
+	}
 	return tools.ToolSpec{
 		Name:       "Banner Grab",
 		BinaryName: "nc",
-		Args:       []string{"-w", "5", "-v", target, tools.SanitizeArg(port)},
+		Args:       []string{"-w", "5", "-v", target, port},
 		Timeout:    30 * time.Second,
 	}, nil
 }
 
-func buildCurlSpec(target string) (tools.ToolSpec, error) {
+func buildCurlSpec(target string, auth *basicAuth, extra []string) (tools.ToolSpec, error) {
 	if err := tools.ValidateURL(target); err != nil {
 		return tools.ToolSpec{}, err
 	}
+	args := []string{"-I", "-s", "-L", "--max-time", "15"}
+	if auth != nil {
+		// curl drops credentials when -L follows a redirect to another host
+		args = append(args, "-u", auth.user+":"+auth.pass)
+	}
+	args = append(args, extra...)
 	return tools.ToolSpec{
 		Name:       "HTTP Headers",
 		BinaryName: "curl",
-		Args:       []string{"-I", "-s", "-L", "--max-time", "15", target},
+		Args:       append(args, target),
 		Timeout:    30 * time.Second,
 	}, nil
 }
 
-func buildWhatWebSpec(target, aggression string) (tools.ToolSpec, error) {
+func buildWhatWebSpec(target, aggression string, auth *basicAuth, extra []string) (tools.ToolSpec, error) {
 	if err := tools.ValidateURL(target); err != nil {
 		return tools.ToolSpec{}, err
 	}
 	if aggression == "" {
 		aggression = "1"
 	}
+	args := []string{"-a", aggression, "--color=never"}
+	if auth != nil {
+		args = append(args, "--user="+auth.user+":"+auth.pass)
+	}
+	args = append(args, extra...)
+	args = append(args, target)
 	return tools.ToolSpec{
 		Name:       "WhatWeb",
 		BinaryName: "whatweb",
-		Args:       []string{"-a", aggression, "--color=never", target},
+		Args:       args,
 		Timeout:    2 * time.Minute,
 	}, nil
 }
 
-func buildGobusterSpec(target, wordlist, extensions string) (tools.ToolSpec, error) {
+func buildGobusterSpec(target, wordlist, extensions string, auth *basicAuth, extra []string) (tools.ToolSpec, error) {
 	if err := tools.ValidateURL(target); err != nil {
 		return tools.ToolSpec{}, err
 	}
@@ -193,6 +288,10 @@ func buildGobusterSpec(target, wordlist, extensions string) (tools.ToolSpec, err
 	if extensions != "" {
 		args = append(args, "-x", tools.SanitizeArg(extensions))
 	}
+	if auth != nil {
+		args = append(args, "-U", auth.user, "-P", auth.pass)
+	}
+	args = append(args, extra...)
 	return tools.ToolSpec{
 		Name:       "Gobuster",
 		BinaryName: "gobuster",
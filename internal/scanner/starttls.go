@@ -0,0 +1,165 @@
+package scanner
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// sslHandshakeTimeout bounds a STARTTLS exchange plus the TLS handshake after
+// it. Direct TLS is bounded by the dialer's own timeout.
+const sslHandshakeTimeout = 15 * time.Second
+
+// starttlsPorts are the well-known plaintext ports whose protocol upgrades
+// to TLS in-band. Everything else (443, 465, 993, 8443, ...) speaks TLS
+// from the first byte.
+var starttlsPorts = map[string]string{
+	"25":  "smtp",
+	"587": "smtp",
+	"143": "imap",
+}
+
+// validateStartTLS checks ssl_check's starttls parameter: empty or "auto"
+// picks by port, "none" forces direct TLS, "smtp" and "imap" force STARTTLS.
+func validateStartTLS(param string) error {
+	switch param {
+	case "", "auto", "none", "smtp", "imap":
+		return nil
+	}
+	return fmt.Errorf("starttls must be auto, none, smtp or imap")
+}
+
+// sslNegotiation resolves the starttls parameter for a port to "tls" (direct)
+// or the STARTTLS protocol to speak.
+func sslNegotiation(param, port string) string {
+	switch param {
+	case "none":
+		return "tls"
+	case "smtp", "imap":
+		return param
+	}
+	if proto, ok := starttlsPorts[port]; ok {
+		return proto
+	}
+	return "tls"
+}
+
+// negotiationName describes a negotiation path for the scan's results.
+func negotiationName(mode string) string {
+	if mode == "tls" {
+		return "direct TLS"
+	}
+	return "STARTTLS (" + strings.ToUpper(mode) + ")"
+}
+
+// sslTarget splits an ssl_check target into host and port. It takes a bare
+// host, host:port, or a URL such as https://example.com:8443/, and defaults
+// the port to 443.
+func sslTarget(target string) (host, port string) {
+	if strings.Contains(target, "://") {
+		if u, err := url.Parse(target); err == nil && u.Hostname() != "" {
+			port = u.Port()
+			if port == "" {
+				port = "443"
+			}
+			return u.Hostname(), port
+		}
+	}
+	return splitHostPortDefault(strings.TrimRight(target, "/"), "443")
+}
+
+// dialSSL opens a TLS connection to addr, first upgrading a plaintext session
+// with STARTTLS when mode names a protocol.
+func dialSSL(dialer *net.Dialer, addr, mode string, cfg *tls.Config) (*tls.Conn, error) {
+	if mode == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", addr, cfg)
+	}
+
+	raw, err := dialer.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	raw.SetDeadline(time.Now().Add(sslHandshakeTimeout))
+
+	tp := textproto.NewConn(raw)
+	switch mode {
+	case "smtp":
+		err = smtpStartTLS(tp)
+	case "imap":
+		err = imapStartTLS(tp)
+	}
+	if err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("STARTTLS (%s): %w", strings.ToUpper(mode), err)
+	}
+
+	conn := tls.Client(raw, cfg)
+	if err := conn.Handshake(); err != nil {
+		raw.Close()
+		return nil, err
+	}
+	raw.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// smtpStartTLS reads the greeting, checks that EHLO advertises STARTTLS and
+// asks for it (RFC 3207).
+func smtpStartTLS(tp *textproto.Conn) error {
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if err := tp.PrintfLine("EHLO raccoon-recon.invalid"); err != nil {
+		return err
+	}
+	_, ext, err := tp.ReadResponse(250)
+	if err != nil {
+		return fmt.Errorf("EHLO: %w", err)
+	}
+	offered := false
+	for _, line := range strings.Split(ext, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "STARTTLS") {
+			offered = true
+		}
+	}
+	if !offered {
+		return fmt.Errorf("server does not offer STARTTLS")
+	}
+	if err := tp.PrintfLine("STARTTLS"); err != nil {
+		return err
+	}
+	if _, _, err := tp.ReadResponse(220); err != nil {
+		return fmt.Errorf("STARTTLS: %w", err)
+	}
+	return nil
+}
+
+// imapStartTLS reads the greeting and issues a tagged STARTTLS (RFC 3501).
+func imapStartTLS(tp *textproto.Conn) error {
+	greeting, err := tp.ReadLine()
+	if err != nil {
+		return fmt.Errorf("greeting: %w", err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(greeting), "* OK") {
+		return fmt.Errorf("unexpected greeting %q", greeting)
+	}
+	if err := tp.PrintfLine("a1 STARTTLS"); err != nil {
+		return err
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "* ") {
+			continue // untagged data before the reply
+		}
+		if !strings.HasPrefix(strings.ToUpper(line), "A1 OK") {
+			return fmt.Errorf("server refused STARTTLS: %s", line)
+		}
+		return nil
+	}
+}
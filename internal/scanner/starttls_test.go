@@ -0,0 +1,26 @@
+package scanner
+
+import "testing"
+
+func TestSSLTarget(t *testing.T) {
+	tests := []struct {
+		target, host, port string
+	}{
+		{"example.com", "example.com", "443"},
+		{"example.com/", "example.com", "443"},
+		{"mail.example.com:465", "mail.example.com", "465"},
+		{"https://example.com", "example.com", "443"},
+		{"https://example.com:8443/path", "example.com", "8443"},
+		{"2001:db8::1", "2001:db8::1", "443"},
+		{"[2001:db8::1]", "2001:db8::1", "443"},
+		{"[2001:db8::1]:8443", "2001:db8::1", "8443"},
+		{"https://[2001:db8::1]", "2001:db8::1", "443"},
+		{"https://[2001:db8::1]:8443/", "2001:db8::1", "8443"},
+	}
+	for _, tt := range tests {
+		host, port := sslTarget(tt.target)
+		if host != tt.host || port != tt.port {
+			t.Errorf("sslTarget(%q) = %q, %q; want %q, %q", tt.target, host, port, tt.host, tt.port)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// takeoverFingerprint identifies a hosted service that can be claimed by
+// anyone once the customer deletes the resource but leaves DNS pointing at it.
+type takeoverFingerprint struct {
+	service string
+	cnames  []string // substrings of the canonical name
+	bodies  []string // markers on the service's "nothing here" page
+	// nxdomain marks services whose dangling records fail to resolve at all,
+	// which is itself a takeover indicator.
+	nxdomain bool
+}
+
+var takeoverFingerprints = []takeoverFingerprint{
+	{
+		service: "GitHub Pages",
+		cnames:  []string{".github.io"},
+		bodies:  []string{"There isn't a GitHub Pages site here.", "For root URLs (like http://example.com/) you must provide an index.html file"},
+	},
+	{
+		service: "AWS S3",
+		cnames:  []string{".s3.amazonaws.com", ".s3-website", ".s3.dualstack."},
+		bodies:  []string{"NoSuchBucket", "The specified bucket does not exist"},
+	},
+	{
+		service: "Heroku",
+		cnames:  []string{".herokuapp.com", ".herokudns.com", ".herokussl.com"},
+		bodies:  []string{"No such app", "herokucdn.com/error-pages/no-such-app.html"},
+	},
+	{
+		service:  "Microsoft Azure",
+		cnames:   []string{".azurewebsites.net", ".cloudapp.net", ".cloudapp.azure.com", ".trafficmanager.net", ".blob.core.windows.net", ".azureedge.net", ".azure-api.net"},
+		bodies:   []string{"404 Web Site not found"},
+		nxdomain: true,
+	},
+	{
+		service: "Fastly",
+		cnames:  []string{".fastly.net"},
+		bodies:  []string{"Fastly error: unknown domain"},
+	},
+}
+
+const maxTakeoverBody = 256 * 1024
+
+// --- Subdomain Takeover Check ---
+
+// checkTakeover tests each hostname in target (comma/whitespace separated, so
+// subdomains from an earlier enumeration can be pasted in) for a CNAME into a
+// fingerprinted service that no longer serves the host. Up to workers hosts
+// are checked at once; each candidate is emitted as soon as it is confirmed.
+func checkTakeover(ctx context.Context, resolver *net.Resolver, client *http.Client, scanID int64, target string, workers int, evidence bool, emit func(database.Result), progress func(string)) error {
+	hosts := strings.FieldsFunc(target, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\t' || r == '\r'
+	})
+	if len(hosts) == 0 {
+		return fmt.Errorf("no hostnames given")
+	}
+
+	forEachConcurrent(ctx, len(hosts), workers, func(i int) {
+		if r := checkTakeoverHost(ctx, resolver, client, scanID, hosts[i], evidence, progress); r != nil {
+			emit(*r)
+		}
+	})
+	return ctx.Err()
+}
+
+// checkTakeoverHost checks a single hostname, returning nil when there is no
+// takeover indicator.
+func checkTakeoverHost(ctx context.Context, resolver *net.Resolver, client *http.Client, scanID int64, host string, evidence bool, progress func(string)) *database.Result {
+	// Accept URLs too, since the web page's target field suggests one
+	if u, err := url.Parse(host); err == nil && u.Host != "" {
+		host = u.Hostname()
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	cname, err := resolver.LookupCNAME(ctx, host)
+	cname = strings.TrimSuffix(strings.ToLower(cname), ".")
+	if err != nil || cname == "" || cname == host {
+		progress(host + ": no CNAME")
+		return nil
+	}
+
+	fp := matchTakeoverCNAME(cname)
+	if fp == nil {
+		progress(host + ": CNAME " + cname + " (no known service)")
+		return nil
+	}
+
+	reason := ""
+	var exchange *httpExchange
+	if fp.nxdomain {
+		_, err := resolver.LookupHost(ctx, cname)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			reason = "CNAME target " + cname + " does not resolve (NXDOMAIN)"
+		}
+	}
+	if reason == "" {
+		var marker string
+		if marker, exchange = fetchTakeoverMarker(ctx, client, host, fp.bodies, evidence); marker != "" {
+			reason = "response contains \"" + marker + "\""
+		}
+	}
+
+	if reason == "" {
+		progress(host + ": CNAME " + cname + " (" + fp.service + ", resource appears claimed)")
+		return nil
+	}
+	return &database.Result{
+		ScanID:     scanID,
+		ResultType: "takeover",
+		Key:        host,
+		Value:      "possible " + fp.service + " takeover",
+		Details: detailsJSON(takeoverDetails{
+			Severity: "high",
+			Service:  fp.service,
+			CNAME:    cname,
+			Evidence: reason,
+			Exchange: exchange,
+		}),
+	}
+}
+
+func matchTakeoverCNAME(cname string) *takeoverFingerprint {
+	for i, fp := range takeoverFingerprints {
+		for _, pattern := range fp.cnames {
+			if strings.Contains(cname, pattern) {
+				return &takeoverFingerprints[i]
+			}
+		}
+	}
+	return nil
+}
+
+// fetchTakeoverMarker requests the host over HTTPS then HTTP and returns the
+// first fingerprint marker found in a response body, along with the exchange
+// when evidence is set.
+func fetchTakeoverMarker(ctx context.Context, client *http.Client, host string, markers []string, evidence bool) (string, *httpExchange) {
+	for _, scheme := range []string{"https://", "http://"} {
+		req, err := http.NewRequestWithContext(ctx, "GET", scheme+host+"/", nil)
+		if err != nil {
+			return "", nil
+		}
+		req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxTakeoverBody))
+		resp.Body.Close()
+
+		for _, m := range markers {
+			if strings.Contains(string(body), m) {
+				return m, captureExchange(evidence, resp, evidenceWindow(body, m))
+			}
+		}
+	}
+	return "", nil
+}
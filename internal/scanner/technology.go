@@ -0,0 +1,152 @@
+package scanner
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+// technology is one product identified on a target, whether from response
+// headers or from WhatWeb, so both sources produce the same "technology"
+// results.
+type technology struct {
+	Product string
+	Version string
+	OS      string
+}
+
+// technologyHeaders are the response headers that name server-side software.
+var technologyHeaders = []string{
+	"Server", "X-Powered-By", "X-AspNet-Version", "X-AspNetMvc-Version", "X-Generator",
+}
+
+// headerTechnologies turns the technology-revealing headers of a response
+// into "technology" results.
+func headerTechnologies(scanID int64, h http.Header) []database.Result {
+	var set technologySet
+	for _, name := range technologyHeaders {
+		source := "header:" + strings.ToLower(name)
+		for _, v := range h.Values(name) {
+			switch name {
+			case "X-AspNet-Version":
+				set.add(technology{Product: "ASP.NET", Version: strings.TrimSpace(v)}, source)
+			case "X-AspNetMvc-Version":
+				set.add(technology{Product: "ASP.NET MVC", Version: strings.TrimSpace(v)}, source)
+			default:
+				for _, t := range parseProductTokens(v) {
+					set.add(t, source)
+				}
+			}
+		}
+	}
+	return set.results(scanID)
+}
+
+// technologySet collects technologies once per product, in the order first
+// seen. A later sighting fills in a version or OS the first one lacked.
+type technologySet struct {
+	techs   []technology
+	sources []string
+	index   map[string]int
+}
+
+func (s *technologySet) add(t technology, source string) {
+	if t.Product == "" {
+		return
+	}
+	key := strings.ToLower(t.Product)
+	if i, ok := s.index[key]; ok {
+		if s.techs[i].Version == "" {
+			s.techs[i].Version = t.Version
+		}
+		if s.techs[i].OS == "" {
+			s.techs[i].OS = t.OS
+		}
+		return
+	}
+	if s.index == nil {
+		s.index = make(map[string]int)
+	}
+	s.index[key] = len(s.techs)
+	s.techs = append(s.techs, t)
+	s.sources = append(s.sources, source)
+}
+
+func (s *technologySet) results(scanID int64) []database.Result {
+	var results []database.Result
+	for i, t := range s.techs {
+		value := t.Product
+		if t.Version != "" {
+			value += " " + t.Version
+		}
+		if t.OS != "" {
+			value += " (" + t.OS + ")"
+		}
+		results = append(results, database.Result{
+			ScanID:     scanID,
+			ResultType: "technology",
+			Key:        t.Product,
+			Value:      value,
+			Details: detailsJSON(technologyDetails{
+				Product: t.Product, Version: t.Version, OS: t.OS, Source: s.sources[i],
+			}),
+		})
+	}
+	return results
+}
+
+// parseProductTokens reads product tokens in the style of the Server header:
+// "Apache/2.4.52 (Ubuntu) OpenSSL/3.0.2", "PHP/8.1.2, ASP.NET" or
+// "Drupal 9 (https://www.drupal.org)". A parenthesized comment naming an
+// operating system sets the OS of the product before it; other comments are
+// ignored.
+func parseProductTokens(s string) []technology {
+	var techs []technology
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimLeft(s, " \t,;") {
+		if s[0] == '(' {
+			comment, rest, _ := strings.Cut(s[1:], ")")
+			if n := len(techs); n > 0 && techs[n-1].OS == "" && isOSComment(comment) {
+				techs[n-1].OS = strings.TrimSpace(comment)
+			}
+			s = rest
+			continue
+		}
+
+		end := strings.IndexAny(s, " \t,;(")
+		if end < 0 {
+			end = len(s)
+		}
+		token := s[:end]
+		s = s[end:]
+
+		if name, version, ok := strings.Cut(token, "/"); ok && name != "" {
+			techs = append(techs, technology{Product: name, Version: version})
+			continue
+		}
+		// "Drupal 9": a bare version right after a product without one
+		if n := len(techs); n > 0 && techs[n-1].Version == "" && token[0] >= '0' && token[0] <= '9' {
+			techs[n-1].Version = token
+			continue
+		}
+		techs = append(techs, technology{Product: token})
+	}
+	return techs
+}
+
+// osMarkers identify a parenthesized product comment as an operating system.
+var osMarkers = []string{
+	"ubuntu", "debian", "centos", "red hat", "rhel", "fedora", "amazon",
+	"alpine", "suse", "linux", "unix", "freebsd", "openbsd", "win32", "win64",
+	"windows",
+}
+
+func isOSComment(comment string) bool {
+	lower := strings.ToLower(comment)
+	for _, m := range osMarkers {
+		if strings.Contains(lower, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package scanner
+
+import "strings"
+
+// TXT record categories stored in dnsDetails.Category.
+const (
+	txtVerification = "verification"
+	txtSPF          = "spf"
+	txtDKIM         = "dkim"
+	txtDMARC        = "dmarc"
+	txtMisc         = "misc"
+)
+
+// txtVerificationPrefixes maps the value prefixes of domain-verification
+// tokens to the service that issued them. Matching ignores case.
+var txtVerificationPrefixes = []struct {
+	prefix  string
+	service string
+}{
+	{"google-site-verification=", "Google"},
+	{"ms=", "Microsoft 365"},
+	{"atlassian-domain-verification=", "Atlassian"},
+	{"status-page-domain-verification=", "Atlassian Statuspage"},
+	{"facebook-domain-verification=", "Facebook"},
+	{"apple-domain-verification=", "Apple"},
+	{"adobe-idp-site-verification=", "Adobe"},
+	{"adobe-sign-verification=", "Adobe Acrobat Sign"},
+	{"amazonses:", "Amazon SES"},
+	{"docusign=", "DocuSign"},
+	{"dropbox-domain-verification=", "Dropbox"},
+	{"globalsign-domain-verification=", "GlobalSign"},
+	{"_globalsign-domain-verification=", "GlobalSign"},
+	{"zoom-domain-verification=", "Zoom"},
+	{"zoom_verify_", "Zoom"},
+	{"slack-domain-verification=", "Slack"},
+	{"stripe-verification=", "Stripe"},
+	{"twilio-domain-verification=", "Twilio"},
+	{"hubspot-developer-verification=", "HubSpot"},
+	{"pinterest-site-verification=", "Pinterest"},
+	{"yandex-verification:", "Yandex"},
+	{"mailru-verification:", "Mail.ru"},
+	{"miro-verification=", "Miro"},
+	{"cisco-ci-domain-verification=", "Cisco Webex"},
+	{"webexdomainverification.", "Cisco Webex"},
+	{"citrix-verification-code=", "Citrix"},
+	{"logmein-verification-code=", "LogMeIn"},
+	{"teamviewer-sso-verification=", "TeamViewer"},
+	{"knowbe4-site-verification=", "KnowBe4"},
+	{"onetrust-domain-verification=", "OneTrust"},
+	{"mongodb-site-verification=", "MongoDB"},
+	{"brevo-code:", "Brevo"},
+	{"sendinblue-code:", "Brevo"},
+	{"have-i-been-pwned-verification=", "Have I Been Pwned"},
+	{"keybase-site-verification=", "Keybase"},
+	{"openai-domain-verification=", "OpenAI"},
+}
+
+// txtMiscTags names the services behind other well-known TXT formats.
+var txtMiscTags = []struct {
+	prefix  string
+	service string
+}{
+	{"v=stsv1", "MTA-STS"},
+	{"v=tlsrptv1", "SMTP TLS Reporting"},
+	{"v=bimi1", "BIMI"},
+}
+
+// digRData returns an answer line's record data as printed, after the name,
+// TTL, class and type fields, since whitespace inside TXT strings matters.
+func digRData(line string) string {
+	rest := line
+	for range 4 {
+		rest = strings.TrimLeft(rest, " \t")
+		i := strings.IndexAny(rest, " \t")
+		if i < 0 {
+			return ""
+		}
+		rest = rest[i:]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// txtValue joins the quoted character-strings of a TXT answer as dig prints
+// it (`"v=spf1 include:a" " ~all"`) into one value. Unquoted text is kept.
+func txtValue(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if !strings.HasPrefix(raw, `"`) {
+		return raw
+	}
+	var b strings.Builder
+	in, escaped := false, false
+	for _, c := range raw {
+		switch {
+		case escaped:
+			b.WriteRune(c)
+			escaped = false
+		case c == '\\' && in:
+			escaped = true
+		case c == '"':
+			in = !in
+		case in:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// classifyTXT works out what a TXT record at name is for, returning its
+// category and, where known, the service it belongs to.
+func classifyTXT(name, value string) (category, service string) {
+	lname := strings.ToLower(strings.TrimSuffix(name, "."))
+	lvalue := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case strings.HasPrefix(lvalue, "v=spf1"):
+		return txtSPF, ""
+	case strings.HasPrefix(lvalue, "v=dmarc1") || strings.HasPrefix(lname, "_dmarc."):
+		return txtDMARC, ""
+	case strings.HasPrefix(lvalue, "v=dkim1") || strings.Contains(lname, "._domainkey."):
+		return txtDKIM, ""
+	}
+	for _, p := range txtVerificationPrefixes {
+		if strings.HasPrefix(lvalue, p.prefix) {
+			return txtVerification, p.service
+		}
+	}
+	for _, p := range txtMiscTags {
+		if strings.HasPrefix(lvalue, p.prefix) {
+			return txtMisc, p.service
+		}
+	}
+	if strings.Contains(lvalue, "verification") || strings.Contains(lvalue, "verify") {
+		return txtVerification, ""
+	}
+	return txtMisc, ""
+}
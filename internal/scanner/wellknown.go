@@ -0,0 +1,249 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jamesruggles/reconsuite/internal/database"
+)
+
+const maxWellKnownSize = 256 * 1024
+
+// wellKnownField is a single interesting value pulled from a resource.
+type wellKnownField struct {
+	name  string
+	value string
+}
+
+// wellKnownResources is the curated set of /.well-known/ paths probed, each
+// with a parser that extracts the fields worth recording.
+var wellKnownResources = []struct {
+	name  string
+	parse func(body []byte) []wellKnownField
+}{
+	{"openid-configuration", parseOpenIDConfiguration},
+	{"assetlinks.json", parseAssetLinks},
+	{"apple-app-site-association", parseAppleAppSiteAssociation},
+	{"change-password", nil},
+	{"mta-sts.txt", parseMTASTS},
+	{"host-meta", parseHostMeta},
+	{"security.txt", parseSecurityTxt},
+}
+
+// --- .well-known Probe ---
+
+func probeWellKnown(ctx context.Context, client *http.Client, scanID int64, target string, evidence bool, emit func(database.Result), progress func(string)) error {
+	if !strings.HasPrefix(target, "http") {
+		target = "https://" + target
+	}
+	target = strings.TrimRight(target, "/")
+
+	reachable := false
+
+	for _, res := range wellKnownResources {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		resourceURL := target + "/.well-known/" + res.name
+		req, err := http.NewRequestWithContext(ctx, "GET", resourceURL, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		req.Header.Set("User-Agent", "RaccoonRecon/1.0")
+
+		resp, err := doWithRetry(ctx, client, req, progress)
+		if err != nil {
+			continue
+		}
+		reachable = true
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxWellKnownSize))
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			emit(database.Result{
+				ScanID:     scanID,
+				ResultType: "well_known",
+				Key:        res.name,
+				Value:      "not found (" + resp.Status + ")",
+			})
+			continue
+		}
+
+		found := database.Result{
+			ScanID:     scanID,
+			ResultType: "well_known",
+			Key:        res.name,
+			Value:      "found: " + resp.Request.URL.String(),
+		}
+		if x := captureExchange(evidence, resp, body); x != nil {
+			found.Details = detailsJSON(wellKnownDetails{Exchange: x})
+		}
+		emit(found)
+		if res.parse == nil {
+			continue
+		}
+		for _, f := range res.parse(body) {
+			emit(database.Result{
+				ScanID:     scanID,
+				ResultType: "well_known",
+				Key:        res.name + ":" + f.name,
+				Value:      f.value,
+			})
+		}
+	}
+
+	if !reachable {
+		return fmt.Errorf("could not reach %s", target)
+	}
+	return nil
+}
+
+func parseOpenIDConfiguration(body []byte) []wellKnownField {
+	var doc map[string]any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	var fields []wellKnownField
+	for _, key := range []string{
+		"issuer", "authorization_endpoint", "token_endpoint", "userinfo_endpoint",
+		"jwks_uri", "registration_endpoint", "end_session_endpoint", "introspection_endpoint",
+		"revocation_endpoint", "grant_types_supported", "scopes_supported",
+	} {
+		if v, ok := doc[key]; ok {
+			fields = append(fields, wellKnownField{key, jsonValueString(v)})
+		}
+	}
+	return fields
+}
+
+func parseAssetLinks(body []byte) []wellKnownField {
+	var statements []struct {
+		Target struct {
+			Namespace    string   `json:"namespace"`
+			PackageName  string   `json:"package_name"`
+			Site         string   `json:"site"`
+			Fingerprints []string `json:"sha256_cert_fingerprints"`
+		} `json:"target"`
+	}
+	if err := json.Unmarshal(body, &statements); err != nil {
+		return nil
+	}
+	var fields []wellKnownField
+	for _, st := range statements {
+		switch {
+		case st.Target.PackageName != "":
+			fields = append(fields, wellKnownField{"android_app", st.Target.PackageName})
+			for _, fp := range st.Target.Fingerprints {
+				fields = append(fields, wellKnownField{"cert_fingerprint", fp})
+			}
+		case st.Target.Site != "":
+			fields = append(fields, wellKnownField{"linked_site", st.Target.Site})
+		}
+	}
+	return fields
+}
+
+func parseAppleAppSiteAssociation(body []byte) []wellKnownField {
+	var doc struct {
+		AppLinks struct {
+			Details []struct {
+				AppID  string   `json:"appID"`
+				AppIDs []string `json:"appIDs"`
+			} `json:"details"`
+		} `json:"applinks"`
+		WebCredentials struct {
+			Apps []string `json:"apps"`
+		} `json:"webcredentials"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil
+	}
+	var fields []wellKnownField
+	for _, d := range doc.AppLinks.Details {
+		if d.AppID != "" {
+			fields = append(fields, wellKnownField{"ios_app", d.AppID})
+		}
+		for _, id := range d.AppIDs {
+			fields = append(fields, wellKnownField{"ios_app", id})
+		}
+	}
+	for _, app := range doc.WebCredentials.Apps {
+		fields = append(fields, wellKnownField{"webcredentials_app", app})
+	}
+	return fields
+}
+
+// parseMTASTS reads the "key: value" lines of an MTA-STS policy.
+func parseMTASTS(body []byte) []wellKnownField {
+	return parseColonLines(body, map[string]bool{"version": true, "mode": true, "mx": true, "max_age": true})
+}
+
+func parseSecurityTxt(body []byte) []wellKnownField {
+	return parseColonLines(body, map[string]bool{
+		"contact": true, "policy": true, "encryption": true, "hiring": true, "expires": true,
+	})
+}
+
+func parseColonLines(body []byte, keys map[string]bool) []wellKnownField {
+	var fields []wellKnownField
+	sc := bufio.NewScanner(bytes.NewReader(body))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		if keys[key] {
+			fields = append(fields, wellKnownField{key, strings.TrimSpace(parts[1])})
+		}
+	}
+	return fields
+}
+
+func parseHostMeta(body []byte) []wellKnownField {
+	var xrd struct {
+		Links []struct {
+			Rel      string `xml:"rel,attr"`
+			Href     string `xml:"href,attr"`
+			Template string `xml:"template,attr"`
+		} `xml:"Link"`
+	}
+	if err := xml.Unmarshal(body, &xrd); err != nil {
+		return nil
+	}
+	var fields []wellKnownField
+	for _, l := range xrd.Links {
+		target := l.Href
+		if target == "" {
+			target = l.Template
+		}
+		if target != "" {
+			fields = append(fields, wellKnownField{"link:" + l.Rel, target})
+		}
+	}
+	return fields
+}
+
+func jsonValueString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, fmt.Sprint(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(val)
+	}
+}
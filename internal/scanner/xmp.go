@@ -0,0 +1,139 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// XMP namespaces holding the document properties PDFs also keep in /Info.
+const (
+	xmpNSDC  = "http://purl.org/dc/elements/1.1/"
+	xmpNSXMP = "http://ns.adobe.com/xap/1.0/"
+	xmpNSPDF = "http://ns.adobe.com/pdf/1.3/"
+	xmpNSRDF = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+)
+
+// xmpFields maps XMP properties to the metadata keys extractPDFMetadata
+// reports for the matching /Info entries.
+var xmpFields = map[xml.Name]string{
+	{Space: xmpNSDC, Local: "title"}:        "title",
+	{Space: xmpNSDC, Local: "creator"}:      "author",
+	{Space: xmpNSDC, Local: "description"}:  "subject",
+	{Space: xmpNSXMP, Local: "CreatorTool"}: "creator",
+	{Space: xmpNSPDF, Local: "Producer"}:    "producer",
+	{Space: xmpNSXMP, Local: "CreateDate"}:  "creation_date",
+	{Space: xmpNSXMP, Local: "ModifyDate"}:  "modification_date",
+	{Space: xmpNSPDF, Local: "Keywords"}:    "keywords",
+}
+
+// findXMPPacket returns the first uncompressed XMP packet (<x:xmpmeta>
+// element) in content, or "". Packets inside compressed streams are not
+// seen.
+func findXMPPacket(content string) string {
+	start := strings.Index(content, "<x:xmpmeta")
+	if start < 0 {
+		return ""
+	}
+	end := strings.Index(content[start:], "</x:xmpmeta>")
+	if end < 0 {
+		return ""
+	}
+	return content[start : start+end+len("</x:xmpmeta>")]
+}
+
+// parseXMP reads the document properties in xmpFields from an XMP packet,
+// written either as elements or as attributes of rdf:Description. List
+// values (rdf:Seq, rdf:Bag, rdf:Alt) are joined with "; ", and dates are
+// formatted like /Info's. A malformed packet yields what was read before the
+// error.
+func parseXMP(packet string) map[string]string {
+	values := make(map[string][]string)
+	add := func(key, v string) {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			return
+		}
+		for _, have := range values[key] {
+			if have == v {
+				return
+			}
+		}
+		values[key] = append(values[key], v)
+	}
+
+	dec := xml.NewDecoder(strings.NewReader(packet))
+	dec.Strict = false
+	current := ""   // key of the property element being read
+	var text []byte // character data of the current property or list item
+	inList := false // inside an rdf:li of the current property
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Space == xmpNSRDF && t.Name.Local == "Description" {
+				for _, a := range t.Attr {
+					if key, ok := xmpFields[a.Name]; ok {
+						add(key, a.Value)
+					}
+				}
+				continue
+			}
+			if key, ok := xmpFields[t.Name]; ok && current == "" {
+				current, text = key, text[:0]
+				continue
+			}
+			if current != "" && t.Name.Space == xmpNSRDF && t.Name.Local == "li" {
+				inList, text = true, text[:0]
+			}
+		case xml.CharData:
+			if current != "" {
+				text = append(text, t...)
+			}
+		case xml.EndElement:
+			switch {
+			case current == "":
+			case inList && t.Name.Space == xmpNSRDF && t.Name.Local == "li":
+				add(current, string(text))
+				inList, text = false, text[:0]
+			case xmpFields[t.Name] == current:
+				add(current, string(text))
+				current = ""
+			}
+		}
+	}
+
+	fields := make(map[string]string, len(values))
+	for key, list := range values {
+		v := strings.Join(list, "; ")
+		if strings.HasSuffix(key, "_date") {
+			v = formatXMPDate(v)
+		}
+		if len(v) > 500 {
+			v = v[:500]
+		}
+		fields[key] = v
+	}
+	return fields
+}
+
+// formatXMPDate turns an XMP date ("2021-01-01T12:00:00+01:00") into the
+// form formatPDFDate gives /Info dates ("2021-01-01 12:00:00").
+func formatXMPDate(d string) string {
+	date, clock, ok := strings.Cut(d, "T")
+	if !ok {
+		return d
+	}
+	if i := strings.IndexAny(clock, "Z+-"); i >= 0 {
+		clock = clock[:i]
+	}
+	if i := strings.Index(clock, "."); i >= 0 {
+		clock = clock[:i]
+	}
+	if len(clock) == 5 {
+		clock += ":00"
+	}
+	return date + " " + clock
+}
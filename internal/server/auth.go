@@ -0,0 +1,196 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionCookie names the cookie holding a login session token.
+const sessionCookie = "reconsuite_session"
+
+// sessionStore keeps login sessions in memory; a restart signs everyone out.
+type sessionStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[string]time.Time // token → expiry
+}
+
+func newSessionStore(ttl time.Duration) *sessionStore {
+	return &sessionStore{ttl: ttl, sessions: make(map[string]time.Time)}
+}
+
+// create starts a session and returns its token and expiry.
+func (st *sessionStore) create() (string, time.Time, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", time.Time{}, err
+	}
+	token := hex.EncodeToString(buf)
+	expires := time.Now().Add(st.ttl)
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := time.Now()
+	for t, exp := range st.sessions {
+		if now.After(exp) {
+			delete(st.sessions, t)
+		}
+	}
+	st.sessions[token] = expires
+	return token, expires, nil
+}
+
+func (st *sessionStore) valid(token string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	exp, ok := st.sessions[token]
+	if ok && time.Now().After(exp) {
+		delete(st.sessions, token)
+		return false
+	}
+	return ok
+}
+
+func (st *sessionStore) delete(token string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.sessions, token)
+}
+
+// validSession reports whether the request carries a live login session.
+func (s *Server) validSession(r *http.Request) bool {
+	c, err := r.Cookie(sessionCookie)
+	return err == nil && c.Value != "" && s.sessions.valid(c.Value)
+}
+
+// requireAuth gates every page, /api/ route and the WebSocket behind the
+// login when security.login is configured. API clients may present the API
+// key instead of a session. Without a login configured it does nothing.
+func (s *Server) requireAuth(next http.Handler) http.Handler {
+	if !s.cfg.Security.Login.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/static/") || path == "/login" || s.validSession(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		switch {
+		case strings.HasPrefix(path, "/api/"):
+			if s.validAPIKey(requestAPIKey(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeErrorCode(w, http.StatusUnauthorized, CodeLoginRequired, "login required")
+		case path == "/ws":
+			if s.validAPIKey(r.URL.Query().Get("api_key")) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, "login required", http.StatusUnauthorized)
+		default:
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+		}
+	})
+}
+
+type loginData struct {
+	Next  string
+	Error string
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !s.cfg.Security.Login.Enabled() {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if s.validSession(r) {
+			http.Redirect(w, r, loginRedirect(r.URL.Query().Get("next")), http.StatusSeeOther)
+			return
+		}
+		s.renderLogin(w, http.StatusOK, loginData{Next: r.URL.Query().Get("next")})
+
+	case http.MethodPost:
+		next := r.FormValue("next")
+		if !s.checkLogin(r.FormValue("username"), r.FormValue("password")) {
+			slog.Warn("failed login", "remote", r.RemoteAddr)
+			s.renderLogin(w, http.StatusUnauthorized, loginData{Next: next, Error: "Invalid username or password."})
+			return
+		}
+		token, expires, err := s.sessions.create()
+		if err != nil {
+			http.Error(w, "could not start session", http.StatusInternalServerError)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    token,
+			Path:     "/",
+			Expires:  expires,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		http.Redirect(w, r, loginRedirect(next), http.StatusSeeOther)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		s.sessions.delete(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// checkLogin compares credentials against security.login. The hash is
+// checked even for a wrong username so both fail in the same time.
+func (s *Server) checkLogin(username, password string) bool {
+	login := s.cfg.Security.Login
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(login.Username)) == 1
+	passOK := bcrypt.CompareHashAndPassword([]byte(login.PasswordHash), []byte(password)) == nil
+	return userOK && passOK
+}
+
+func (s *Server) renderLogin(w http.ResponseWriter, status int, data loginData) {
+	w.WriteHeader(status)
+	if err := s.loginTmpl.Execute(w, data); err != nil {
+		slog.Error("template render error", "page", "login", "error", err)
+	}
+}
+
+// loginRedirect keeps the post-login redirect on this site.
+func loginRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// backupPrefix and backupExt frame the snapshot files in database.backup_dir,
+// so unrelated files there are never served or pruned.
+const (
+	backupPrefix = "reconsuite-"
+	backupExt    = ".db"
+)
+
+type backupInfo struct {
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+	Pruned    int       `json:"pruned,omitempty"`
+}
+
+// handleAPIBackup takes an online snapshot of the database (POST) into
+// database.backup_dir and prunes old ones down to database.backup_keep.
+// Only one backup runs at a time.
+func (s *Server) handleAPIBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.backupMu.TryLock() {
+		writeError(w, http.StatusConflict, "a backup is already in progress")
+		return
+	}
+	defer s.backupMu.Unlock()
+
+	dir := s.cfg.Database.BackupDir
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Sprintf("creating backup directory: %v", err))
+		return
+	}
+	now := time.Now().UTC()
+	path := filepath.Join(dir, backupPrefix+now.Format("20060102-150405")+backupExt)
+	for i := 2; fileExists(path); i++ {
+		path = filepath.Join(dir, fmt.Sprintf("%s%s-%d%s", backupPrefix, now.Format("20060102-150405"), i, backupExt))
+	}
+
+	if err := s.db.Backup(r.Context(), path); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	pruned := pruneBackups(dir, s.cfg.Database.BackupKeep)
+	slog.Info("database backed up", "path", path, "size", fi.Size(), "pruned", pruned)
+	writeJSON(w, http.StatusCreated, backupInfo{Path: path, Size: fi.Size(), CreatedAt: now, Pruned: pruned})
+}
+
+// handleAPIBackupLatest downloads the newest snapshot (GET).
+func (s *Server) handleAPIBackupLatest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	backups := listBackups(s.cfg.Database.BackupDir)
+	if len(backups) == 0 {
+		writeError(w, http.StatusNotFound, "no backups yet; POST /api/backup to take one")
+		return
+	}
+	path := backups[len(backups)-1]
+	f, err := os.Open(path)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	http.ServeContent(w, r, filepath.Base(path), fi.ModTime(), f)
+}
+
+// listBackups returns the snapshot files in dir, oldest first.
+func listBackups(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	type backup struct {
+		path string
+		mod  time.Time
+	}
+	var found []backup
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasPrefix(name, backupPrefix) || !strings.HasSuffix(name, backupExt) {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		found = append(found, backup{filepath.Join(dir, name), fi.ModTime()})
+	}
+	sort.Slice(found, func(i, j int) bool {
+		if !found[i].mod.Equal(found[j].mod) {
+			return found[i].mod.Before(found[j].mod)
+		}
+		return found[i].path < found[j].path
+	})
+	paths := make([]string, len(found))
+	for i, b := range found {
+		paths[i] = b.path
+	}
+	return paths
+}
+
+// pruneBackups removes all but the newest keep snapshots in dir, returning
+// how many were removed. keep 0 keeps them all.
+func pruneBackups(dir string, keep int) int {
+	backups := listBackups(dir)
+	if keep <= 0 || len(backups) <= keep {
+		return 0
+	}
+	removed := 0
+	for _, path := range backups[:len(backups)-keep] {
+		if err := os.Remove(path); err != nil {
+			slog.Warn("remove old backup failed", "path", path, "error", err)
+			continue
+		}
+		removed++
+	}
+	return removed
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
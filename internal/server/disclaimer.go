@@ -0,0 +1,227 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"html/template"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jamesruggles/reconsuite/internal/config"
+	"github.com/jamesruggles/reconsuite/internal/database"
+	"github.com/jamesruggles/reconsuite/web"
+)
+
+// disclaimerCookie names the cookie recording that the welcome page terms
+// were accepted.
+const disclaimerCookie = "disclaimer_accepted"
+
+// disclaimer holds the welcome page terms and the key that signs acceptance
+// cookies. A cookie is bound to the hash of the terms, so changing them asks
+// everyone to accept again.
+type disclaimer struct {
+	terms template.HTML // empty shows the built-in terms
+	hash  string
+	key   []byte
+}
+
+func newDisclaimer(cfg config.DisclaimerConfig) (*disclaimer, error) {
+	d := &disclaimer{key: []byte(cfg.Secret)}
+	if len(d.key) == 0 {
+		d.key = make([]byte, 32)
+		if _, err := rand.Read(d.key); err != nil {
+			return nil, fmt.Errorf("generating disclaimer key: %w", err)
+		}
+	}
+
+	var text []byte
+	if cfg.File != "" {
+		data, err := os.ReadFile(cfg.File)
+		if err != nil {
+			return nil, fmt.Errorf("reading security.disclaimer.file: %w", err)
+		}
+		text = data
+		if strings.HasSuffix(strings.ToLower(cfg.File), ".md") {
+			d.terms = renderMarkdown(string(data))
+		} else {
+			d.terms = template.HTML(data) // operator-supplied, trusted
+		}
+	} else {
+		data, err := web.Templates.ReadFile("templates/welcome.html")
+		if err != nil {
+			return nil, fmt.Errorf("reading welcome.html: %w", err)
+		}
+		text = data
+	}
+	sum := sha256.Sum256(text)
+	d.hash = hex.EncodeToString(sum[:8])
+	return d, nil
+}
+
+// cookieValue signs an acceptance made at t: "<unix time>.<hmac>".
+func (d *disclaimer) cookieValue(t time.Time) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return ts + "." + d.sign(ts)
+}
+
+func (d *disclaimer) sign(ts string) string {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write([]byte(d.hash + "|" + ts))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// accepted reports whether the request carries a valid acceptance cookie for
+// the current terms.
+func (d *disclaimer) accepted(r *http.Request) bool {
+	c, err := r.Cookie(disclaimerCookie)
+	if err != nil {
+		return false
+	}
+	ts, sig, ok := strings.Cut(c.Value, ".")
+	return ok && hmac.Equal([]byte(sig), []byte(d.sign(ts)))
+}
+
+// disclaimerMiddleware redirects to the welcome page until the terms have
+// been accepted. API requests carrying a valid API key are let through, since
+// integrations have no browser to accept the terms in.
+func (s *Server) disclaimerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Allow static assets, the welcome page, the accept endpoint and the
+		// login page through
+		path := r.URL.Path
+		if strings.HasPrefix(path, "/static/") ||
+			strings.HasPrefix(path, "/welcome") ||
+			path == "/login" || path == "/logout" ||
+			strings.HasPrefix(path, "/api/") && s.validAPIKey(requestAPIKey(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !s.disclaimer.accepted(r) {
+			http.Redirect(w, r, "/welcome", http.StatusSeeOther)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+type welcomeData struct {
+	Terms template.HTML
+}
+
+func (s *Server) handleWelcome(w http.ResponseWriter, r *http.Request) {
+	// If already accepted, redirect to dashboard
+	if s.disclaimer.accepted(r) {
+		http.Redirect(w, r, "/", http.StatusSeeOther)
+		return
+	}
+	if err := s.welcomeTmpl.Execute(w, welcomeData{Terms: s.disclaimer.terms}); err != nil {
+		slog.Error("template render error", "page", "welcome", "error", err)
+		http.Error(w, "render error", http.StatusInternalServerError)
+	}
+}
+
+// handleWelcomeAccept records the acceptance and sets the signed cookie.
+func (s *Server) handleWelcomeAccept(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	a := &database.DisclaimerAcceptance{ClientIP: ip, UserAgent: r.UserAgent(), TermsHash: s.disclaimer.hash}
+	if err := s.db.RecordDisclaimerAcceptance(a); err != nil {
+		slog.Error("record disclaimer acceptance failed", "error", err)
+		http.Error(w, "could not record acceptance", http.StatusInternalServerError)
+		return
+	}
+	slog.Info("disclaimer accepted", "remote", ip, "terms", a.TermsHash, "id", a.ID)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     disclaimerCookie,
+		Value:    s.disclaimer.cookieValue(time.Now()),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+var (
+	mdStrong = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdEm     = regexp.MustCompile(`\*(.+?)\*|\b_(.+?)_\b`)
+	mdCode   = regexp.MustCompile("`([^`]+)`")
+	mdLink   = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+)
+
+// renderMarkdown turns the small Markdown subset a disclaimer needs into
+// HTML: paragraphs, "-"/"*" lists, "#" headings, **bold**, *emphasis*,
+// `code` and http(s) links. Everything else is escaped text.
+func renderMarkdown(src string) template.HTML {
+	var b strings.Builder
+	var para []string
+	inList := false
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString("<p>" + mdInline(strings.Join(para, " ")) + "</p>\n")
+			para = nil
+		}
+		if inList {
+			b.WriteString("</ul>\n")
+			inList = false
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* "):
+			if len(para) > 0 {
+				flush()
+			}
+			if !inList {
+				b.WriteString("<ul>\n")
+				inList = true
+			}
+			b.WriteString("<li>" + mdInline(line[2:]) + "</li>\n")
+		case strings.HasPrefix(line, "#"):
+			flush()
+			level := len(line) - len(strings.TrimLeft(line, "#"))
+			tag := fmt.Sprintf("h%d", min(level+2, 6)) // the card title is an h2
+			b.WriteString("<" + tag + ">" + mdInline(strings.TrimSpace(line[level:])) + "</" + tag + ">\n")
+		default:
+			if inList {
+				flush()
+			}
+			para = append(para, line)
+		}
+	}
+	flush()
+	return template.HTML(b.String())
+}
+
+func mdInline(s string) string {
+	s = html.EscapeString(s)
+	s = mdCode.ReplaceAllString(s, "<code>$1</code>")
+	s = mdLink.ReplaceAllString(s, `<a href="$2" rel="noopener">$1</a>`)
+	s = mdStrong.ReplaceAllString(s, "<strong>$1</strong>")
+	s = mdEm.ReplaceAllString(s, "<em>$1$2</em>")
+	return s
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jamesruggles/reconsuite/internal/scanner"
+	"github.com/jamesruggles/reconsuite/internal/tools"
+)
+
+// Error codes sent as "code" alongside "error" in API error responses. The
+// message is for people; the code is stable for clients to switch on.
+const (
+	CodeBadRequest          = "bad_request"
+	CodeInvalidJSON         = "invalid_json"
+	CodeInvalidTarget       = "invalid_target"
+	CodeUnknownTool         = "unknown_tool"
+	CodeToolNotInstalled    = "tool_not_installed"
+	CodeUnauthorized        = "unauthorized"
+	CodeLoginRequired       = "login_required"
+	CodeInvalidAPIKey       = "invalid_api_key"
+	CodeAPIKeyNotConfigured = "api_key_not_configured"
+	CodeForbidden           = "forbidden"
+	CodeReadOnly            = "read_only"
+	CodeNotFound            = "not_found"
+	CodeMethodNotAllowed    = "method_not_allowed"
+	CodePayloadTooLarge     = "payload_too_large"
+	CodeUnsupportedType     = "unsupported_media_type"
+	CodeInternal            = "internal_error"
+)
+
+type errorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// writeError writes an error response with the generic code for status.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeErrorCode(w, status, statusCode(status), msg)
+}
+
+// writeErrorCode writes an error response with a specific code.
+func writeErrorCode(w http.ResponseWriter, status int, code, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg, Code: code})
+}
+
+// statusCode is the generic error code for an HTTP status.
+func statusCode(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedType
+	}
+	if status >= 500 {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// scanErrorCode classifies an error rejecting a scan request.
+func scanErrorCode(err error) string {
+	switch {
+	case errors.Is(err, tools.ErrInvalidTarget):
+		return CodeInvalidTarget
+	case errors.Is(err, tools.ErrNotInstalled):
+		return CodeToolNotInstalled
+	case errors.Is(err, scanner.ErrUnknownTool):
+		return CodeUnknownTool
+	}
+	return CodeBadRequest
+}
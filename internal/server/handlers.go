@@ -1,19 +1,31 @@
 package server
 
 import (
+	"archive/zip"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
+	"unicode"
 
 	"github.com/jamesruggles/reconsuite/internal/database"
+	"github.com/jamesruggles/reconsuite/internal/report"
 	"github.com/jamesruggles/reconsuite/internal/scanner"
 	"github.com/jamesruggles/reconsuite/internal/tools"
 )
 
 type pageData struct {
-	ActivePage string
+	ActivePage   string
+	LoginEnabled bool
+	Timezone     string // display.timezone, for the UI's date formatting
 }
 
 func (s *Server) renderPage(w http.ResponseWriter, page string, data pageData) {
@@ -22,39 +34,14 @@ func (s *Server) renderPage(w http.ResponseWriter, page string, data pageData) {
 		http.Error(w, "page not found", http.StatusInternalServerError)
 		return
 	}
+	data.LoginEnabled = s.cfg.Security.Login.Enabled()
+	data.Timezone = s.cfg.Display.Timezone
 	if err := tmpl.ExecuteTemplate(w, "layout.html", data); err != nil {
 		slog.Error("template render error", "page", page, "error", err)
 		http.Error(w, "render error", http.StatusInternalServerError)
 	}
 }
 
-func (s *Server) handleWelcome(w http.ResponseWriter, r *http.Request) {
-	// If already accepted, redirect to dashboard
-	if cookie, err := r.Cookie("disclaimer_accepted"); err == nil && cookie.Value == "true" {
-		http.Redirect(w, r, "/", http.StatusSeeOther)
-		return
-	}
-	if err := s.welcomeTmpl.Execute(w, nil); err != nil {
-		slog.Error("template render error", "page", "welcome", "error", err)
-		http.Error(w, "render error", http.StatusInternalServerError)
-	}
-}
-
-func (s *Server) handleWelcomeAccept(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	http.SetCookie(w, &http.Cookie{
-		Name:     "disclaimer_accepted",
-		Value:    "true",
-		Path:     "/",
-		HttpOnly: true,
-		SameSite: http.SameSiteLaxMode,
-	})
-	http.Redirect(w, r, "/", http.StatusSeeOther)
-}
-
 func (s *Server) handleDashboard(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
 		http.NotFound(w, r)
@@ -95,8 +82,21 @@ func writeJSON(w http.ResponseWriter, status int, v any) {
 	json.NewEncoder(w).Encode(v)
 }
 
-func writeError(w http.ResponseWriter, status int, msg string) {
-	writeJSON(w, status, map[string]string{"error": msg})
+// decodeJSON decodes the request body into v, writing 413 when the body was
+// cut off by maxBodyMiddleware and 400 for anything else. It reports whether
+// decoding succeeded.
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err == nil {
+		return true
+	}
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxErr.Limit))
+		return false
+	}
+	writeErrorCode(w, http.StatusBadRequest, CodeInvalidJSON, "invalid JSON")
+	return false
 }
 
 // handleAPIProjects handles /api/projects (collection)
@@ -115,8 +115,7 @@ func (s *Server) handleAPIProjects(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPost:
 		var p database.Project
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON")
+		if !decodeJSON(w, r, &p) {
 			return
 		}
 		if p.Name == "" {
@@ -130,7 +129,7 @@ func (s *Server) handleAPIProjects(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusCreated, p)
 
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
@@ -158,6 +157,12 @@ func (s *Server) handleAPIProject(w http.ResponseWriter, r *http.Request) {
 			s.handleAPIProjectScans(w, r, id)
 		case "results":
 			s.handleAPIProjectResults(w, r, id)
+		case "entities":
+			s.handleAPIProjectEntities(w, r, id)
+		case "reports/archive":
+			s.handleAPIProjectReportArchive(w, r, id)
+		case "pin":
+			s.handleAPIProjectPin(w, r, id)
 		default:
 			http.NotFound(w, r)
 		}
@@ -179,8 +184,7 @@ func (s *Server) handleAPIProject(w http.ResponseWriter, r *http.Request) {
 
 	case http.MethodPut:
 		var p database.Project
-		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON")
+		if !decodeJSON(w, r, &p) {
 			return
 		}
 		p.ID = id
@@ -198,19 +202,27 @@ func (s *Server) handleAPIProject(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
 
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
+// handleAPIProjectScans lists a project's scans, only those carrying the
+// tag given as ?tag= when set.
 func (s *Server) handleAPIProjectScans(w http.ResponseWriter, r *http.Request, projectID int64) {
 	scans, err := s.db.ListScansByProject(projectID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if scans == nil {
+	if tag := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("tag"))); tag != "" {
+		scans = slices.DeleteFunc(scans, func(sc database.Scan) bool { return !slices.Contains(sc.Tags, tag) })
+	}
+	if len(scans) == 0 {
 		scans = []database.Scan{}
 	}
+	for i := range scans {
+		s.executor.AnnotateScan(&scans[i])
+	}
 	writeJSON(w, http.StatusOK, scans)
 }
 
@@ -220,12 +232,163 @@ func (s *Server) handleAPIProjectResults(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	results = filterInteresting(r, results)
 	if results == nil {
 		results = []database.Result{}
 	}
+	annotateResultAges(results)
 	writeJSON(w, http.StatusOK, results)
 }
 
+// handleAPIProjectEntities correlates a project's results into one record per
+// domain and IP address, for an attack-surface view.
+func (s *Server) handleAPIProjectEntities(w http.ResponseWriter, r *http.Request, projectID int64) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	project, err := s.db.GetProject(projectID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if project == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	scans, err := s.db.ListScansByProject(projectID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	results, err := s.db.GetResultsByProject(projectID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"project_id": projectID,
+		"entities":   scanner.CorrelateEntities(scans, results),
+	})
+}
+
+// handleAPIProjectPin toggles whether a project is pinned to the top of lists.
+func (s *Server) handleAPIProjectPin(w http.ResponseWriter, r *http.Request, projectID int64) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	pinned, err := s.db.ToggleProjectPinned(projectID)
+	if errors.Is(err, database.ErrNotFound) {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": projectID, "pinned": pinned})
+}
+
+// handleAPIProjectReportArchive streams every report for a project as a zip.
+func (s *Server) handleAPIProjectReportArchive(w http.ResponseWriter, r *http.Request, projectID int64) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	project, err := s.db.GetProject(projectID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if project == nil {
+		writeError(w, http.StatusNotFound, "project not found")
+		return
+	}
+
+	reports, err := s.db.ListReportsByProject(projectID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	name := strings.ReplaceAll(strings.ToLower(project.Name), " ", "-")
+	filename := fmt.Sprintf("%s-reports-%s.zip", name, time.Now().In(s.cfg.Location()).Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// on a write error the archive is left unfinished, without its central
+	// directory, so the client can't mistake it for a complete one
+	zw := zip.NewWriter(w)
+
+	used := make(map[string]bool)
+	for _, rpt := range reports {
+		if rpt.FilePath != "" {
+			if err := addFileToZip(zw, rpt.FilePath, uniqueZipName(used, filepath.Base(rpt.FilePath))); err != nil {
+				slog.Warn("skipping report in archive", "report_id", rpt.ID, "error", err)
+			}
+			continue
+		}
+
+		// Reports stored only inline need their content loaded separately
+		full, err := s.db.GetReport(rpt.ID)
+		if err != nil || full == nil || full.Content == "" {
+			continue
+		}
+		entry, err := zw.Create(uniqueZipName(used, fmt.Sprintf("report-%d.%s", rpt.ID, reportExtension(rpt.Format))))
+		if err != nil {
+			slog.Error("zip entry error", "report_id", rpt.ID, "error", err)
+			return
+		}
+		if _, err := entry.Write([]byte(full.Content)); err != nil {
+			slog.Error("zip entry error", "report_id", rpt.ID, "error", err)
+			return
+		}
+	}
+	if err := zw.Close(); err != nil {
+		slog.Error("zip archive error", "project_id", projectID, "error", err)
+	}
+}
+
+// reportExtension is the file extension the report generator gives reports
+// of format.
+func reportExtension(format string) string {
+	switch format {
+	case "markdown":
+		return "md"
+	case "stix":
+		return "json"
+	}
+	return format
+}
+
+func addFileToZip(zw *zip.Writer, path, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	entry, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+// uniqueZipName returns name, suffixed if needed so no two entries collide.
+func uniqueZipName(used map[string]bool, name string) string {
+	candidate := name
+	ext := filepath.Ext(name)
+	for i := 2; used[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	used[candidate] = true
+	return candidate
+}
+
 func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := s.db.GetStats()
 	if err != nil {
@@ -235,31 +398,201 @@ func (s *Server) handleAPIStats(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, stats)
 }
 
+const (
+	defaultActivityLimit = 20
+	maxActivityLimit     = 100
+)
+
+// handleAPIActivity handles GET /api/activity?limit=..., the dashboard's feed
+// of recent scan, project and report events, newest first.
+func (s *Server) handleAPIActivity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	limit := defaultActivityLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxActivityLimit {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxActivityLimit))
+			return
+		}
+		limit = n
+	}
+
+	events, err := s.db.RecentActivity(limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range events {
+		switch a := &events[i]; a.Type {
+		case "project_created":
+			a.Link = fmt.Sprintf("/api/projects/%d", a.ID)
+		case "report_generated":
+			a.Link = fmt.Sprintf("/api/reports/%d/download", a.ID)
+		default:
+			a.Link = fmt.Sprintf("/api/scans/%d", a.ID)
+		}
+	}
+	if events == nil {
+		events = []database.Activity{}
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
 // --- Scan API ---
 
+const (
+	maxScanLabelLen = 200
+	maxScanTags     = 20
+	maxScanTagLen   = 40
+)
+
+// normalizeScanTags lower-cases and trims tags, dropping empty ones and
+// duplicates. Tags may hold letters, digits, spaces and . _ : - only, so the
+// comma the database stores them with can't appear in one.
+func normalizeScanTags(tags []string) ([]string, error) {
+	out := []string{}
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || slices.Contains(out, t) {
+			continue
+		}
+		if len(t) > maxScanTagLen {
+			return nil, fmt.Errorf("tag %q is longer than %d characters", t, maxScanTagLen)
+		}
+		for _, c := range t {
+			if !unicode.IsLetter(c) && !unicode.IsDigit(c) && !strings.ContainsRune(" ._:-", c) {
+				return nil, fmt.Errorf("tag %q may only contain letters, digits, spaces and . _ : -", t)
+			}
+		}
+		out = append(out, t)
+	}
+	if len(out) > maxScanTags {
+		return nil, fmt.Errorf("a scan can have at most %d tags", maxScanTags)
+	}
+	return out, nil
+}
+
 func (s *Server) handleAPIScans(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodPost:
-		var scan database.Scan
-		if err := json.NewDecoder(r.Body).Decode(&scan); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON")
+		var req struct {
+			database.Scan
+			DryRun bool `json:"dry_run"`
+		}
+		if !decodeJSON(w, r, &req) {
 			return
 		}
+		scan := req.Scan
 		if scan.Target == "" || scan.Tool == "" || scan.ScanType == "" {
 			writeError(w, http.StatusBadRequest, "target, tool, and scan_type are required")
 			return
 		}
+		scan.Label = strings.TrimSpace(scan.Label)
+		if len(scan.Label) > maxScanLabelLen {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("label must be at most %d characters", maxScanLabelLen))
+			return
+		}
+		tags, err := normalizeScanTags(scan.Tags)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		scan.Tags = tags
+		if req.DryRun {
+			plan, err := s.executor.PlanScan(&scan)
+			if err != nil {
+				writeErrorCode(w, http.StatusBadRequest, scanErrorCode(err), err.Error())
+				return
+			}
+			writeJSON(w, http.StatusOK, plan)
+			return
+		}
 		if err := s.executor.StartScan(&scan); err != nil {
+			var rejected *scanner.RejectedError
+			if errors.As(err, &rejected) {
+				writeErrorCode(w, http.StatusBadRequest, scanErrorCode(err), err.Error())
+				return
+			}
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
 		writeJSON(w, http.StatusCreated, scan)
 
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
+// handleAPIScanRaw serves a scan's raw tool output as a plain-text download.
+func (s *Server) handleAPIScanRaw(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	scan, err := s.db.GetScan(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if scan == nil {
+		writeError(w, http.StatusNotFound, "scan not found")
+		return
+	}
+	if scan.RawOutput == "" && scan.RawOutputFile == "" {
+		writeError(w, http.StatusNotFound, "scan has no raw output")
+		return
+	}
+
+	name := fmt.Sprintf("%s-%s-%d.txt", safeFilename(scan.Tool), safeFilename(scan.Target), scan.ID)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if scan.RawOutputFile == "" {
+		w.Write([]byte(scan.RawOutput))
+		return
+	}
+
+	// large output lives in a file; stream it rather than loading it
+	f, err := os.Open(scan.RawOutputFile)
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		if os.IsNotExist(err) {
+			writeError(w, http.StatusNotFound, "raw output file is missing")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer f.Close()
+	var modTime time.Time
+	if fi, err := f.Stat(); err == nil {
+		modTime = fi.ModTime()
+	}
+	http.ServeContent(w, r, name, modTime, f)
+}
+
+// safeFilename replaces anything but letters, digits, dots and dashes so a
+// scan target can be used in a download name.
+func safeFilename(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, s)
+	s = strings.Trim(s, "._")
+	if len(s) > 64 {
+		s = s[:64]
+	}
+	if s == "" {
+		return "scan"
+	}
+	return s
+}
+
 func (s *Server) handleAPIScan(w http.ResponseWriter, r *http.Request) {
 	idStr := strings.TrimPrefix(r.URL.Path, "/api/scans/")
 	if idStr == "" {
@@ -294,13 +627,20 @@ func (s *Server) handleAPIScan(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
+		results = filterInteresting(r, results)
 		if results == nil {
 			results = []database.Result{}
 		}
+		annotateResultAges(results)
 		writeJSON(w, http.StatusOK, results)
 		return
 	}
 
+	if len(parts) > 1 && parts[1] == "raw" {
+		s.handleAPIScanRaw(w, r, id)
+		return
+	}
+
 	switch r.Method {
 	case http.MethodGet:
 		scan, err := s.db.GetScan(id)
@@ -312,6 +652,40 @@ func (s *Server) handleAPIScan(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "scan not found")
 			return
 		}
+		s.executor.AnnotateScan(scan)
+		writeJSON(w, http.StatusOK, scan)
+
+	case http.MethodPatch:
+		var req struct {
+			Tags *[]string `json:"tags"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Tags == nil {
+			writeError(w, http.StatusBadRequest, "tags is required")
+			return
+		}
+		tags, err := normalizeScanTags(*req.Tags)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		err = s.db.SetScanTags(id, tags)
+		if errors.Is(err, database.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "scan not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		scan, err := s.db.GetScan(id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.executor.AnnotateScan(scan)
 		writeJSON(w, http.StatusOK, scan)
 
 	case http.MethodDelete:
@@ -319,7 +693,218 @@ func (s *Server) handleAPIScan(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusOK, map[string]string{"status": "cancelled"})
 
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// --- Result API ---
+
+// handleAPIResults searches findings across every project.
+func (s *Server) handleAPIResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q := r.URL.Query()
+	filter := database.ResultFilter{
+		ResultType: q.Get("result_type"),
+		Key:        q.Get("key"),
+		Value:      q.Get("value"),
+		Limit:      100,
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > 1000 {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		filter.Limit = n
+	}
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "invalid offset")
+			return
+		}
+		filter.Offset = n
+	}
+
+	results, err := s.db.SearchResults(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if results == nil {
+		results = []database.ProjectResult{}
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+const (
+	maxSearchTermLen = 200
+	searchLimit      = 20 // hits per category
+)
+
+// searchHit is one match in a global search, with the API link to open it.
+type searchHit struct {
+	ID        int64  `json:"id"`
+	Title     string `json:"title"`
+	Detail    string `json:"detail,omitempty"`
+	ProjectID int64  `json:"project_id,omitempty"`
+	Link      string `json:"link"`
+}
+
+type searchResponse struct {
+	Query    string      `json:"query"`
+	Projects []searchHit `json:"projects"`
+	Scans    []searchHit `json:"scans"`
+	Results  []searchHit `json:"results"`
+}
+
+// handleAPISearch handles GET /api/search?q=..., matching q as a substring of
+// project names, descriptions and scope, scan targets and labels, and result
+// values across every project.
+func (s *Server) handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	term := strings.TrimSpace(r.URL.Query().Get("q"))
+	if term == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len(term) > maxSearchTermLen {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("q must be at most %d characters", maxSearchTermLen))
+		return
+	}
+
+	projects, err := s.db.SearchProjects(term, searchLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	scans, err := s.db.SearchScans(term, searchLimit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	results, err := s.db.SearchResults(database.ResultFilter{Value: term, Limit: searchLimit})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := searchResponse{
+		Query:    term,
+		Projects: []searchHit{},
+		Scans:    []searchHit{},
+		Results:  []searchHit{},
+	}
+	for _, p := range projects {
+		resp.Projects = append(resp.Projects, searchHit{
+			ID: p.ID, Title: p.Name, Detail: p.Description, ProjectID: p.ID,
+			Link: fmt.Sprintf("/api/projects/%d", p.ID),
+		})
+	}
+	for _, sc := range scans {
+		title := sc.Tool + " — " + sc.Target
+		if sc.Label != "" {
+			title = sc.Label + " (" + title + ")"
+		}
+		resp.Scans = append(resp.Scans, searchHit{
+			ID: sc.ID, Title: title, Detail: sc.Status, ProjectID: sc.ProjectID,
+			Link: fmt.Sprintf("/api/scans/%d", sc.ID),
+		})
+	}
+	for _, res := range results {
+		resp.Results = append(resp.Results, searchHit{
+			ID: res.ID, Title: res.Key + ": " + res.Value, Detail: res.ResultType + " from " + res.Tool + " on " + res.Target,
+			ProjectID: res.ProjectID, Link: fmt.Sprintf("/api/results/%d", res.ID),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleAPIResult handles /api/results/{id} and /api/results/{id}/...
+func (s *Server) handleAPIResult(w http.ResponseWriter, r *http.Request) {
+	idStr := strings.TrimPrefix(r.URL.Path, "/api/results/")
+	parts := strings.SplitN(idStr, "/", 2)
+	id, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid result id")
+		return
+	}
+
+	if len(parts) == 1 {
+		// any result by id reaches across projects, like /api/results
+		s.requireAPIKey(func(w http.ResponseWriter, r *http.Request) {
+			s.serveResult(w, r, id)
+		})(w, r)
+		return
+	}
+
+	if parts[1] == "flag" {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		interesting, err := s.db.ToggleResultInteresting(id)
+		if errors.Is(err, database.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "result not found")
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "interesting": interesting})
+		return
+	}
+
+	http.NotFound(w, r)
+}
+
+// serveResult answers GET /api/results/{id}.
+func (s *Server) serveResult(w http.ResponseWriter, r *http.Request, id int64) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	result, err := s.db.GetResult(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if result == nil {
+		writeError(w, http.StatusNotFound, "result not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// filterInteresting applies the ?interesting=true query filter.
+func filterInteresting(r *http.Request, results []database.Result) []database.Result {
+	if r.URL.Query().Get("interesting") != "true" {
+		return results
+	}
+	var filtered []database.Result
+	for _, res := range results {
+		if res.Interesting {
+			filtered = append(filtered, res)
+		}
+	}
+	return filtered
+}
+
+// annotateResultAges fills in how long ago each result was stored.
+func annotateResultAges(results []database.Result) {
+	now := time.Now()
+	for i := range results {
+		results[i].AgeSeconds = int64(now.Sub(results[i].CreatedAt).Seconds())
 	}
 }
 
@@ -331,9 +916,10 @@ func (s *Server) handleAPIReports(w http.ResponseWriter, r *http.Request) {
 		var req struct {
 			ProjectID int64  `json:"project_id"`
 			Format    string `json:"format"`
+			report.Options
+			report.DiffOptions
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeError(w, http.StatusBadRequest, "invalid JSON")
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		if req.ProjectID == 0 {
@@ -349,14 +935,42 @@ func (s *Server) handleAPIReports(w http.ResponseWriter, r *http.Request) {
 
 		switch req.Format {
 		case "markdown":
-			_, rpt, err = s.reportGen.SaveMarkdown(req.ProjectID)
+			_, rpt, err = s.reportGen.SaveMarkdown(req.ProjectID, req.Options)
 		case "pdf":
-			_, rpt, err = s.reportGen.SavePDF(req.ProjectID)
+			_, rpt, err = s.reportGen.SavePDF(req.ProjectID, req.Options)
+		case "json":
+			_, rpt, err = s.reportGen.SaveJSON(req.ProjectID, req.Options)
+		case "sarif":
+			_, rpt, err = s.reportGen.SaveSARIF(req.ProjectID, req.Options)
+		case "stix":
+			if req.RedactTargets {
+				writeError(w, http.StatusBadRequest, "redact_targets can't be used with stix: pseudonyms aren't observables")
+				return
+			}
+			_, rpt, err = s.reportGen.SaveSTIX(req.ProjectID, req.Options)
+		case "diff":
+			if req.RedactTargets {
+				writeError(w, http.StatusBadRequest, "redact_targets can't be used with diff: change reports are not redacted")
+				return
+			}
+			if err := req.DiffOptions.Validate(); err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			_, rpt, err = s.reportGen.SaveDiff(req.ProjectID, req.DiffOptions)
 		default:
-			writeError(w, http.StatusBadRequest, "format must be 'markdown' or 'pdf'")
+			writeError(w, http.StatusBadRequest, "format must be 'markdown', 'pdf', 'json', 'sarif', 'stix' or 'diff'")
 			return
 		}
 
+		if errors.Is(err, report.ErrProjectNotFound) {
+			writeError(w, http.StatusNotFound, "project not found")
+			return
+		}
+		if errors.Is(err, report.ErrInvalidDiff) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -364,7 +978,7 @@ func (s *Server) handleAPIReports(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusCreated, rpt)
 
 	default:
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 	}
 }
 
@@ -423,41 +1037,90 @@ func (s *Server) handleAPIReport(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		if rpt.Format == "markdown" && rpt.Content != "" {
-			w.Header().Set("Content-Type", "text/markdown")
-			w.Header().Set("Content-Disposition", "attachment; filename=report.md")
-			w.Write([]byte(rpt.Content))
+			// ServeContent gives inline reports the same Range, If-Range and
+			// conditional GET handling ServeFile provides for files on disk.
+			// Reports never change once written, so ID and timestamp make a
+			// stable ETag.
+			name := fmt.Sprintf("report-%d.md", rpt.ID)
+			w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename="+name)
+			w.Header().Set("ETag", fmt.Sprintf(`"report-%d-%d"`, rpt.ID, rpt.CreatedAt.Unix()))
+			http.ServeContent(w, r, name, rpt.CreatedAt, strings.NewReader(rpt.Content))
 			return
 		}
 		writeError(w, http.StatusNotFound, "report file not found")
 		return
 	}
 
-	// GET /api/reports/{id}
 	rpt, err := s.db.GetReport(id)
 	if err != nil || rpt == nil {
 		writeError(w, http.StatusNotFound, "report not found")
 		return
 	}
-	writeJSON(w, http.StatusOK, rpt)
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, rpt)
+
+	case http.MethodDelete:
+		if err := s.reportGen.DeleteReport(rpt); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
 }
 
 // --- Tool Status API ---
 
 func (s *Server) handleAPIToolStatus(w http.ResponseWriter, r *http.Request) {
-	statuses := tools.DetectAll()
+	timeout := time.Duration(s.cfg.Tools.DetectTimeoutMS) * time.Millisecond
+	statuses := tools.DetectAll(r.Context(), timeout, s.cfg.Tools.DetectConcurrency)
 	writeJSON(w, http.StatusOK, statuses)
 }
 
 // --- File Metadata Upload API ---
 
+// uploadTypeAllowed matches a detected MIME type against server.upload_types
+// patterns: exact types, "family/*" and "*".
+func uploadTypeAllowed(detected string, allowed []string) bool {
+	mediaType, _, _ := strings.Cut(detected, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	family, _, _ := strings.Cut(mediaType, "/")
+	for _, a := range allowed {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "*" || a == mediaType || a == family+"/*" {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *Server) handleAPIFileMetadata(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
-	if err := r.ParseMultipartForm(10 << 20); err != nil { // 10MB limit
-		writeError(w, http.StatusBadRequest, "file too large or invalid form data")
+	maxSize := s.cfg.Server.MaxUploadSize
+	tooLarge := fmt.Sprintf("file exceeds maximum upload size of %d bytes", maxSize)
+	if r.ContentLength > maxSize {
+		writeError(w, http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxSize)
+
+	// Large files spill to a temp file so they can be read by range
+	if err := r.ParseMultipartForm(min(maxSize, scanner.RangedReadThreshold)); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, tooLarge)
+			return
+		}
+		writeError(w, http.StatusBadRequest, "invalid form data")
 		return
 	}
 
@@ -468,22 +1131,47 @@ func (s *Server) handleAPIFileMetadata(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	data := make([]byte, header.Size)
-	if _, err := file.Read(data); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to read file")
+	if header.Size > maxSize {
+		writeError(w, http.StatusRequestEntityTooLarge, tooLarge)
+		return
+	}
+
+	if header.Size == 0 {
+		writeError(w, http.StatusBadRequest, "empty file")
+		return
+	}
+
+	// Check the sniffed type, not the client's Content-Type, before parsing
+	allowed := s.cfg.Server.UploadTypes
+	if len(allowed) == 0 {
+		allowed = scanner.MetadataFileTypes
+	}
+	detected, err := scanner.DetectFileTypeAt(file, header.Size)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !uploadTypeAllowed(detected, allowed) {
+		writeError(w, http.StatusUnsupportedMediaType,
+			fmt.Sprintf("file type %s is not accepted (allowed: %s)", detected, strings.Join(allowed, ", ")))
 		return
 	}
 
-	results, err := scanner.ExtractFileMetadata(header.Filename, data)
+	results, err := scanner.ExtractFileMetadataAt(header.Filename, file, header.Size)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]any{
-		"filename":  header.Filename,
-		"size":      header.Size,
-		"mime_type": http.DetectContentType(data),
-		"results":   results,
-	})
+	resp := map[string]any{
+		"filename": header.Filename,
+		"size":     header.Size,
+		"results":  results,
+	}
+	for _, res := range results {
+		if res.Key == "mime_type" || res.Key == "detected_type" {
+			resp[res.Key] = res.Value
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
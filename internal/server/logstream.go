@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// logBacklog is how many recent lines a new log stream client is sent.
+	logBacklog = 200
+	// logClientBuffer is how many lines may wait for a slow client before
+	// further ones are dropped.
+	logClientBuffer = 256
+	logKeepAlive    = 15 * time.Second
+)
+
+// logStream fans formatted log lines out to /api/logs/stream clients and
+// keeps the last few for clients that join later. It is only created when
+// server.log_stream is set.
+type logStream struct {
+	mu      sync.Mutex
+	backlog []string
+	clients map[*logClient]struct{}
+}
+
+type logClient struct {
+	lines   chan string
+	dropped int // guarded by logStream.mu
+}
+
+func newLogStream() *logStream {
+	return &logStream{clients: make(map[*logClient]struct{})}
+}
+
+// Write receives one record at a time from the slog.TextHandler that
+// handler sets up.
+func (ls *logStream) Write(p []byte) (int, error) {
+	line := strings.TrimRight(string(p), "\n")
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	if len(ls.backlog) == logBacklog {
+		ls.backlog = append(ls.backlog[:0], ls.backlog[1:]...)
+	}
+	ls.backlog = append(ls.backlog, line)
+	for c := range ls.clients {
+		if c.dropped > 0 {
+			select {
+			case c.lines <- fmt.Sprintf("(%d log lines dropped)", c.dropped):
+				c.dropped = 0
+			default:
+				c.dropped++
+				continue
+			}
+		}
+		select {
+		case c.lines <- line:
+		default:
+			c.dropped++
+		}
+	}
+	return len(p), nil
+}
+
+// handler returns a slog.Handler that logs to next and also to the stream.
+func (ls *logStream) handler(next slog.Handler) slog.Handler {
+	return teeHandler{next, slog.NewTextHandler(ls, &slog.HandlerOptions{Level: slog.LevelInfo})}
+}
+
+// subscribe registers a client, returning it with the backlog to send first.
+func (ls *logStream) subscribe() (*logClient, []string) {
+	c := &logClient{lines: make(chan string, logClientBuffer)}
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	ls.clients[c] = struct{}{}
+	return c, append([]string(nil), ls.backlog...)
+}
+
+func (ls *logStream) unsubscribe(c *logClient) {
+	ls.mu.Lock()
+	defer ls.mu.Unlock()
+	delete(ls.clients, c)
+}
+
+// teeHandler sends each record to two handlers.
+type teeHandler struct {
+	a, b slog.Handler
+}
+
+func (t teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return t.a.Enabled(ctx, level) || t.b.Enabled(ctx, level)
+}
+
+func (t teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var err error
+	if t.a.Enabled(ctx, r.Level) {
+		err = t.a.Handle(ctx, r.Clone())
+	}
+	if t.b.Enabled(ctx, r.Level) {
+		t.b.Handle(ctx, r.Clone())
+	}
+	return err
+}
+
+func (t teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return teeHandler{t.a.WithAttrs(attrs), t.b.WithAttrs(attrs)}
+}
+
+func (t teeHandler) WithGroup(name string) slog.Handler {
+	return teeHandler{t.a.WithGroup(name), t.b.WithGroup(name)}
+}
+
+// handleAPILogStream streams the server log as server-sent events: the
+// recent backlog, then each line as it is logged.
+func (s *Server) handleAPILogStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if s.logs == nil {
+		writeError(w, http.StatusNotFound, "log streaming is disabled; set server.log_stream to enable it")
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	c, backlog := s.logs.subscribe()
+	defer s.logs.unsubscribe(c)
+	for _, line := range backlog {
+		fmt.Fprintf(w, "data: %s\n\n", line)
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(logKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line := <-c.lines:
+			fmt.Fprintf(w, "data: %s\n\n", line)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
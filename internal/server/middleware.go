@@ -1,6 +1,8 @@
 package server
 
 import (
+	"crypto/subtle"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"strings"
@@ -42,23 +44,110 @@ func recoveryMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-func disclaimerMiddleware(next http.Handler) http.Handler {
+// requireAPIKey guards endpoints that cross engagement boundaries. The key is
+// taken from the X-API-Key header or an Authorization: Bearer token; while
+// server.api_key is unset the endpoint is disabled outright. A logged-in
+// session stands in for the key when security.login is configured.
+func (s *Server) requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.Security.Login.Enabled() && s.validSession(r) {
+			next(w, r)
+			return
+		}
+		if s.cfg.Server.APIKey == "" {
+			writeErrorCode(w, http.StatusForbidden, CodeAPIKeyNotConfigured, "this endpoint requires server.api_key to be configured")
+			return
+		}
+		if !s.validAPIKey(requestAPIKey(r)) {
+			writeErrorCode(w, http.StatusUnauthorized, CodeInvalidAPIKey, "invalid or missing API key")
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *Server) validAPIKey(key string) bool {
+	return key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(s.cfg.Server.APIKey)) == 1
+}
+
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	return strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
+
+// maxBodyMiddleware caps request bodies on API routes. The metadata upload
+// endpoint enforces its own, larger server.max_upload_size.
+func maxBodyMiddleware(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limit > 0 && strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/api/upload/metadata" {
+			if r.ContentLength > limit {
+				writeError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsMiddleware lets pages on the origins in security.allowed_origins call
+// the /api/ routes. It answers preflight requests itself, ahead of the login,
+// disclaimer and API-key checks, since browsers send them without
+// credentials. Requests from other origins get no CORS headers, so browsers
+// keep them same-origin as before.
+func corsMiddleware(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow static assets, the welcome page, and the accept endpoint through
-		path := r.URL.Path
-		if strings.HasPrefix(path, "/static/") ||
-			strings.HasPrefix(path, "/welcome") {
+		origin := r.Header.Get("Origin")
+		if !strings.HasPrefix(r.URL.Path, "/api/") || origin == "" || !originAllowed(origins, origin) {
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Check for the disclaimer cookie
-		cookie, err := r.Cookie("disclaimer_accepted")
-		if err != nil || cookie.Value != "true" {
-			http.Redirect(w, r, "/welcome", http.StatusSeeOther)
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Add("Vary", "Origin")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
+		h.Set("Access-Control-Expose-Headers", "Content-Disposition")
+		next.ServeHTTP(w, r)
+	})
+}
 
+func originAllowed(origins []string, origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, o := range origins {
+		if o == "*" || o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// readOnlyMiddleware rejects requests that would change state (anything but
+// GET, HEAD or OPTIONS on /api/) when security.read_only is set.
+func readOnlyMiddleware(enabled bool, next http.Handler) http.Handler {
+	if !enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+			default:
+				writeErrorCode(w, http.StatusForbidden, CodeReadOnly, "this instance is read-only")
+				return
+			}
+		}
 		next.ServeHTTP(w, r)
 	})
 }
@@ -72,3 +161,9 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.status = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap lets http.ResponseController reach the underlying writer's Flush
+// and Hijack.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
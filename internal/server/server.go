@@ -6,6 +6,8 @@ import (
 	"io/fs"
 	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/jamesruggles/reconsuite/internal/config"
 	"github.com/jamesruggles/reconsuite/internal/database"
@@ -23,24 +25,40 @@ type Server struct {
 	mux         *http.ServeMux
 	pages       map[string]*template.Template
 	welcomeTmpl *template.Template
+	loginTmpl   *template.Template
+	sessions    *sessionStore
+	disclaimer  *disclaimer
+	logs        *logStream // nil unless server.log_stream is set
+	backupMu    sync.Mutex // held while POST /api/backup runs
 }
 
 func New(cfg *config.Config, db *database.DB) (*Server, error) {
-	hub := NewHub()
+	hub := NewHub(cfg.Server.OutputBatchMS)
 
 	s := &Server{
 		cfg:       cfg,
 		db:        db,
 		hub:       hub,
-		executor:  scanner.NewExecutor(db, hub),
-		reportGen: report.NewGenerator(db, cfg.Reports.Directory),
+		executor:  scanner.NewExecutor(db, hub, cfg),
+		reportGen: report.NewGenerator(db, cfg.Reports.Directory, cfg.Location()),
 		mux:       http.NewServeMux(),
 		pages:     make(map[string]*template.Template),
+		sessions:  newSessionStore(time.Duration(cfg.Security.Login.SessionHours) * time.Hour),
 	}
 
 	if err := s.loadTemplates(); err != nil {
 		return nil, fmt.Errorf("loading templates: %w", err)
 	}
+	d, err := newDisclaimer(cfg.Security.Disclaimer)
+	if err != nil {
+		return nil, err
+	}
+	s.disclaimer = d
+
+	if cfg.Server.LogStream {
+		s.logs = newLogStream()
+		slog.SetDefault(slog.New(s.logs.handler(slog.Default().Handler())))
+	}
 
 	s.registerRoutes()
 	return s, nil
@@ -72,6 +90,12 @@ func (s *Server) loadTemplates() error {
 	}
 	s.welcomeTmpl = welcomeTmpl
 
+	loginTmpl, err := template.ParseFS(web.Templates, "templates/login.html")
+	if err != nil {
+		return fmt.Errorf("parsing login.html: %w", err)
+	}
+	s.loginTmpl = loginTmpl
+
 	return nil
 }
 
@@ -79,7 +103,9 @@ func (s *Server) ListenAndServe() error {
 	addr := fmt.Sprintf("%s:%d", s.cfg.Server.Host, s.cfg.Server.Port)
 	slog.Info("starting server", "addr", addr)
 
-	handler := recoveryMiddleware(securityHeaders(loggingMiddleware(disclaimerMiddleware(s.mux))))
+	handler := recoveryMiddleware(securityHeaders(loggingMiddleware(corsMiddleware(s.cfg.Security.AllowedOrigins,
+		s.requireAuth(s.disclaimerMiddleware(
+			readOnlyMiddleware(s.cfg.Security.ReadOnly, maxBodyMiddleware(s.cfg.Server.MaxBodySize, s.mux))))))))
 	return http.ListenAndServe(addr, handler)
 }
 
@@ -87,6 +113,10 @@ func (s *Server) registerRoutes() {
 	staticFS, _ := fs.Sub(web.Static, "static")
 	s.mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
 
+	// Login
+	s.mux.HandleFunc("/login", s.handleLogin)
+	s.mux.HandleFunc("/logout", s.handleLogout)
+
 	// Welcome / Disclaimer
 	s.mux.HandleFunc("/welcome", s.handleWelcome)
 	s.mux.HandleFunc("/welcome/accept", s.handleWelcomeAccept)
@@ -104,12 +134,19 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("/api/projects", s.handleAPIProjects)
 	s.mux.HandleFunc("/api/projects/", s.handleAPIProject)
 	s.mux.HandleFunc("/api/stats", s.handleAPIStats)
+	s.mux.HandleFunc("/api/activity", s.handleAPIActivity)
 	s.mux.HandleFunc("/api/scans", s.handleAPIScans)
 	s.mux.HandleFunc("/api/scans/", s.handleAPIScan)
+	s.mux.HandleFunc("/api/results", s.requireAPIKey(s.handleAPIResults))
+	s.mux.HandleFunc("/api/results/", s.handleAPIResult)
+	s.mux.HandleFunc("/api/search", s.requireAPIKey(s.handleAPISearch))
 	s.mux.HandleFunc("/api/reports", s.handleAPIReports)
 	s.mux.HandleFunc("/api/reports/", s.handleAPIReport)
 	s.mux.HandleFunc("/api/tools/status", s.handleAPIToolStatus)
 	s.mux.HandleFunc("/api/upload/metadata", s.handleAPIFileMetadata)
+	s.mux.HandleFunc("/api/logs/stream", s.requireAPIKey(s.handleAPILogStream))
+	s.mux.HandleFunc("/api/backup", s.requireAPIKey(s.handleAPIBackup))
+	s.mux.HandleFunc("/api/backup/latest/download", s.requireAPIKey(s.handleAPIBackupLatest))
 
 	// WebSocket
 	s.mux.HandleFunc("/ws", s.handleWebSocket)
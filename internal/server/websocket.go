@@ -5,71 +5,214 @@ import (
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/jamesruggles/reconsuite/internal/tools"
 )
 
+const (
+	// wsSendBuffer is how many messages may queue for a client before it is
+	// considered too slow and disconnected.
+	wsSendBuffer   = 256
+	wsWriteTimeout = 10 * time.Second
+
+	// A scan sending more than autoBatchLines lines within a second is
+	// switched to batching with autoBatchWindow for the rest of its run.
+	autoBatchLines  = 200
+	autoBatchWindow = 50 * time.Millisecond
+	// maxBatchLines flushes a batch early so one frame stays small.
+	maxBatchLines = 500
+)
+
+// wsClient is a subscribed connection with its own outbound queue, drained by
+// a dedicated writer goroutine so a slow client never blocks broadcasters.
+type wsClient struct {
+	conn      *websocket.Conn
+	send      chan []byte
+	closeOnce sync.Once
+}
+
+func (c *wsClient) writeLoop() {
+	for data := range c.send {
+		ctx, cancel := context.WithTimeout(context.Background(), wsWriteTimeout)
+		err := c.conn.Write(ctx, websocket.MessageText, data)
+		cancel()
+		if err != nil {
+			slog.Debug("ws write error", "error", err)
+			// Unblocks the handler's read loop, which unsubscribes us
+			c.conn.CloseNow()
+			return
+		}
+	}
+}
+
 // Hub manages WebSocket clients subscribed to scan output.
 type Hub struct {
 	mu      sync.RWMutex
-	clients map[int64]map[*websocket.Conn]struct{}
+	clients map[int64]map[*wsClient]struct{}
+
+	// batchWindow > 0 batches every scan's output, 0 batches only scans
+	// that exceed autoBatchLines, and < 0 never batches.
+	batchWindow time.Duration
+	batchMu     sync.Mutex
+	batches     map[int64]*outputBatch
 }
 
-func NewHub() *Hub {
+// outputBatch is one scan's pending lines and its line rate.
+type outputBatch struct {
+	lines    []tools.OutputLine
+	timer    *time.Timer
+	window   time.Duration // 0 until the scan is batched
+	rateFrom time.Time
+	rateN    int
+}
+
+// NewHub returns a hub batching output per server.output_batch_ms.
+func NewHub(batchMS int) *Hub {
 	return &Hub{
-		clients: make(map[int64]map[*websocket.Conn]struct{}),
+		clients:     make(map[int64]map[*wsClient]struct{}),
+		batchWindow: time.Duration(batchMS) * time.Millisecond,
+		batches:     make(map[int64]*outputBatch),
 	}
 }
 
-func (h *Hub) Subscribe(scanID int64, conn *websocket.Conn) {
+// Subscribe registers conn for a scan's output and starts its writer.
+func (h *Hub) Subscribe(scanID int64, conn *websocket.Conn) *wsClient {
+	c := &wsClient{conn: conn, send: make(chan []byte, wsSendBuffer)}
+	go c.writeLoop()
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if h.clients[scanID] == nil {
-		h.clients[scanID] = make(map[*websocket.Conn]struct{})
+		h.clients[scanID] = make(map[*wsClient]struct{})
 	}
-	h.clients[scanID][conn] = struct{}{}
+	h.clients[scanID][c] = struct{}{}
+	return c
 }
 
-func (h *Hub) Unsubscribe(scanID int64, conn *websocket.Conn) {
+// Unsubscribe removes a client and stops its writer. Safe to call repeatedly.
+func (h *Hub) Unsubscribe(scanID int64, c *wsClient) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 	if conns, ok := h.clients[scanID]; ok {
-		delete(conns, conn)
+		delete(conns, c)
 		if len(conns) == 0 {
 			delete(h.clients, scanID)
 		}
 	}
+	c.closeOnce.Do(func() { close(c.send) })
 }
 
+// Broadcast sends a line to every subscriber, either at once as a single
+// OutputLine message or, for a batched scan, coalesced with the lines that
+// follow it within the batch window into one JSON array. A done line flushes
+// the pending batch and is always sent on its own.
 func (h *Hub) Broadcast(scanID int64, line tools.OutputLine) {
-	h.mu.RLock()
-	conns := h.clients[scanID]
-	h.mu.RUnlock()
+	if h.batchWindow < 0 {
+		h.sendJSON(scanID, line)
+		return
+	}
+
+	h.batchMu.Lock()
+	defer h.batchMu.Unlock()
+
+	b := h.batches[scanID]
+	if line.Done {
+		if b != nil {
+			h.flushLocked(scanID, b)
+			delete(h.batches, scanID)
+		}
+		h.sendJSON(scanID, line)
+		return
+	}
+	if b == nil {
+		b = &outputBatch{window: h.batchWindow, rateFrom: time.Now()}
+		h.batches[scanID] = b
+	}
 
-	data, err := json.Marshal(line)
+	if b.window == 0 {
+		if now := time.Now(); now.Sub(b.rateFrom) > time.Second {
+			b.rateFrom, b.rateN = now, 0
+		}
+		b.rateN++
+		if b.rateN <= autoBatchLines {
+			h.sendJSON(scanID, line)
+			return
+		}
+		slog.Debug("batching websocket output", "scan_id", scanID)
+		b.window = autoBatchWindow
+	}
+
+	b.lines = append(b.lines, line)
+	if len(b.lines) >= maxBatchLines {
+		h.flushLocked(scanID, b)
+	} else if b.timer == nil {
+		b.timer = time.AfterFunc(b.window, func() {
+			h.batchMu.Lock()
+			defer h.batchMu.Unlock()
+			if h.batches[scanID] == b {
+				h.flushLocked(scanID, b)
+			}
+		})
+	}
+}
+
+// flushLocked sends a scan's pending lines as one array. batchMu must be held.
+func (h *Hub) flushLocked(scanID int64, b *outputBatch) {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.lines) == 0 {
+		return
+	}
+	h.sendJSON(scanID, b.lines)
+	b.lines = nil
+}
+
+// sendJSON queues v for every subscriber without blocking. Clients whose
+// queue is full are disconnected rather than stalling the scan.
+func (h *Hub) sendJSON(scanID int64, v any) {
+	data, err := json.Marshal(v)
 	if err != nil {
 		return
 	}
 
-	for conn := range conns {
-		err := conn.Write(context.Background(), websocket.MessageText, data)
-		if err != nil {
-			slog.Debug("ws write error", "error", err)
-			h.Unsubscribe(scanID, conn)
-			conn.Close(websocket.StatusNormalClosure, "")
+	var slow []*wsClient
+	h.mu.RLock()
+	for c := range h.clients[scanID] {
+		select {
+		case c.send <- data:
+		default:
+			slow = append(slow, c)
 		}
 	}
+	h.mu.RUnlock()
+
+	for _, c := range slow {
+		slog.Warn("dropping slow websocket client", "scan_id", scanID)
+		h.Unsubscribe(scanID, c)
+		go c.conn.Close(websocket.StatusPolicyViolation, "client too slow")
+	}
 }
 
 type wsSubscribeMsg struct {
-	ScanID int64 `json:"scan_id"`
+	ScanID int64  `json:"scan_id"`
+	APIKey string `json:"api_key,omitempty"`
 }
 
+// wsStatusUnauthorized is the close code sent when the API key is missing or wrong.
+const wsStatusUnauthorized websocket.StatusCode = 4401
+
 func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	// Without OriginPatterns the library only accepts same-origin handshakes.
+	// Pages allowed to call the API cross-origin may stream output too; full
+	// origins ("https://dash.example.com") match on scheme and host.
 	conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
-		OriginPatterns: []string{"*"},
+		OriginPatterns: append(slices.Clone(s.cfg.Server.AllowedOrigins), s.cfg.Security.AllowedOrigins...),
 	})
 	if err != nil {
 		slog.Error("ws accept error", "error", err)
@@ -89,12 +232,27 @@ func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.hub.Subscribe(msg.ScanID, conn)
-	defer s.hub.Unsubscribe(msg.ScanID, conn)
+	// With an API key configured, output is only streamed to clients that
+	// present it, either as ?api_key= on the handshake or in the subscribe
+	// message, or that are logged in.
+	loggedIn := s.cfg.Security.Login.Enabled() && s.validSession(r)
+	if s.cfg.Server.APIKey != "" && !loggedIn {
+		key := msg.APIKey
+		if key == "" {
+			key = r.URL.Query().Get("api_key")
+		}
+		if !s.validAPIKey(key) {
+			conn.Close(wsStatusUnauthorized, "unauthorized")
+			return
+		}
+	}
+
+	client := s.hub.Subscribe(msg.ScanID, conn)
+	defer s.hub.Unsubscribe(msg.ScanID, client)
 
 	// Check if scan already completed before we subscribed (race condition fix)
 	scan, err := s.db.GetScan(msg.ScanID)
-	if err == nil && scan != nil && (scan.Status == "completed" || scan.Status == "failed") {
+	if err == nil && scan != nil && scan.Finished() {
 		done := tools.OutputLine{Done: true}
 		if doneData, err := json.Marshal(done); err == nil {
 			conn.Write(r.Context(), websocket.MessageText, doneData)
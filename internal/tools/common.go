@@ -3,8 +3,11 @@ package tools
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 )
@@ -15,6 +18,9 @@ type ToolSpec struct {
 	BinaryName string
 	Args       []string
 	Timeout    time.Duration
+	// Env is added to the server's own environment for the tool, e.g.
+	// API keys or proxy settings the tool only reads from the environment.
+	Env map[string]string
 }
 
 // ToolResult captures the outcome of a tool execution.
@@ -32,13 +38,48 @@ type OutputLine struct {
 	Stream    string    `json:"stream"`
 	Line      string    `json:"line"`
 	Done      bool      `json:"done,omitempty"`
+	// Results, when set, is the number of results the scan has stored so
+	// far; builtins send it after each batch is written.
+	Results int `json:"results,omitempty"`
+}
+
+// ErrNotInstalled matches, via errors.Is, CheckInstalled's error for a
+// missing binary.
+var ErrNotInstalled = errors.New("tool not installed")
+
+// kindError keeps its own message while matching a sentinel error, so callers
+// can classify it without the sentinel's text leaking into the message.
+type kindError struct {
+	msg  string
+	kind error
+}
+
+func (e *kindError) Error() string        { return e.msg }
+func (e *kindError) Is(target error) bool { return target == e.kind }
+
+// EnvList renders env as sorted NAME=value entries for exec.Cmd.Env. Names
+// must be non-empty and free of "="; neither names nor values may hold
+// newlines or NULs, which could smuggle in further variables.
+func EnvList(env map[string]string) ([]string, error) {
+	list := make([]string, 0, len(env))
+	for k, v := range env {
+		if k == "" || strings.ContainsAny(k, "=\n\r\x00") {
+			return nil, fmt.Errorf("invalid environment variable name %q", k)
+		}
+		if strings.ContainsAny(v, "\n\r\x00") {
+			return nil, fmt.Errorf("environment variable %s: value contains a newline or NUL", k)
+		}
+		list = append(list, k+"="+v)
+	}
+	sort.Strings(list)
+	return list, nil
 }
 
 // CheckInstalled verifies that a tool binary exists on PATH.
 func CheckInstalled(binaryName string) (string, error) {
 	path, err := exec.LookPath(binaryName)
 	if err != nil {
-		return "", fmt.Errorf("%s is not installed or not on PATH", binaryName)
+		return "", &kindError{msg: fmt.Sprintf("%s is not installed or not on PATH", binaryName), kind: ErrNotInstalled}
 	}
 	return path, nil
 }
@@ -57,6 +98,13 @@ func Run(ctx context.Context, spec ToolSpec, output chan<- OutputLine) *ToolResu
 	start := time.Now()
 
 	cmd := exec.CommandContext(ctx, spec.BinaryName, spec.Args...)
+	if len(spec.Env) > 0 {
+		env, err := EnvList(spec.Env)
+		if err != nil {
+			return &ToolResult{ExitCode: -1, Error: err, Duration: time.Since(start)}
+		}
+		cmd.Env = append(os.Environ(), env...)
+	}
 
 	stdoutPipe, err := cmd.StdoutPipe()
 	if err != nil {
@@ -112,6 +160,11 @@ func Run(ctx context.Context, spec ToolSpec, output chan<- OutputLine) *ToolResu
 			exitCode = -1
 		}
 	}
+	// The kill from our own deadline looks like any other signal exit;
+	// report it as a timeout so callers can tell the two apart.
+	if waitErr != nil && ctx.Err() == context.DeadlineExceeded {
+		waitErr = fmt.Errorf("timed out after %s: %w", spec.Timeout, context.DeadlineExceeded)
+	}
 
 	return &ToolResult{
 		ExitCode: exitCode,
@@ -1,8 +1,19 @@
 package tools
 
 import (
+	"context"
+	"errors"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDetectTimeout bounds each version command DetectAll runs.
+	DefaultDetectTimeout = 3 * time.Second
+	// DefaultDetectConcurrency is how many version commands run at once.
+	DefaultDetectConcurrency = 4
 )
 
 type ToolStatus struct {
@@ -11,6 +22,9 @@ type ToolStatus struct {
 	Installed bool   `json:"installed"`
 	Path      string `json:"path,omitempty"`
 	Version   string `json:"version,omitempty"`
+	// TimedOut is set when the version command was killed for running past
+	// the detection timeout; the tool is installed but its version unknown.
+	TimedOut bool `json:"timed_out,omitempty"`
 }
 
 var requiredTools = []struct {
@@ -30,40 +44,73 @@ var requiredTools = []struct {
 	{"Netcat", "nc", "-h"},
 }
 
-func DetectAll() []ToolStatus {
-	var statuses []ToolStatus
-
-	for _, tool := range requiredTools {
-		status := ToolStatus{
-			Name:   tool.name,
-			Binary: tool.binary,
-		}
+// DetectAll looks up every required tool and runs its version command,
+// at most workers at a time and each for at most timeout; zero values use
+// DefaultDetectConcurrency and DefaultDetectTimeout. A command that hangs
+// (waiting on stdin, say) is killed and its tool marked TimedOut, so the
+// statuses of the others still come back. Cancelling ctx stops the rest.
+func DetectAll(ctx context.Context, timeout time.Duration, workers int) []ToolStatus {
+	if timeout <= 0 {
+		timeout = DefaultDetectTimeout
+	}
+	if workers <= 0 {
+		workers = DefaultDetectConcurrency
+	}
 
+	statuses := make([]ToolStatus, len(requiredTools))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, tool := range requiredTools {
+		statuses[i] = ToolStatus{Name: tool.name, Binary: tool.binary}
 		path, err := exec.LookPath(tool.binary)
 		if err != nil {
-			status.Installed = false
-		} else {
-			status.Installed = true
-			status.Path = path
-
-			if tool.versionArg != "" {
-				out, err := exec.Command(tool.binary, tool.versionArg).CombinedOutput()
-				if err == nil {
-					version := strings.TrimSpace(string(out))
-					if len(version) > 100 {
-						version = version[:100]
-					}
-					// Extract first line
-					if idx := strings.IndexByte(version, '\n'); idx > 0 {
-						version = version[:idx]
-					}
-					status.Version = version
-				}
-			}
+			continue
+		}
+		statuses[i].Installed = true
+		statuses[i].Path = path
+		if tool.versionArg == "" {
+			continue
 		}
 
-		statuses = append(statuses, status)
+		wg.Add(1)
+		go func(st *ToolStatus, binary, arg string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			st.Version, st.TimedOut = detectVersion(ctx, timeout, binary, arg)
+		}(&statuses[i], tool.binary, tool.versionArg)
 	}
-
+	wg.Wait()
 	return statuses
 }
+
+// detectVersion runs "binary arg" for at most timeout and returns the first
+// line of its output, or reports that it timed out.
+func detectVersion(ctx context.Context, timeout time.Duration, binary, arg string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, binary, arg)
+	// don't wait on children that keep the output pipe open after the kill
+	cmd.WaitDelay = time.Second
+	out, err := cmd.CombinedOutput()
+	if cmd.ProcessState == nil || !cmd.ProcessState.Exited() {
+		// never started, or killed
+		return "", ctx.Err() == context.DeadlineExceeded
+	}
+	if err != nil && !errors.Is(err, exec.ErrWaitDelay) {
+		return "", false
+	}
+	version := strings.TrimSpace(string(out))
+	if len(version) > 100 {
+		version = version[:100]
+	}
+	// Extract first line
+	if idx := strings.IndexByte(version, '\n'); idx > 0 {
+		version = version[:idx]
+	}
+	return version, false
+}
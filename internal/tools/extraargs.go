@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxExtraArgs caps how many arguments extra_args may add.
+const MaxExtraArgs = 32
+
+var (
+	extraNumber   = regexp.MustCompile(`^[0-9]{1,6}$`)
+	extraDuration = regexp.MustCompile(`^[0-9]{1,6}(ms|s|m|h)?$`)
+	extraCodes    = regexp.MustCompile(`^[0-9]{3}(,[0-9]{3})*$`)
+	extraLengths  = regexp.MustCompile(`^[0-9]{1,9}(,[0-9]{1,9})*$`)
+	extraToken    = regexp.MustCompile(`^[A-Za-z0-9._:/+-]{1,128}$`)
+	extraScripts  = regexp.MustCompile(`^[a-z0-9-]+(,[a-z0-9-]+)*$`)
+)
+
+// extraArgAllowlist lists, per tool, the flags extra_args may add. A nil
+// pattern marks a switch that takes no value; otherwise the value, given as
+// --flag=value or as the next argument, must match. Flags that read or write
+// local files, change the target or run commands are deliberately absent.
+var extraArgAllowlist = map[string]map[string]*regexp.Regexp{
+	"nmap": {
+		"-Pn": nil, "-n": nil, "-R": nil, "-F": nil, "-r": nil, "-v": nil,
+		"-T0": nil, "-T1": nil, "-T2": nil, "-T3": nil, "-T4": nil, "-T5": nil,
+		"-sV": nil, "-sC": nil, "--open": nil, "--reason": nil, "--traceroute": nil,
+		"--version-intensity": regexp.MustCompile(`^[0-9]$`),
+		"--top-ports":         extraNumber,
+		"--max-retries":       extraNumber,
+		"--min-rate":          extraNumber,
+		"--max-rate":          extraNumber,
+		"--host-timeout":      extraDuration,
+		"--scan-delay":        extraDuration,
+		"--script":            extraScripts, // names checked by ValidateNmapArgs
+	},
+	"gobuster": {
+		"-k": nil, "--no-tls-validation": nil,
+		"-r": nil, "--follow-redirect": nil,
+		"-e": nil, "--expanded": nil,
+		"-n": nil, "--no-status": nil,
+		"-t": extraNumber, "--threads": extraNumber,
+		"-s": extraCodes, "--status-codes": extraCodes,
+		"-b": extraCodes, "--status-codes-blacklist": extraCodes,
+		"-a": extraToken, "--useragent": extraToken,
+		"--delay":          extraDuration,
+		"--timeout":        extraDuration,
+		"--exclude-length": extraLengths,
+	},
+	"whatweb": {
+		"-v": nil, "--verbose": nil,
+		"-t": extraNumber, "--max-threads": extraNumber,
+		"-U": extraToken, "--user-agent": extraToken,
+		"--max-redirects":   extraNumber,
+		"--open-timeout":    extraNumber,
+		"--read-timeout":    extraNumber,
+		"--follow-redirect": regexp.MustCompile(`^(never|http-only|meta-only|same-site|always)$`),
+	},
+	"curl": {
+		"-k": nil, "--insecure": nil,
+		"--http1.1": nil, "--http2": nil, "--compressed": nil,
+		"-A": extraToken, "--user-agent": extraToken,
+		"--connect-timeout": extraNumber,
+		"--max-redirs":      extraNumber,
+	},
+}
+
+// ParseExtraArgs turns the extra_args parameter into arguments for tool. The
+// string is split on whitespace only, with no quoting or escapes, and every
+// flag must be on the tool's allowlist with a value matching its pattern.
+// Bare words that aren't a flag's value are rejected, so extra_args can't
+// add targets. An empty string yields no arguments.
+func ParseExtraArgs(tool, raw string) ([]string, error) {
+	fields := strings.Fields(raw)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	allowed, ok := extraArgAllowlist[tool]
+	if !ok {
+		return nil, fmt.Errorf("%s does not accept extra_args", tool)
+	}
+	if len(fields) > MaxExtraArgs {
+		return nil, fmt.Errorf("extra_args may add at most %d arguments", MaxExtraArgs)
+	}
+
+	var args []string
+	for i := 0; i < len(fields); i++ {
+		arg := fields[i]
+		if dangerousChars.MatchString(arg) {
+			return nil, fmt.Errorf("extra_args contains invalid characters: %s", arg)
+		}
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("extra_args: unexpected argument %q", arg)
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		pattern, ok := allowed[name]
+		if !ok {
+			return nil, fmt.Errorf("%s option %s is not allowed in extra_args", tool, name)
+		}
+		if pattern == nil {
+			if hasValue {
+				return nil, fmt.Errorf("%s option %s takes no value", tool, name)
+			}
+			args = append(args, name)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("%s option %s requires a value", tool, name)
+			}
+			i++
+			value = fields[i]
+			if dangerousChars.MatchString(value) {
+				return nil, fmt.Errorf("extra_args contains invalid characters: %s", value)
+			}
+		}
+		if !pattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid value for %s option %s: %q", tool, name, value)
+		}
+		args = append(args, name, value)
+	}
+	return args, nil
+}
@@ -0,0 +1,187 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// nmapDeniedOptions read or write arbitrary local files, or hand control to
+// something other than a scan.
+var nmapDeniedOptions = map[string]bool{
+	"--interactive":      true,
+	"--resume":           true,
+	"--stylesheet":       true,
+	"--webxml":           true,
+	"--datadir":          true,
+	"--servicedb":        true,
+	"--versiondb":        true,
+	"--excludefile":      true,
+	"-iL":                true,
+	"--script-args":      true,
+	"--script-args-file": true,
+	"--script-updatedb":  true,
+	"--script-trace":     true,
+	"--append-output":    true,
+}
+
+// nmapAllowedScripts are the NSE scripts and categories a scan may request.
+// Everything here is read-only and ships with nmap.
+var nmapAllowedScripts = map[string]bool{
+	"default":            true,
+	"safe":               true,
+	"discovery":          true,
+	"version":            true,
+	"banner":             true,
+	"http-title":         true,
+	"http-headers":       true,
+	"http-server-header": true,
+	"ssl-cert":           true,
+	"ssl-enum-ciphers":   true,
+	"ssh-hostkey":        true,
+	"dns-nsid":           true,
+	"smb-os-discovery":   true,
+}
+
+// ValidateNmapArgs rejects nmap options that would turn a scan into a file
+// read/write or code execution primitive: output to anything but stdout,
+// interactive mode, alternate data files, NSE scripts outside a read-only
+// allowlist, and script arguments, which only buildNmapSpec adds, after
+// checking them with ValidateNmapScriptArgs.
+func ValidateNmapArgs(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+
+		if nmapDeniedOptions[name] {
+			return fmt.Errorf("nmap option %s is not allowed", name)
+		}
+
+		switch {
+		case name == "--script":
+			if !hasValue {
+				if i+1 >= len(args) {
+					return fmt.Errorf("--script requires a value")
+				}
+				i++
+				value = args[i]
+			}
+			if err := validateNmapScripts(value); err != nil {
+				return err
+			}
+
+		case name == "-p":
+			if i+1 >= len(args) {
+				return fmt.Errorf("-p requires a value")
+			}
+			i++
+			if err := ValidatePortSpec(args[i]); err != nil {
+				return err
+			}
+
+		case strings.HasPrefix(arg, "-o") && len(arg) >= 3:
+			// -oX, -oN, -oA, ... take a filename either attached or as the
+			// next argument; only "-" (stdout) is allowed
+			dest := arg[3:]
+			if dest == "" {
+				if i+1 >= len(args) {
+					return fmt.Errorf("%s requires a value", arg)
+				}
+				i++
+				dest = args[i]
+			}
+			if dest != "-" {
+				return fmt.Errorf("nmap output must go to stdout, not %q", dest)
+			}
+		}
+	}
+	return nil
+}
+
+const maxNmapScriptArgs = 16
+
+// nmapScriptArgKind says what an allowed script argument's value may be.
+type nmapScriptArgKind int
+
+const (
+	// argWord is a host name, number, duration or flag: no slashes.
+	argWord nmapScriptArgKind = iota
+	// argText is free text such as a user agent, which may contain slashes.
+	argText
+	// argURLPath is a path requested from the target, starting with "/".
+	argURLPath
+)
+
+// nmapAllowedScriptArgs are the script arguments a scan may pass, for the
+// scripts in nmapAllowedScripts and the libraries they use. None of them
+// makes NSE read a file or add targets; arguments that do (dns-brute's
+// hostlist, userdb, passdb, ssh-hostkey.known-hosts, newtargets, ...) are
+// absent, including those of scripts only reachable through a category.
+var nmapAllowedScriptArgs = map[string]nmapScriptArgKind{
+	"timeout":             argWord,
+	"http.useragent":      argText,
+	"http.host":           argWord,
+	"http.max-body-size":  argWord,
+	"http.truncated-ok":   argWord,
+	"http-title.url":      argURLPath,
+	"http-headers.path":   argURLPath,
+	"http-headers.useget": argWord,
+	"banner.timeout":      argWord,
+	"ssh_hostkey":         argWord,
+	"tls.servername":      argWord,
+}
+
+// nmapScriptArgValueRe allows plain words, numbers, durations, host names,
+// user agents and URL paths; no quotes, commas, braces or backslashes, which
+// nmap reads as Lua table syntax.
+var nmapScriptArgValueRe = regexp.MustCompile(`^[A-Za-z0-9 _.:@%+/-]{1,128}$`)
+
+// ValidateNmapScriptArgs checks a comma-separated key=value list for
+// --script-args and returns it with surrounding spaces removed. Only the
+// names in nmapAllowedScriptArgs are accepted, and no value may name a
+// file: words may not contain "/", text may not start with "/", "~" or "."
+// and URL paths must start with "/"; none may contain "..".
+func ValidateNmapScriptArgs(spec string) (string, error) {
+	var pairs []string
+	for _, pair := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if !ok || key == "" {
+			return "", fmt.Errorf("script argument %q must be key=value", strings.TrimSpace(pair))
+		}
+		kind, allowed := nmapAllowedScriptArgs[key]
+		if !allowed {
+			return "", fmt.Errorf("script argument %q is not allowed", key)
+		}
+		if !nmapScriptArgValueRe.MatchString(value) {
+			return "", fmt.Errorf("script argument %s has an invalid value", key)
+		}
+		pathLike := strings.Contains(value, "..") || strings.HasPrefix(value, "~")
+		switch kind {
+		case argWord:
+			pathLike = pathLike || strings.Contains(value, "/") || strings.HasPrefix(value, ".")
+		case argText:
+			pathLike = pathLike || strings.ContainsAny(value[:1], "/.")
+		case argURLPath:
+			pathLike = pathLike || !strings.HasPrefix(value, "/") || strings.HasPrefix(value, "//")
+		}
+		if pathLike {
+			return "", fmt.Errorf("script argument %s looks like a file path, which is not allowed", key)
+		}
+		pairs = append(pairs, key+"="+value)
+	}
+	if len(pairs) > maxNmapScriptArgs {
+		return "", fmt.Errorf("at most %d script arguments are allowed", maxNmapScriptArgs)
+	}
+	return strings.Join(pairs, ","), nil
+}
+
+func validateNmapScripts(spec string) error {
+	for _, script := range strings.Split(spec, ",") {
+		script = strings.TrimSpace(script)
+		if !nmapAllowedScripts[script] {
+			return fmt.Errorf("nmap script %q is not allowed", script)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ValidatePort checks that port is a single TCP/UDP port number, 1-65535.
+func ValidatePort(port string) error {
+	if _, err := parsePort(port); err != nil {
+		return fmt.Errorf("invalid port %q: %w", port, err)
+	}
+	return nil
+}
+
+// ValidatePortSpec checks an nmap -p specification: comma-separated ports
+// and ranges ("22,80,443", "1-1024", open-ended "1024-" or "-" for all),
+// optionally switched between protocols with a T:, U: or S: prefix
+// ("U:53,T:80,443"). Service names and protocol numbers (P:) are not
+// accepted.
+func ValidatePortSpec(spec string) error {
+	spec = strings.ReplaceAll(spec, " ", "")
+	if spec == "" {
+		return fmt.Errorf("port specification is empty")
+	}
+	for _, item := range strings.Split(spec, ",") {
+		if err := validatePortItem(item); err != nil {
+			return fmt.Errorf("invalid port specification %q: %w", spec, err)
+		}
+	}
+	return nil
+}
+
+func validatePortItem(item string) error {
+	if len(item) >= 2 && item[1] == ':' {
+		switch item[0] {
+		case 'T', 'U', 'S':
+			item = item[2:]
+		default:
+			return fmt.Errorf("unknown protocol prefix %q", item[:2])
+		}
+	}
+	if item == "" {
+		return fmt.Errorf("empty port")
+	}
+	if item == "-" {
+		return nil
+	}
+
+	lo, hi, isRange := strings.Cut(item, "-")
+	if !isRange {
+		_, err := parsePort(item)
+		return err
+	}
+	first, last := 1, 65535
+	var err error
+	if lo != "" {
+		if first, err = parsePort(lo); err != nil {
+			return err
+		}
+	}
+	if hi != "" {
+		if last, err = parsePort(hi); err != nil {
+			return err
+		}
+	}
+	if first > last {
+		return fmt.Errorf("range %s is backwards", item)
+	}
+	return nil
+}
+
+func parsePort(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || strings.HasPrefix(s, "+") {
+		return 0, fmt.Errorf("%q is not a port number", s)
+	}
+	if n < 1 || n > 65535 {
+		return 0, fmt.Errorf("port %d is out of range 1-65535", n)
+	}
+	return n, nil
+}
@@ -1,8 +1,11 @@
 package tools
 
 import (
+	"errors"
 	"fmt"
 	"net"
+	"net/netip"
+	"net/url"
 	"regexp"
 	"strings"
 )
@@ -12,15 +15,24 @@ var (
 	dangerousChars = regexp.MustCompile("[;|&`$(){}\\[\\]!<>\\\\\"']")
 )
 
+// ErrInvalidTarget matches, via errors.Is, every error ValidateTarget and
+// ValidateURL return.
+var ErrInvalidTarget = errors.New("invalid target")
+
+// invalidTarget formats a validation message that matches ErrInvalidTarget.
+func invalidTarget(format string, args ...any) error {
+	return &kindError{msg: fmt.Sprintf(format, args...), kind: ErrInvalidTarget}
+}
+
 // ValidateTarget checks that a target is a valid IP, CIDR, or hostname.
 func ValidateTarget(target string) error {
 	target = strings.TrimSpace(target)
 	if target == "" {
-		return fmt.Errorf("target cannot be empty")
+		return invalidTarget("target cannot be empty")
 	}
 
 	if dangerousChars.MatchString(target) {
-		return fmt.Errorf("target contains invalid characters")
+		return invalidTarget("target contains invalid characters")
 	}
 
 	// Try IP address
@@ -32,20 +44,20 @@ func ValidateTarget(target string) error {
 	if _, ipNet, err := net.ParseCIDR(target); err == nil {
 		ones, bits := ipNet.Mask.Size()
 		if bits == 32 && ones < 16 {
-			return fmt.Errorf("CIDR range /%d is too large (minimum /16)", ones)
+			return invalidTarget("CIDR range /%d is too large (minimum /16)", ones)
 		}
 		if bits == 128 && ones < 48 {
-			return fmt.Errorf("IPv6 CIDR range /%d is too large (minimum /48)", ones)
+			return invalidTarget("IPv6 CIDR range /%d is too large (minimum /48)", ones)
 		}
 		return nil
 	}
 
 	// Try hostname
 	if !hostnameRegex.MatchString(target) {
-		return fmt.Errorf("invalid hostname: %s", target)
+		return invalidTarget("invalid hostname: %s", target)
 	}
 	if len(target) > 253 {
-		return fmt.Errorf("hostname too long")
+		return invalidTarget("hostname too long")
 	}
 
 	return nil
@@ -55,7 +67,7 @@ func ValidateTarget(target string) error {
 func ValidateURL(target string) error {
 	target = strings.TrimSpace(target)
 	if target == "" {
-		return fmt.Errorf("URL cannot be empty")
+		return invalidTarget("URL cannot be empty")
 	}
 
 	if dangerousChars.MatchString(target) {
@@ -70,17 +82,62 @@ func ValidateURL(target string) error {
 		cleaned = strings.ReplaceAll(cleaned, "_", "")
 		cleaned = strings.ReplaceAll(cleaned, "%", "")
 		if dangerousChars.MatchString(cleaned) {
-			return fmt.Errorf("URL contains invalid characters")
+			return invalidTarget("URL contains invalid characters")
 		}
 	}
 
 	if !strings.HasPrefix(target, "http://") && !strings.HasPrefix(target, "https://") {
-		return fmt.Errorf("URL must start with http:// or https://")
+		return invalidTarget("URL must start with http:// or https://")
 	}
 
 	return nil
 }
 
+// NormalizeTarget returns a target in canonical form, so the same
+// host or URL submitted two ways is stored the same way. URLs get a
+// lower-case scheme and host, lose a default port (80 for http, 443 for
+// https), a fragment and a bare "/" path; paths and queries are otherwise
+// kept, since case and trailing slashes there can matter. IP addresses and
+// CIDRs are written in their standard form, and anything else (hostnames,
+// host:port, lists) has its host part lower-cased. It does not validate;
+// callers validate the result, which is what gets stored and run.
+func NormalizeTarget(target string) string {
+	target = strings.TrimSpace(target)
+	if u, err := url.Parse(target); err == nil && u.Host != "" &&
+		(strings.EqualFold(u.Scheme, "http") || strings.EqualFold(u.Scheme, "https")) {
+		u.Scheme = strings.ToLower(u.Scheme)
+		host, port := strings.ToLower(u.Hostname()), u.Port()
+		if addr, err := netip.ParseAddr(host); err == nil {
+			host = addr.String()
+		}
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+		if port != "" && !(u.Scheme == "http" && port == "80") && !(u.Scheme == "https" && port == "443") {
+			host += ":" + port
+		}
+		u.Host = host
+		u.Fragment, u.RawFragment = "", ""
+		if u.Path == "/" {
+			u.Path, u.RawPath = "", ""
+		}
+		return u.String()
+	}
+
+	if addr, err := netip.ParseAddr(target); err == nil {
+		return addr.String()
+	}
+	if prefix, err := netip.ParsePrefix(target); err == nil {
+		return prefix.String()
+	}
+	host, path, _ := strings.Cut(target, "/")
+	host = strings.ToLower(host)
+	if path == "" {
+		return host
+	}
+	return host + "/" + path
+}
+
 // SanitizeArg strips any shell metacharacters from a single argument.
 func SanitizeArg(arg string) string {
 	return dangerousChars.ReplaceAllString(arg, "")
@@ -1,19 +1,34 @@
 package main
 
 import (
+	"bufio"
 	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/jamesruggles/reconsuite/internal/config"
 	"github.com/jamesruggles/reconsuite/internal/database"
 	"github.com/jamesruggles/reconsuite/internal/server"
+	"golang.org/x/crypto/bcrypt"
 )
 
 func main() {
 	configPath := flag.String("config", "config.yaml", "path to config file")
+	hashPassword := flag.Bool("hash-password", false, "read a password from stdin, print its bcrypt hash for security.login.password_hash and exit")
 	flag.Parse()
 
+	if *hashPassword {
+		if err := printPasswordHash(os.Stdin); err != nil {
+			fmt.Fprintln(os.Stderr, "hash-password:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelInfo})))
 
 	cfg, err := config.Load(*configPath)
@@ -21,8 +36,17 @@ func main() {
 		slog.Error("failed to load config", "error", err)
 		os.Exit(1)
 	}
+	if err := cfg.EnsureDirs(); err != nil {
+		slog.Error("failed to create data directories", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("using data directory", "path", cfg.DataDir, "database", cfg.Database.Path, "reports", cfg.Reports.Directory)
 
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.New(cfg.Database.Path, database.Options{
+		BusyTimeout:  time.Duration(cfg.Database.BusyTimeoutMS) * time.Millisecond,
+		MaxOpenConns: cfg.Database.MaxOpenConns,
+		CacheSizeKB:  cfg.Database.CacheSizeKB,
+	})
 	if err != nil {
 		slog.Error("failed to open database", "error", err)
 		os.Exit(1)
@@ -40,3 +64,21 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// printPasswordHash hashes the first line of r with bcrypt.
+func printPasswordHash(r io.Reader) error {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return fmt.Errorf("empty password")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(hash))
+	return nil
+}
@@ -51,10 +51,11 @@ async function loadProjects() {
     list.innerHTML = projects.map(p => `
         <div class="card">
             <div class="glow-card"></div>
-            <h3>${esc(p.name)}</h3>
+            <h3>${p.pinned ? '&#9733; ' : ''}${esc(p.name)}</h3>
             <p style="color: var(--text-secondary); margin-bottom: 8px;">${esc(p.description || '')}</p>
             <p style="font-family: var(--font-mono); font-size: 12px; color: var(--text-muted);">${esc(p.scope || 'No scope defined')}</p>
             <div style="margin-top: 12px;">
+                <button class="btn btn-sm" onclick="togglePinned(${p.id})">${p.pinned ? 'Unpin' : 'Pin'}</button>
                 <button class="btn btn-sm btn-danger" onclick="deleteProject(${p.id})">Delete</button>
             </div>
         </div>
@@ -62,6 +63,11 @@ async function loadProjects() {
     initGlowCards();
 }
 
+async function togglePinned(id) {
+    const resp = await fetch(`/api/projects/${id}/pin`, { method: 'POST' });
+    if (resp.ok) loadProjects();
+}
+
 async function deleteProject(id) {
     if (!confirm('Delete this project and all its data?')) return;
     const resp = await fetch(`/api/projects/${id}`, { method: 'DELETE' });
@@ -122,6 +128,8 @@ async function runScan(e, scanType) {
         tool,
         scan_type: scanType,
         project_id: projectId,
+        label: (document.getElementById('scan_label')?.value || '').trim(),
+        tags: (document.getElementById('scan_tags')?.value || '').split(',').map(t => t.trim()).filter(Boolean),
         parameters: JSON.stringify(params),
     };
 
@@ -146,7 +154,7 @@ async function runScan(e, scanType) {
 
     if (!resp.ok) {
         const err = await resp.json();
-        terminal.innerHTML = `<span class="line-stderr">Error: ${esc(err.error || 'Unknown error')}</span>\n`;
+        terminal.innerHTML = `<span class="line-stderr">Error: ${esc(errorMessage(err, 'Unknown error'))}</span>\n`;
         statusBadge.textContent = 'Failed';
         statusBadge.className = 'badge badge-failed';
         return;
@@ -158,8 +166,8 @@ async function runScan(e, scanType) {
     const markDone = (status) => {
         if (finished) return;
         finished = true;
-        statusBadge.textContent = status === 'completed' ? 'Completed' : 'Failed';
-        statusBadge.className = status === 'completed' ? 'badge badge-completed' : 'badge badge-failed';
+        statusBadge.textContent = statusLabel(status);
+        statusBadge.className = 'badge badge-' + status;
         loadScanResults(scan.id);
     };
 
@@ -168,19 +176,27 @@ async function runScan(e, scanType) {
     const ws = new WebSocket(`${wsProto}//${location.host}/ws`);
 
     ws.onopen = () => {
-        ws.send(JSON.stringify({ scan_id: scan.id }));
+        ws.send(JSON.stringify(wsSubscribeMessage(scan.id)));
     };
 
     ws.onmessage = (evt) => {
-        const msg = JSON.parse(evt.data);
-        if (msg.done) {
-            ws.close();
-            markDone('completed');
-            return;
-        }
-        if (!finished) {
+        let html = '';
+        for (const msg of wsMessages(evt)) {
+            if (msg.done) {
+                ws.close();
+                fetchScanStatus(scan.id).then(markDone);
+                break;
+            }
+            if (msg.results) {
+                // a builtin stored another batch of results
+                if (!finished) loadScanResults(scan.id);
+                continue;
+            }
             const cls = msg.stream === 'stderr' ? 'line-stderr' : 'line-stdout';
-            terminal.innerHTML += `<span class="${cls}">${esc(msg.line)}</span>\n`;
+            html += `<span class="${cls}">${esc(msg.line)}</span>\n`;
+        }
+        if (html && !finished) {
+            terminal.innerHTML += html;
             terminal.scrollTop = terminal.scrollHeight;
         }
     };
@@ -210,7 +226,7 @@ async function loadScanResults(scanId) {
             displayValue = esc(displayValue);
         }
         return `<tr>
-            <td><span class="badge badge-${badgeClass(r.result_type)}">${esc(r.result_type)}</span></td>
+            <td><span class="badge badge-${badgeClass(r.result_type)}">${esc(r.result_type)}</span>${staleBadge(r)}</td>
             <td style="font-family: var(--font-mono);">${esc(r.key)}</td>
             <td>${displayValue}</td>
         </tr>`;
@@ -222,7 +238,11 @@ function badgeClass(type) {
         port: 'running', dns: 'completed', whois: 'completed',
         header: 'pending', ssl: 'completed', os: 'running',
         google_dork: 'pending', osint_link: 'pending', raw: 'pending',
-        metadata: 'completed',
+        metadata: 'completed', takeover: 'failed', snmp: 'running',
+        resolution: 'completed', auth_portal: 'failed', cookie: 'pending',
+        clickjacking: 'failed', technology: 'running', http_method: 'failed',
+        caa: 'completed', ct: 'completed', dir_listing: 'failed', disallowed_live: 'failed',
+        summary: 'completed', timing: 'running',
     };
     return map[type] || 'pending';
 }
@@ -266,7 +286,7 @@ function quickScan(tool, inputId, scanType) {
     }).then(resp => {
         if (!resp.ok) {
             return resp.json().then(err => {
-                terminal.innerHTML = `<span class="line-stderr">Error: ${esc(err.error || 'Unknown error')}</span>\n`;
+                terminal.innerHTML = `<span class="line-stderr">Error: ${esc(errorMessage(err, 'Unknown error'))}</span>\n`;
                 statusBadge.textContent = 'Failed';
                 statusBadge.className = 'badge badge-failed';
                 throw new Error('scan failed');
@@ -279,8 +299,8 @@ function quickScan(tool, inputId, scanType) {
         const markDone = (status) => {
             if (finished) return;
             finished = true;
-            statusBadge.textContent = status === 'completed' ? 'Completed' : 'Failed';
-            statusBadge.className = status === 'completed' ? 'badge badge-completed' : 'badge badge-failed';
+            statusBadge.textContent = statusLabel(status);
+            statusBadge.className = 'badge badge-' + status;
             loadQAResults(scan.id);
             if (typeof initDashboard === 'function') initDashboard();
         };
@@ -290,19 +310,26 @@ function quickScan(tool, inputId, scanType) {
         const ws = new WebSocket(`${wsProto}//${location.host}/ws`);
 
         ws.onopen = () => {
-            ws.send(JSON.stringify({ scan_id: scan.id }));
+            ws.send(JSON.stringify(wsSubscribeMessage(scan.id)));
         };
 
         ws.onmessage = (evt) => {
-            const msg = JSON.parse(evt.data);
-            if (msg.done) {
-                ws.close();
-                markDone('completed');
-                return;
-            }
-            if (!finished) {
+            let html = '';
+            for (const msg of wsMessages(evt)) {
+                if (msg.done) {
+                    ws.close();
+                    fetchScanStatus(scan.id).then(markDone);
+                    break;
+                }
+                if (msg.results) {
+                    if (!finished) loadQAResults(scan.id);
+                    continue;
+                }
                 const cls = msg.stream === 'stderr' ? 'line-stderr' : 'line-stdout';
-                terminal.innerHTML += `<span class="${cls}">${esc(msg.line)}</span>\n`;
+                html += `<span class="${cls}">${esc(msg.line)}</span>\n`;
+            }
+            if (html && !finished) {
+                terminal.innerHTML += html;
                 terminal.scrollTop = terminal.scrollHeight;
             }
         };
@@ -314,6 +341,70 @@ function quickScan(tool, inputId, scanType) {
     }).catch(() => {});
 }
 
+// wsMessages reads a WebSocket frame, which holds either one output line or,
+// when the server batches a busy scan, an array of them.
+function wsMessages(evt) {
+    const data = JSON.parse(evt.data);
+    return Array.isArray(data) ? data : [data];
+}
+
+// wsSubscribeMessage builds the WebSocket subscribe frame. When the server has
+// an API key configured, store it with localStorage.setItem('reconsuite_api_key', ...).
+function wsSubscribeMessage(scanId) {
+    const msg = { scan_id: scanId };
+    const key = localStorage.getItem('reconsuite_api_key');
+    if (key) msg.api_key = key;
+    return msg;
+}
+
+// FINISHED_STATUSES are the scan statuses that end polling.
+const FINISHED_STATUSES = ['completed', 'failed', 'timed_out', 'cancelled'];
+
+function statusLabel(status) {
+    const labels = { completed: 'Completed', failed: 'Failed', timed_out: 'Timed Out', cancelled: 'Cancelled' };
+    return labels[status] || status;
+}
+
+// elapsedLabel renders a running scan's elapsed seconds as "45s" or "3m 12s".
+function elapsedLabel(seconds) {
+    if (seconds < 60) return `${seconds}s`;
+    const m = Math.floor(seconds / 60);
+    if (m < 60) return `${m}m ${seconds % 60}s`;
+    return `${Math.floor(m / 60)}h ${m % 60}m`;
+}
+
+// ageLabel renders a result's age_seconds as "4m ago", "3h ago" or "12d ago".
+function ageLabel(seconds) {
+    if (!seconds || seconds < 60) return 'just now';
+    const m = Math.floor(seconds / 60);
+    if (m < 60) return `${m}m ago`;
+    const h = Math.floor(m / 60);
+    if (h < 48) return `${h}h ago`;
+    return `${Math.floor(h / 24)}d ago`;
+}
+
+// staleBadge marks a result the latest scan of its tool and target no longer
+// reports.
+function staleBadge(r) {
+    return r.stale ? ' <span class="badge badge-pending" title="Not reported by the latest scan of this tool and target">stale</span>' : '';
+}
+
+// formatTime renders an API timestamp (UTC) in display.timezone when the
+// server sets one, and in the browser's zone otherwise.
+function formatTime(ts) {
+    const zone = document.querySelector('meta[name="display-timezone"]')?.content;
+    return new Date(ts).toLocaleString(undefined, zone ? { timeZone: zone, timeZoneName: 'short' } : undefined);
+}
+
+// fetchScanStatus looks up how a scan ended once its stream reports done.
+async function fetchScanStatus(scanId) {
+    try {
+        const resp = await fetch(`/api/scans/${scanId}`);
+        if (resp.ok) return (await resp.json()).status;
+    } catch (e) { /* fall through */ }
+    return 'completed';
+}
+
 async function pollScanStatus(scanId, statusBadge, terminal, isFinished, onDone) {
     for (let i = 0; i < 60; i++) {
         await new Promise(r => setTimeout(r, 500));
@@ -322,16 +413,27 @@ async function pollScanStatus(scanId, statusBadge, terminal, isFinished, onDone)
             const resp = await fetch(`/api/scans/${scanId}`);
             if (!resp.ok) continue;
             const scan = await resp.json();
-            if (scan.status === 'completed' || scan.status === 'failed') {
+            if (scan.status === 'queued') {
+                const ahead = scan.queued_ahead ? `, ${scan.queued_ahead} ahead` : '';
+                statusBadge.textContent = `Queued (#${scan.queue_position}${ahead})`;
+                i = 0; // queued time doesn't count against the polling window
+                continue;
+            }
+            if (scan.status === 'running' && /^(Queued|Running)/.test(statusBadge.textContent)) {
+                statusBadge.textContent = scan.elapsed_seconds ? `Running (${elapsedLabel(scan.elapsed_seconds)})` : 'Running';
+            }
+            if (FINISHED_STATUSES.includes(scan.status)) {
                 if (scan.raw_output && terminal.innerHTML.trim() === '') {
                     terminal.innerHTML = scan.raw_output.split('\n').map(l =>
                         `<span class="line-stdout">${esc(l)}</span>\n`
                     ).join('');
+                } else if (scan.raw_output_in_file && terminal.innerHTML.trim() === '') {
+                    terminal.innerHTML = `<span class="line-stdout">Output saved to file. <a href="/api/scans/${scan.id}/raw">Download raw output</a></span>\n`;
                 }
                 if (onDone) { onDone(scan.status); }
                 else {
-                    statusBadge.textContent = scan.status === 'completed' ? 'Completed' : 'Failed';
-                    statusBadge.className = scan.status === 'completed' ? 'badge badge-completed' : 'badge badge-failed';
+                    statusBadge.textContent = statusLabel(scan.status);
+                    statusBadge.className = 'badge badge-' + scan.status;
                     loadQAResults(scanId);
                     if (typeof initDashboard === 'function') initDashboard();
                 }
@@ -426,7 +528,7 @@ async function uploadFileMetadata(file) {
 
         if (!resp.ok) {
             const err = await resp.json();
-            terminal.innerHTML += `<span class="line-stderr">Error: ${esc(err.error || 'Upload failed')}</span>\n`;
+            terminal.innerHTML += `<span class="line-stderr">Error: ${esc(errorMessage(err, 'Upload failed'))}</span>\n`;
             statusBadge.textContent = 'Failed';
             statusBadge.className = 'badge badge-failed';
             return;
@@ -434,7 +536,7 @@ async function uploadFileMetadata(file) {
 
         const data = await resp.json();
         terminal.innerHTML += `<span class="line-stdout">Extracted ${data.results.length} metadata fields</span>\n`;
-        terminal.innerHTML += `<span class="line-stdout">MIME type: ${esc(data.mime_type)}</span>\n`;
+        terminal.innerHTML += `<span class="line-stdout">MIME type: ${esc(data.detected_type || data.mime_type)}</span>\n`;
         statusBadge.textContent = 'Completed';
         statusBadge.className = 'badge badge-completed';
 
@@ -447,6 +549,8 @@ async function uploadFileMetadata(file) {
                 if (r.key === 'gps_coordinates') {
                     const coords = r.value;
                     displayValue = `<a href="https://www.google.com/maps?q=${encodeURIComponent(coords)}" target="_blank" rel="noopener" style="color: var(--accent);">${esc(coords)}</a>`;
+                } else if (r.key.startsWith('gps_map_') && r.value.startsWith('https://')) {
+                    displayValue = `<a href="${esc(r.value)}" target="_blank" rel="noopener" style="color: var(--accent);">${esc(r.value)}</a>`;
                 }
                 return `<tr>
                     <td><span class="badge badge-completed">metadata</span></td>
@@ -489,6 +593,23 @@ function esc(text) {
     return div.innerHTML;
 }
 
+// Hints shown under API errors whose "code" a user can act on.
+const errorHints = {
+    tool_not_installed: 'Install the tool on the server or add it to PATH, then try again.',
+    invalid_target: 'Check the target: enter a hostname, IP address or URL.',
+    login_required: 'Your session has expired. Reload the page to sign in again.',
+    read_only: 'This instance is read-only; scans and uploads are disabled.',
+    payload_too_large: 'The file is larger than the server accepts.',
+    unsupported_media_type: 'This file type is not accepted for metadata extraction.',
+};
+
+// errorMessage turns an API error response into text for the terminal.
+function errorMessage(err, fallback) {
+    const msg = err.error || fallback;
+    const hint = errorHints[err.code];
+    return hint ? `${msg}\n${hint}` : msg;
+}
+
 // --- Glowing card effect ---
 let _glowHandler = null;
 
@@ -566,5 +687,6 @@ document.addEventListener('DOMContentLoaded', () => {
     loadProjects();
     loadProjectDropdown();
     loadDashboard();
+    updateToolOptions();
     initGlowCards();
 });